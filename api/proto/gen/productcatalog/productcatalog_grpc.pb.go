@@ -25,22 +25,44 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	ProductCatalogService_CreateProduct_FullMethodName = "/productcatalog.ProductCatalogService/CreateProduct"
-	ProductCatalogService_GetProduct_FullMethodName    = "/productcatalog.ProductCatalogService/GetProduct"
-	ProductCatalogService_UpdateProduct_FullMethodName = "/productcatalog.ProductCatalogService/UpdateProduct"
-	ProductCatalogService_DeleteProduct_FullMethodName = "/productcatalog.ProductCatalogService/DeleteProduct"
-	ProductCatalogService_ListProducts_FullMethodName  = "/productcatalog.ProductCatalogService/ListProducts"
+	ProductCatalogService_CreateProduct_FullMethodName       = "/productcatalog.ProductCatalogService/CreateProduct"
+	ProductCatalogService_GetProduct_FullMethodName          = "/productcatalog.ProductCatalogService/GetProduct"
+	ProductCatalogService_UpdateProduct_FullMethodName       = "/productcatalog.ProductCatalogService/UpdateProduct"
+	ProductCatalogService_DeleteProduct_FullMethodName       = "/productcatalog.ProductCatalogService/DeleteProduct"
+	ProductCatalogService_ListProducts_FullMethodName        = "/productcatalog.ProductCatalogService/ListProducts"
+	ProductCatalogService_StreamProducts_FullMethodName      = "/productcatalog.ProductCatalogService/StreamProducts"
+	ProductCatalogService_BatchCreateProducts_FullMethodName = "/productcatalog.ProductCatalogService/BatchCreateProducts"
+	ProductCatalogService_BatchUpdateProducts_FullMethodName = "/productcatalog.ProductCatalogService/BatchUpdateProducts"
+	ProductCatalogService_BatchDeleteProducts_FullMethodName = "/productcatalog.ProductCatalogService/BatchDeleteProducts"
+	ProductCatalogService_ImportProducts_FullMethodName      = "/productcatalog.ProductCatalogService/ImportProducts"
+	ProductCatalogService_WatchProducts_FullMethodName       = "/productcatalog.ProductCatalogService/WatchProducts"
+	ProductCatalogService_CreateProductType_FullMethodName   = "/productcatalog.ProductCatalogService/CreateProductType"
+	ProductCatalogService_GetProductType_FullMethodName      = "/productcatalog.ProductCatalogService/GetProductType"
+	ProductCatalogService_ListProductTypes_FullMethodName    = "/productcatalog.ProductCatalogService/ListProductTypes"
+	ProductCatalogService_UpdateProductType_FullMethodName   = "/productcatalog.ProductCatalogService/UpdateProductType"
+	ProductCatalogService_DeleteProductType_FullMethodName   = "/productcatalog.ProductCatalogService/DeleteProductType"
 )
 
 // ProductCatalogServiceClient is the client API for ProductCatalogService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProductCatalogServiceClient interface {
-	CreateProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*Product, error)
+	CreateProduct(ctx context.Context, in *CreateProductRequest, opts ...grpc.CallOption) (*Product, error)
 	GetProduct(ctx context.Context, in *GetProductRequest, opts ...grpc.CallOption) (*Product, error)
-	UpdateProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*Product, error)
+	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error)
 	DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductResponse, error)
 	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
+	StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (ProductCatalogService_StreamProductsClient, error)
+	BatchCreateProducts(ctx context.Context, in *BatchCreateProductsRequest, opts ...grpc.CallOption) (*BatchCreateProductsResponse, error)
+	BatchUpdateProducts(ctx context.Context, in *BatchUpdateProductsRequest, opts ...grpc.CallOption) (*BatchUpdateProductsResponse, error)
+	BatchDeleteProducts(ctx context.Context, in *BatchDeleteProductsRequest, opts ...grpc.CallOption) (*BatchDeleteProductsResponse, error)
+	ImportProducts(ctx context.Context, opts ...grpc.CallOption) (ProductCatalogService_ImportProductsClient, error)
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (ProductCatalogService_WatchProductsClient, error)
+	CreateProductType(ctx context.Context, in *CreateProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error)
+	GetProductType(ctx context.Context, in *GetProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error)
+	ListProductTypes(ctx context.Context, in *ListProductTypesRequest, opts ...grpc.CallOption) (*ListProductTypesResponse, error)
+	UpdateProductType(ctx context.Context, in *UpdateProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error)
+	DeleteProductType(ctx context.Context, in *DeleteProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error)
 }
 
 type productCatalogServiceClient struct {
@@ -51,7 +73,7 @@ func NewProductCatalogServiceClient(cc grpc.ClientConnInterface) ProductCatalogS
 	return &productCatalogServiceClient{cc}
 }
 
-func (c *productCatalogServiceClient) CreateProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*Product, error) {
+func (c *productCatalogServiceClient) CreateProduct(ctx context.Context, in *CreateProductRequest, opts ...grpc.CallOption) (*Product, error) {
 	out := new(Product)
 	err := c.cc.Invoke(ctx, ProductCatalogService_CreateProduct_FullMethodName, in, out, opts...)
 	if err != nil {
@@ -69,7 +91,7 @@ func (c *productCatalogServiceClient) GetProduct(ctx context.Context, in *GetPro
 	return out, nil
 }
 
-func (c *productCatalogServiceClient) UpdateProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*Product, error) {
+func (c *productCatalogServiceClient) UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error) {
 	out := new(Product)
 	err := c.cc.Invoke(ctx, ProductCatalogService_UpdateProduct_FullMethodName, in, out, opts...)
 	if err != nil {
@@ -96,15 +118,196 @@ func (c *productCatalogServiceClient) ListProducts(ctx context.Context, in *List
 	return out, nil
 }
 
+func (c *productCatalogServiceClient) StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (ProductCatalogService_StreamProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProductCatalogService_ServiceDesc.Streams[0], ProductCatalogService_StreamProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productCatalogServiceStreamProductsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ProductCatalogService_StreamProductsClient interface {
+	Recv() (*Product, error)
+	grpc.ClientStream
+}
+
+type productCatalogServiceStreamProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *productCatalogServiceStreamProductsClient) Recv() (*Product, error) {
+	m := new(Product)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *productCatalogServiceClient) BatchCreateProducts(ctx context.Context, in *BatchCreateProductsRequest, opts ...grpc.CallOption) (*BatchCreateProductsResponse, error) {
+	out := new(BatchCreateProductsResponse)
+	err := c.cc.Invoke(ctx, ProductCatalogService_BatchCreateProducts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) BatchUpdateProducts(ctx context.Context, in *BatchUpdateProductsRequest, opts ...grpc.CallOption) (*BatchUpdateProductsResponse, error) {
+	out := new(BatchUpdateProductsResponse)
+	err := c.cc.Invoke(ctx, ProductCatalogService_BatchUpdateProducts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) BatchDeleteProducts(ctx context.Context, in *BatchDeleteProductsRequest, opts ...grpc.CallOption) (*BatchDeleteProductsResponse, error) {
+	out := new(BatchDeleteProductsResponse)
+	err := c.cc.Invoke(ctx, ProductCatalogService_BatchDeleteProducts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) ImportProducts(ctx context.Context, opts ...grpc.CallOption) (ProductCatalogService_ImportProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProductCatalogService_ServiceDesc.Streams[1], ProductCatalogService_ImportProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productCatalogServiceImportProductsClient{stream}
+	return x, nil
+}
+
+type ProductCatalogService_ImportProductsClient interface {
+	Send(*Product) error
+	CloseAndRecv() (*ImportProductsResponse, error)
+	grpc.ClientStream
+}
+
+type productCatalogServiceImportProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *productCatalogServiceImportProductsClient) Send(m *Product) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *productCatalogServiceImportProductsClient) CloseAndRecv() (*ImportProductsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportProductsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *productCatalogServiceClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (ProductCatalogService_WatchProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProductCatalogService_ServiceDesc.Streams[2], ProductCatalogService_WatchProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productCatalogServiceWatchProductsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ProductCatalogService_WatchProductsClient interface {
+	Recv() (*ProductChange, error)
+	grpc.ClientStream
+}
+
+type productCatalogServiceWatchProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *productCatalogServiceWatchProductsClient) Recv() (*ProductChange, error) {
+	m := new(ProductChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *productCatalogServiceClient) CreateProductType(ctx context.Context, in *CreateProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error) {
+	out := new(ProductType)
+	err := c.cc.Invoke(ctx, ProductCatalogService_CreateProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) GetProductType(ctx context.Context, in *GetProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error) {
+	out := new(ProductType)
+	err := c.cc.Invoke(ctx, ProductCatalogService_GetProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) ListProductTypes(ctx context.Context, in *ListProductTypesRequest, opts ...grpc.CallOption) (*ListProductTypesResponse, error) {
+	out := new(ListProductTypesResponse)
+	err := c.cc.Invoke(ctx, ProductCatalogService_ListProductTypes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) UpdateProductType(ctx context.Context, in *UpdateProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error) {
+	out := new(ProductType)
+	err := c.cc.Invoke(ctx, ProductCatalogService_UpdateProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productCatalogServiceClient) DeleteProductType(ctx context.Context, in *DeleteProductTypeRequest, opts ...grpc.CallOption) (*ProductType, error) {
+	out := new(ProductType)
+	err := c.cc.Invoke(ctx, ProductCatalogService_DeleteProductType_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductCatalogServiceServer is the server API for ProductCatalogService service.
 // All implementations must embed UnimplementedProductCatalogServiceServer
 // for forward compatibility
 type ProductCatalogServiceServer interface {
-	CreateProduct(context.Context, *Product) (*Product, error)
+	CreateProduct(context.Context, *CreateProductRequest) (*Product, error)
 	GetProduct(context.Context, *GetProductRequest) (*Product, error)
-	UpdateProduct(context.Context, *Product) (*Product, error)
+	UpdateProduct(context.Context, *UpdateProductRequest) (*Product, error)
 	DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error)
 	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
+	StreamProducts(*StreamProductsRequest, ProductCatalogService_StreamProductsServer) error
+	BatchCreateProducts(context.Context, *BatchCreateProductsRequest) (*BatchCreateProductsResponse, error)
+	BatchUpdateProducts(context.Context, *BatchUpdateProductsRequest) (*BatchUpdateProductsResponse, error)
+	BatchDeleteProducts(context.Context, *BatchDeleteProductsRequest) (*BatchDeleteProductsResponse, error)
+	ImportProducts(ProductCatalogService_ImportProductsServer) error
+	WatchProducts(*WatchProductsRequest, ProductCatalogService_WatchProductsServer) error
+	CreateProductType(context.Context, *CreateProductTypeRequest) (*ProductType, error)
+	GetProductType(context.Context, *GetProductTypeRequest) (*ProductType, error)
+	ListProductTypes(context.Context, *ListProductTypesRequest) (*ListProductTypesResponse, error)
+	UpdateProductType(context.Context, *UpdateProductTypeRequest) (*ProductType, error)
+	DeleteProductType(context.Context, *DeleteProductTypeRequest) (*ProductType, error)
 	mustEmbedUnimplementedProductCatalogServiceServer()
 }
 
@@ -112,13 +315,13 @@ type ProductCatalogServiceServer interface {
 type UnimplementedProductCatalogServiceServer struct {
 }
 
-func (UnimplementedProductCatalogServiceServer) CreateProduct(context.Context, *Product) (*Product, error) {
+func (UnimplementedProductCatalogServiceServer) CreateProduct(context.Context, *CreateProductRequest) (*Product, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateProduct not implemented")
 }
 func (UnimplementedProductCatalogServiceServer) GetProduct(context.Context, *GetProductRequest) (*Product, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
-func (UnimplementedProductCatalogServiceServer) UpdateProduct(context.Context, *Product) (*Product, error) {
+func (UnimplementedProductCatalogServiceServer) UpdateProduct(context.Context, *UpdateProductRequest) (*Product, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
 }
 func (UnimplementedProductCatalogServiceServer) DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error) {
@@ -127,6 +330,39 @@ func (UnimplementedProductCatalogServiceServer) DeleteProduct(context.Context, *
 func (UnimplementedProductCatalogServiceServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
 }
+func (UnimplementedProductCatalogServiceServer) StreamProducts(*StreamProductsRequest, ProductCatalogService_StreamProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) BatchCreateProducts(context.Context, *BatchCreateProductsRequest) (*BatchCreateProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) BatchUpdateProducts(context.Context, *BatchUpdateProductsRequest) (*BatchUpdateProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) BatchDeleteProducts(context.Context, *BatchDeleteProductsRequest) (*BatchDeleteProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) ImportProducts(ProductCatalogService_ImportProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) WatchProducts(*WatchProductsRequest, ProductCatalogService_WatchProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) CreateProductType(context.Context, *CreateProductTypeRequest) (*ProductType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProductType not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) GetProductType(context.Context, *GetProductTypeRequest) (*ProductType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProductType not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) ListProductTypes(context.Context, *ListProductTypesRequest) (*ListProductTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProductTypes not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) UpdateProductType(context.Context, *UpdateProductTypeRequest) (*ProductType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProductType not implemented")
+}
+func (UnimplementedProductCatalogServiceServer) DeleteProductType(context.Context, *DeleteProductTypeRequest) (*ProductType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProductType not implemented")
+}
 func (UnimplementedProductCatalogServiceServer) mustEmbedUnimplementedProductCatalogServiceServer() {}
 
 // UnsafeProductCatalogServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -141,7 +377,7 @@ func RegisterProductCatalogServiceServer(s grpc.ServiceRegistrar, srv ProductCat
 }
 
 func _ProductCatalogService_CreateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Product)
+	in := new(CreateProductRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -153,7 +389,7 @@ func _ProductCatalogService_CreateProduct_Handler(srv interface{}, ctx context.C
 		FullMethod: ProductCatalogService_CreateProduct_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ProductCatalogServiceServer).CreateProduct(ctx, req.(*Product))
+		return srv.(ProductCatalogServiceServer).CreateProduct(ctx, req.(*CreateProductRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -177,7 +413,7 @@ func _ProductCatalogService_GetProduct_Handler(srv interface{}, ctx context.Cont
 }
 
 func _ProductCatalogService_UpdateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Product)
+	in := new(UpdateProductRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -189,7 +425,7 @@ func _ProductCatalogService_UpdateProduct_Handler(srv interface{}, ctx context.C
 		FullMethod: ProductCatalogService_UpdateProduct_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ProductCatalogServiceServer).UpdateProduct(ctx, req.(*Product))
+		return srv.(ProductCatalogServiceServer).UpdateProduct(ctx, req.(*UpdateProductRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -230,6 +466,218 @@ func _ProductCatalogService_ListProducts_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductCatalogService_StreamProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductCatalogServiceServer).StreamProducts(m, &productCatalogServiceStreamProductsServer{stream})
+}
+
+type ProductCatalogService_StreamProductsServer interface {
+	Send(*Product) error
+	grpc.ServerStream
+}
+
+type productCatalogServiceStreamProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *productCatalogServiceStreamProductsServer) Send(m *Product) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ProductCatalogService_BatchCreateProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).BatchCreateProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_BatchCreateProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).BatchCreateProducts(ctx, req.(*BatchCreateProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_BatchUpdateProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).BatchUpdateProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_BatchUpdateProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).BatchUpdateProducts(ctx, req.(*BatchUpdateProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_BatchDeleteProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).BatchDeleteProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_BatchDeleteProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).BatchDeleteProducts(ctx, req.(*BatchDeleteProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_ImportProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProductCatalogServiceServer).ImportProducts(&productCatalogServiceImportProductsServer{stream})
+}
+
+type ProductCatalogService_ImportProductsServer interface {
+	SendAndClose(*ImportProductsResponse) error
+	Recv() (*Product, error)
+	grpc.ServerStream
+}
+
+type productCatalogServiceImportProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *productCatalogServiceImportProductsServer) SendAndClose(m *ImportProductsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *productCatalogServiceImportProductsServer) Recv() (*Product, error) {
+	m := new(Product)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ProductCatalogService_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductCatalogServiceServer).WatchProducts(m, &productCatalogServiceWatchProductsServer{stream})
+}
+
+type ProductCatalogService_WatchProductsServer interface {
+	Send(*ProductChange) error
+	grpc.ServerStream
+}
+
+type productCatalogServiceWatchProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *productCatalogServiceWatchProductsServer) Send(m *ProductChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ProductCatalogService_CreateProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).CreateProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_CreateProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).CreateProductType(ctx, req.(*CreateProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_GetProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).GetProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_GetProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).GetProductType(ctx, req.(*GetProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_ListProductTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).ListProductTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_ListProductTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).ListProductTypes(ctx, req.(*ListProductTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_UpdateProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).UpdateProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_UpdateProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).UpdateProductType(ctx, req.(*UpdateProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductCatalogService_DeleteProductType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProductTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductCatalogServiceServer).DeleteProductType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductCatalogService_DeleteProductType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductCatalogServiceServer).DeleteProductType(ctx, req.(*DeleteProductTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProductCatalogService_ServiceDesc is the grpc.ServiceDesc for ProductCatalogService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -257,7 +705,55 @@ var ProductCatalogService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListProducts",
 			Handler:    _ProductCatalogService_ListProducts_Handler,
 		},
+		{
+			MethodName: "BatchCreateProducts",
+			Handler:    _ProductCatalogService_BatchCreateProducts_Handler,
+		},
+		{
+			MethodName: "BatchUpdateProducts",
+			Handler:    _ProductCatalogService_BatchUpdateProducts_Handler,
+		},
+		{
+			MethodName: "BatchDeleteProducts",
+			Handler:    _ProductCatalogService_BatchDeleteProducts_Handler,
+		},
+		{
+			MethodName: "CreateProductType",
+			Handler:    _ProductCatalogService_CreateProductType_Handler,
+		},
+		{
+			MethodName: "GetProductType",
+			Handler:    _ProductCatalogService_GetProductType_Handler,
+		},
+		{
+			MethodName: "ListProductTypes",
+			Handler:    _ProductCatalogService_ListProductTypes_Handler,
+		},
+		{
+			MethodName: "UpdateProductType",
+			Handler:    _ProductCatalogService_UpdateProductType_Handler,
+		},
+		{
+			MethodName: "DeleteProductType",
+			Handler:    _ProductCatalogService_DeleteProductType_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamProducts",
+			Handler:       _ProductCatalogService_StreamProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportProducts",
+			Handler:       _ProductCatalogService_ImportProducts_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchProducts",
+			Handler:       _ProductCatalogService_WatchProducts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "productcatalog.proto",
 }
@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// apiKeys holds the static API keys callers may present.
+// Only the hashes of the keys are kept, and a key is looked up by comparing
+// its hash with every other in constant time, so that the time a lookup takes
+// tells nothing about the keys.
+type apiKeys struct {
+	hashes [][sha256.Size]byte
+	names  []string
+}
+
+// newAPIKeys returns the API keys of the given map of keys to the names of their callers.
+func newAPIKeys(keys map[string]string) *apiKeys {
+	k := &apiKeys{}
+	for key, name := range keys {
+		k.hashes = append(k.hashes, sha256.Sum256([]byte(key)))
+		k.names = append(k.names, name)
+	}
+	return k
+}
+
+// lookup returns the identity of the caller presenting the given API key.
+func (k *apiKeys) lookup(key string) (*Identity, bool) {
+	hash := sha256.Sum256([]byte(key))
+	found := -1
+	for i := range k.hashes {
+		if subtle.ConstantTimeCompare(hash[:], k.hashes[i][:]) == 1 {
+			found = i
+		}
+	}
+	if found < 0 || key == "" {
+		return nil, false
+	}
+	return &Identity{Subject: k.names[found], Method: MethodAPIKey}, true
+}
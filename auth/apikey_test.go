@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys := newAPIKeys(map[string]string{"key1": "importer", "key2": "pricing"})
+	testCases := []struct {
+		name           string
+		input          string
+		expectedOutput *Identity
+	}{
+		{
+			name:           "first key",
+			input:          "key1",
+			expectedOutput: &Identity{Subject: "importer", Method: MethodAPIKey},
+		},
+		{
+			name:           "second key",
+			input:          "key2",
+			expectedOutput: &Identity{Subject: "pricing", Method: MethodAPIKey},
+		},
+		{
+			name:  "unknown key",
+			input: "key3",
+		},
+		{
+			name:  "prefix of a key",
+			input: "key",
+		},
+		{
+			name:  "empty key",
+			input: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, ok := keys.lookup(tc.input)
+			require.Equal(t, tc.expectedOutput != nil, ok)
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
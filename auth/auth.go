@@ -0,0 +1,211 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+//
+// Package auth authenticates the callers of the gRPC server.
+// Callers present either a JWT bearer token in the authorization metadata,
+// verified against a shared secret or the keys of a local JWKS file,
+// a static API key in the x-api-key metadata, or, over mutual TLS,
+// a client certificate the listener verified.
+//
+// The interceptors of an Authenticator reject the calls without valid credentials
+// with codes.Unauthenticated, and attach the identity of the caller to the context
+// of the others, where handlers find it with FromContext.
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys holding the credentials of a call.
+const (
+	authorizationKey = "authorization"
+	apiKeyKey        = "x-api-key"
+	bearerPrefix     = "bearer "
+)
+
+// Methods by which a caller can be authenticated.
+const (
+	MethodJWT               = "jwt"
+	MethodAPIKey            = "api_key"
+	MethodClientCertificate = "client_certificate"
+)
+
+// Identity is the authenticated caller of an RPC.
+type Identity struct {
+	// Subject names the caller: the sub claim of its JWT, the name of its API key,
+	// or the common name of its client certificate, or its whole subject without one.
+	Subject string
+	// Method is how the caller was authenticated, MethodJWT, MethodAPIKey or MethodClientCertificate.
+	Method string
+	// Roles are the roles claimed by the JWT of the caller, if any.
+	Roles []string
+	// CertificateSubject is the subject of the client certificate the TLS listener
+	// verified, as in "CN=importer,O=Acme", whichever the method. Empty without one.
+	CertificateSubject string
+}
+
+// identityKey is the context key of the identity of the caller.
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying the identity of the caller.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity of the caller carried by ctx, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// Config holds the credentials an Authenticator accepts.
+// At least one of Secret, JWKS, APIKeys and ClientCertificates must be set.
+type Config struct {
+	// Secret verifies JWTs signed with HMAC.
+	Secret []byte
+	// JWKS is a JSON Web Key Set whose keys verify JWTs signed with RSA, ECDSA or Ed25519.
+	JWKS []byte
+	// Issuer and Audience, when set, must match the iss and aud claims of JWTs.
+	Issuer   string
+	Audience string
+	// APIKeys maps each API key to the name of its caller.
+	APIKeys map[string]string
+	// ClientCertificates accepts the client certificates verified by the TLS listener
+	// from callers presenting neither a bearer token nor an API key.
+	ClientCertificates bool
+}
+
+// Authenticator authenticates the callers of RPCs.
+type Authenticator struct {
+	jwt                *jwtVerifier
+	apiKeys            *apiKeys
+	clientCertificates bool
+}
+
+// New creates an Authenticator accepting the credentials of the given config.
+func New(cfg Config) (*Authenticator, error) {
+	if len(cfg.Secret) == 0 && len(cfg.JWKS) == 0 && len(cfg.APIKeys) == 0 && !cfg.ClientCertificates {
+		return nil, errors.New("no JWT secret, JWKS, API keys or client certificates configured")
+	}
+	a := &Authenticator{apiKeys: newAPIKeys(cfg.APIKeys), clientCertificates: cfg.ClientCertificates}
+	if len(cfg.Secret) > 0 || len(cfg.JWKS) > 0 {
+		v, err := newJWTVerifier(cfg.Secret, cfg.JWKS, cfg.Issuer, cfg.Audience)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = v
+	}
+	return a, nil
+}
+
+// Authenticate returns the identity of the caller of the RPC whose incoming
+// metadata and peer ctx carries, failing with codes.Unauthenticated when its
+// credentials are missing or invalid.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	id, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cert := clientCertificate(ctx); cert != nil {
+		id.CertificateSubject = cert.Subject.String()
+	}
+	return id, nil
+}
+
+// authenticate returns the identity of the caller of the RPC, from its credentials.
+func (a *Authenticator) authenticate(ctx context.Context) (*Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(authorizationKey); len(values) > 0 {
+		if len(values) > 1 || !strings.HasPrefix(strings.ToLower(values[0]), bearerPrefix) {
+			return nil, status.Error(codes.Unauthenticated, "authorization must be a single bearer token")
+		}
+		if a.jwt == nil {
+			return nil, status.Error(codes.Unauthenticated, "bearer tokens are not accepted")
+		}
+		id, err := a.jwt.verify(strings.TrimSpace(values[0][len(bearerPrefix):]))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token: "+err.Error())
+		}
+		return id, nil
+	}
+	if values := md.Get(apiKeyKey); len(values) > 0 {
+		if len(values) > 1 {
+			return nil, status.Error(codes.Unauthenticated, "x-api-key must be a single API key")
+		}
+		id, ok := a.apiKeys.lookup(values[0])
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		return id, nil
+	}
+	if !a.clientCertificates {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token or API key")
+	}
+	cert := clientCertificate(ctx)
+	if cert == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token, API key or client certificate")
+	}
+	subject := cert.Subject.CommonName
+	if subject == "" {
+		subject = cert.Subject.String()
+	}
+	return &Identity{Subject: subject, Method: MethodClientCertificate}, nil
+}
+
+// clientCertificate returns the client certificate the TLS listener verified
+// for the RPC ctx belongs to, if any.
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+// UnaryServerInterceptor returns a server interceptor authenticating unary RPCs.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(NewContext(ctx, id), req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor authenticating streaming RPCs.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id, err := a.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: NewContext(ss.Context(), id)})
+	}
+}
+
+// serverStream is a grpc.ServerStream whose context carries the identity of the caller.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	require.Equal(t, "no JWT secret, JWKS, API keys or client certificates configured", err.Error())
+	_, err = New(Config{JWKS: []byte(`{}`)})
+	require.Equal(t, "JWKS has no signature keys", err.Error())
+	a, err := New(Config{APIKeys: map[string]string{"key": "importer"}})
+	require.Nil(t, err)
+	require.Nil(t, a.jwt)
+	_, err = New(Config{ClientCertificates: true})
+	require.Nil(t, err)
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, err := New(Config{Secret: testSecret, APIKeys: map[string]string{"key": "importer"}})
+	require.Nil(t, err)
+	apiKeysOnly, err := New(Config{APIKeys: map[string]string{"key": "importer"}})
+	require.Nil(t, err)
+	withClientCertificates, err := New(Config{APIKeys: map[string]string{"key": "importer"}, ClientCertificates: true})
+	require.Nil(t, err)
+	token := sign(jwt.SigningMethodHS256, "", testSecret, validClaims())
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "importer", Organization: []string{"Acme"}}}
+	testCases := []struct {
+		name           string
+		authenticator  *Authenticator
+		md             metadata.MD
+		cert           *x509.Certificate
+		expectedOutput *Identity
+		expectedError  error
+	}{
+		{
+			name:           "bearer token",
+			md:             metadata.Pairs("authorization", "Bearer "+token),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "bearer token in lowercase",
+			md:             metadata.Pairs("authorization", "bearer "+token),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "API key",
+			md:             metadata.Pairs("x-api-key", "key"),
+			expectedOutput: &Identity{Subject: "importer", Method: MethodAPIKey},
+		},
+		{
+			name:           "client certificate",
+			authenticator:  withClientCertificates,
+			md:             metadata.MD{},
+			cert:           cert,
+			expectedOutput: &Identity{Subject: "importer", Method: MethodClientCertificate, CertificateSubject: "CN=importer,O=Acme"},
+		},
+		{
+			name:           "client certificate without common name",
+			authenticator:  withClientCertificates,
+			md:             metadata.MD{},
+			cert:           &x509.Certificate{Subject: pkix.Name{Organization: []string{"Acme"}}},
+			expectedOutput: &Identity{Subject: "O=Acme", Method: MethodClientCertificate, CertificateSubject: "O=Acme"},
+		},
+		{
+			name:           "API key over mutual TLS",
+			authenticator:  withClientCertificates,
+			md:             metadata.Pairs("x-api-key", "key"),
+			cert:           &x509.Certificate{Subject: pkix.Name{CommonName: "gateway"}},
+			expectedOutput: &Identity{Subject: "importer", Method: MethodAPIKey, CertificateSubject: "CN=gateway"},
+		},
+		{
+			name:          "client certificate not accepted",
+			md:            metadata.MD{},
+			cert:          cert,
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = missing bearer token or API key"),
+		},
+		{
+			name:          "no credentials",
+			md:            metadata.MD{},
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = missing bearer token or API key"),
+		},
+		{
+			name:          "no credentials nor client certificate",
+			authenticator: withClientCertificates,
+			md:            metadata.MD{},
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = missing bearer token, API key or client certificate"),
+		},
+		{
+			name:          "invalid bearer token",
+			md:            metadata.Pairs("authorization", "Bearer token"),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = invalid bearer token: token is malformed: token contains an invalid number of segments"),
+		},
+		{
+			name:          "basic authorization",
+			md:            metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = authorization must be a single bearer token"),
+		},
+		{
+			name:          "several bearer tokens",
+			md:            metadata.Pairs("authorization", "Bearer "+token, "authorization", "Bearer "+token),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = authorization must be a single bearer token"),
+		},
+		{
+			name:          "bearer token without JWT verification",
+			authenticator: apiKeysOnly,
+			md:            metadata.Pairs("authorization", "Bearer "+token),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = bearer tokens are not accepted"),
+		},
+		{
+			name:          "invalid API key",
+			md:            metadata.Pairs("x-api-key", "other"),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = invalid API key"),
+		},
+		{
+			name:          "several API keys",
+			md:            metadata.Pairs("x-api-key", "key", "x-api-key", "key"),
+			expectedError: errors.New("rpc error: code = Unauthenticated desc = x-api-key must be a single API key"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := a
+			if tc.authenticator != nil {
+				authenticator = tc.authenticator
+			}
+			ctx := metadata.NewIncomingContext(context.TODO(), tc.md)
+			if tc.cert != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tc.cert}}},
+				}})
+			}
+			output, err := authenticator.Authenticate(ctx)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with the given context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	a, err := New(Config{APIKeys: map[string]string{"key": "importer"}})
+	require.Nil(t, err)
+	authenticated := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("x-api-key", "key"))
+	anonymous := metadata.NewIncomingContext(context.TODO(), metadata.MD{})
+
+	// Handlers find the identity of the caller in their context.
+	unary := a.UnaryServerInterceptor()
+	response, err := unary(authenticated, "request", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		id, ok := FromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, "importer", id.Subject)
+		return "response", nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, "response", response)
+	stream := a.StreamServerInterceptor()
+	err = stream(nil, &fakeServerStream{ctx: authenticated}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		id, ok := FromContext(ss.Context())
+		require.True(t, ok)
+		require.Equal(t, "importer", id.Subject)
+		return nil
+	})
+	require.Nil(t, err)
+
+	// And are not called without one.
+	called := false
+	_, err = unary(anonymous, "request", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	require.Equal(t, "rpc error: code = Unauthenticated desc = missing bearer token or API key", err.Error())
+	err = stream(nil, &fakeServerStream{ctx: anonymous}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	require.Equal(t, "rpc error: code = Unauthenticated desc = missing bearer token or API key", err.Error())
+	require.False(t, called)
+
+	_, ok := FromContext(context.TODO())
+	require.False(t, ok)
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// Bearer tokens must be signed, expire, and name their subject. Tokens signed with
+// HMAC are verified with the shared secret, and the others with the key of the JWKS
+// whose kid their header names, or its only key when they name none. A key only
+// verifies the algorithms of its type, or the one its alg names.
+
+// clockSkew is the leeway given to the time based claims of tokens.
+const clockSkew = 30 * time.Second
+
+var (
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	rsaMethods  = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+)
+
+// minRSAKeyBits is the minimum size of the RSA keys of a JWKS.
+const minRSAKeyBits = 2048
+
+// tokenClaims are the claims of a bearer token.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+// verificationKey is a key of a JWKS.
+type verificationKey struct {
+	kid     string
+	key     interface{}
+	methods []string
+}
+
+// jwtVerifier verifies bearer tokens.
+type jwtVerifier struct {
+	secret []byte
+	keys   []verificationKey
+	parser *jwt.Parser
+}
+
+// newJWTVerifier returns a verifier of the tokens signed with the given secret,
+// or with the keys of the given JWKS, and issued by issuer for audience when set.
+func newJWTVerifier(secret, jwks []byte, issuer, audience string) (*jwtVerifier, error) {
+	v := &jwtVerifier{secret: secret}
+	var methods []string
+	if len(secret) > 0 {
+		methods = append(methods, hmacMethods...)
+	}
+	if len(jwks) > 0 {
+		keys, err := parseJWKS(jwks)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		for _, k := range keys {
+			methods = append(methods, k.methods...)
+		}
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(clockSkew)}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// verify returns the identity of the caller presenting the given token.
+func (v *jwtVerifier) verify(token string) (*Identity, error) {
+	claims := &tokenClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no expiration")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return &Identity{Subject: claims.Subject, Method: MethodJWT, Roles: claims.Roles}, nil
+}
+
+// key returns the key verifying the signature of a token.
+func (v *jwtVerifier) key(t *jwt.Token) (interface{}, error) {
+	alg := t.Method.Alg()
+	if contains(hmacMethods, alg) {
+		return v.secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	for _, k := range v.keys {
+		if (k.kid == kid || kid == "" && len(v.keys) == 1) && contains(k.methods, alg) {
+			return k.key, nil
+		}
+	}
+	return nil, errors.Errorf(`no key with kid "%s" verifies %s`, kid, alg)
+}
+
+// jsonWebKey is a key of a JSON Web Key Set, as defined by RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the signature keys of a JSON Web Key Set.
+// Keys meant for encryption are ignored.
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrap(err, "parsing JWKS")
+	}
+	var keys []verificationKey
+	for i, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, methods, err := jwk.publicKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing key %d of JWKS", i)
+		}
+		if jwk.Alg != "" {
+			if !contains(methods, jwk.Alg) {
+				return nil, errors.Errorf(`parsing key %d of JWKS: algorithm "%s" does not match key type "%s"`, i, jwk.Alg, jwk.Kty)
+			}
+			methods = []string{jwk.Alg}
+		}
+		keys = append(keys, verificationKey{kid: jwk.Kid, key: key, methods: methods})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signature keys")
+	}
+	return keys, nil
+}
+
+// publicKey returns the public key a JSON Web Key holds,
+// and the signing methods it verifies.
+func (jwk jsonWebKey) publicKey() (interface{}, []string, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt("n", jwk.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := decodeBigInt("e", jwk.E)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, nil, errors.New("invalid RSA exponent")
+		}
+		if n.BitLen() < minRSAKeyBits {
+			return nil, nil, errors.Errorf("RSA keys must have at least %d bits", minRSAKeyBits)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, rsaMethods, nil
+	case "EC":
+		var curve elliptic.Curve
+		var method string
+		switch jwk.Crv {
+		case "P-256":
+			curve, method = elliptic.P256(), "ES256"
+		case "P-384":
+			curve, method = elliptic.P384(), "ES384"
+		case "P-521":
+			curve, method = elliptic.P521(), "ES512"
+		default:
+			return nil, nil, errors.Errorf(`unsupported curve "%s"`, jwk.Crv)
+		}
+		x, err := decodeBigInt("x", jwk.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		y, err := decodeBigInt("y", jwk.Y)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, []string{method}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, nil, errors.Errorf(`unsupported curve "%s"`, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), []string{"EdDSA"}, nil
+	}
+	return nil, nil, errors.Errorf(`unsupported key type "%s"`, jwk.Kty)
+}
+
+// decodeBigInt decodes the base64url encoded big-endian integer of the named JSON Web Key member.
+func decodeBigInt(name, s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(b) == 0 {
+		return nil, errors.Errorf(`invalid "%s"`, name)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// contains reports whether values holds v.
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "productcatalog"
+)
+
+var (
+	testSecret   = []byte("0123456789abcdef0123456789abcdef")
+	testRSAKey   = mustRSAKey()
+	testECKey    = mustECKey()
+	_, testEdKey = mustEdKey()
+	testJWKS     = jwks(rsaJWK("rsa", testRSAKey), ecJWK("ec", testECKey), edJWK("ed", testEdKey))
+	otherRSAKey  = mustRSAKey()
+)
+
+// validClaims returns the claims of a valid token.
+func validClaims() jwt.MapClaims {
+	return claims("merchandiser", time.Hour)
+}
+
+func mustRSAKey() *rsa.PrivateKey {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func mustECKey() *ecdsa.PrivateKey {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func mustEdKey() (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return pub, k
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, k *rsa.PrivateKey) string {
+	return fmt.Sprintf(`{"kty":"RSA","kid":%q,"use":"sig","n":%q,"e":%q}`, kid, b64(k.N.Bytes()), b64(big.NewInt(int64(k.E)).Bytes()))
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) string {
+	return fmt.Sprintf(`{"kty":"EC","kid":%q,"crv":"P-256","x":%q,"y":%q}`, kid, b64(k.X.Bytes()), b64(k.Y.Bytes()))
+}
+
+func edJWK(kid string, k ed25519.PrivateKey) string {
+	return fmt.Sprintf(`{"kty":"OKP","kid":%q,"crv":"Ed25519","x":%q}`, kid, b64(k.Public().(ed25519.PublicKey)))
+}
+
+func jwks(keys ...string) []byte {
+	set := `{"keys":[`
+	for i, k := range keys {
+		if i > 0 {
+			set += ","
+		}
+		set += k
+	}
+	return []byte(set + `]}`)
+}
+
+func claims(subject string, expiresIn time.Duration) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(expiresIn).Unix(),
+		"roles": []string{"merchandiser"},
+	}
+	if subject != "" {
+		c["sub"] = subject
+	}
+	return c
+}
+
+// sign returns a token with the given claims, signed with key by method,
+// naming kid in its header when set.
+func sign(method jwt.SigningMethod, kid string, key interface{}, c jwt.Claims) string {
+	t := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		t.Header["kid"] = kid
+	}
+	s, err := t.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestJWTVerifier(t *testing.T) {
+	withoutExpiration := validClaims()
+	delete(withoutExpiration, "exp")
+	otherIssuer := validClaims()
+	otherIssuer["iss"] = "https://other.example.com"
+	testCases := []struct {
+		name           string
+		token          string
+		expectedOutput *Identity
+		expectedError  error
+	}{
+		{
+			name:           "HMAC",
+			token:          sign(jwt.SigningMethodHS256, "", testSecret, validClaims()),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "RSA",
+			token:          sign(jwt.SigningMethodRS256, "rsa", testRSAKey, validClaims()),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "RSA-PSS",
+			token:          sign(jwt.SigningMethodPS384, "rsa", testRSAKey, validClaims()),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "ECDSA",
+			token:          sign(jwt.SigningMethodES256, "ec", testECKey, validClaims()),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:           "Ed25519",
+			token:          sign(jwt.SigningMethodEdDSA, "ed", testEdKey, validClaims()),
+			expectedOutput: &Identity{Subject: "merchandiser", Method: MethodJWT, Roles: []string{"merchandiser"}},
+		},
+		{
+			name:          "wrong secret",
+			token:         sign(jwt.SigningMethodHS256, "", []byte("wrong"), validClaims()),
+			expectedError: errors.New("token signature is invalid: signature is invalid"),
+		},
+		{
+			name:          "unknown key",
+			token:         sign(jwt.SigningMethodRS256, "other", otherRSAKey, validClaims()),
+			expectedError: errors.New(`token is unverifiable: error while executing keyfunc: no key with kid "other" verifies RS256`),
+		},
+		{
+			name:          "key of another type",
+			token:         sign(jwt.SigningMethodRS256, "ec", testRSAKey, validClaims()),
+			expectedError: errors.New(`token is unverifiable: error while executing keyfunc: no key with kid "ec" verifies RS256`),
+		},
+		{
+			name:          "without kid when there are several keys",
+			token:         sign(jwt.SigningMethodRS256, "", testRSAKey, validClaims()),
+			expectedError: errors.New(`token is unverifiable: error while executing keyfunc: no key with kid "" verifies RS256`),
+		},
+		{
+			name:          "unsigned",
+			token:         sign(jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, validClaims()),
+			expectedError: errors.New("token signature is invalid: signing method none is invalid"),
+		},
+		{
+			name:          "expired",
+			token:         sign(jwt.SigningMethodHS256, "", testSecret, claims("merchandiser", -time.Hour)),
+			expectedError: errors.New("token has invalid claims: token is expired"),
+		},
+		{
+			name:          "without expiration",
+			token:         sign(jwt.SigningMethodHS256, "", testSecret, withoutExpiration),
+			expectedError: errors.New("token has no expiration"),
+		},
+		{
+			name:          "without subject",
+			token:         sign(jwt.SigningMethodHS256, "", testSecret, claims("", time.Hour)),
+			expectedError: errors.New("token has no subject"),
+		},
+		{
+			name:          "other issuer",
+			token:         sign(jwt.SigningMethodHS256, "", testSecret, otherIssuer),
+			expectedError: errors.New("token has invalid claims: token has invalid issuer"),
+		},
+		{
+			name:          "malformed",
+			token:         "token",
+			expectedError: errors.New("token is malformed: token contains an invalid number of segments"),
+		},
+	}
+	v, err := newJWTVerifier(testSecret, testJWKS, testIssuer, testAudience)
+	require.Nil(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := v.verify(tc.token)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierWithoutSecret(t *testing.T) {
+	// Without a secret, tokens signed with HMAC are rejected, even with the public
+	// key of the JWKS as secret. The only key of a JWKS verifies tokens without kid.
+	v, err := newJWTVerifier(nil, jwks(rsaJWK("rsa", testRSAKey)), "", "")
+	require.Nil(t, err)
+	_, err = v.verify(sign(jwt.SigningMethodHS256, "rsa", testRSAKey.N.Bytes(), validClaims()))
+	require.Equal(t, "token signature is invalid: signing method HS256 is invalid", err.Error())
+	id, err := v.verify(sign(jwt.SigningMethodRS512, "", testRSAKey, validClaims()))
+	require.Nil(t, err)
+	require.Equal(t, "merchandiser", id.Subject)
+}
+
+func TestParseJWKS(t *testing.T) {
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.Nil(t, err)
+	testCases := []struct {
+		name            string
+		input           []byte
+		expectedMethods [][]string
+		expectedError   error
+	}{
+		{
+			name:            "keys of every type",
+			input:           testJWKS,
+			expectedMethods: [][]string{rsaMethods, {"ES256"}, {"EdDSA"}},
+		},
+		{
+			name:            "algorithm restricting a key",
+			input:           jwks(`{"kty":"RSA","alg":"PS256","n":"` + b64(testRSAKey.N.Bytes()) + `","e":"AQAB"}`),
+			expectedMethods: [][]string{{"PS256"}},
+		},
+		{
+			name:            "encryption keys are ignored",
+			input:           jwks(`{"kty":"oct","use":"enc","k":"c2VjcmV0"}`, edJWK("ed", testEdKey)),
+			expectedMethods: [][]string{{"EdDSA"}},
+		},
+		{
+			name:          "invalid JSON",
+			input:         []byte(`{"keys":`),
+			expectedError: errors.New("parsing JWKS: unexpected end of JSON input"),
+		},
+		{
+			name:          "no keys",
+			input:         jwks(),
+			expectedError: errors.New("JWKS has no signature keys"),
+		},
+		{
+			name:          "unsupported key type",
+			input:         jwks(`{"kty":"oct","k":"c2VjcmV0"}`),
+			expectedError: errors.New(`parsing key 0 of JWKS: unsupported key type "oct"`),
+		},
+		{
+			name:          "algorithm of another key type",
+			input:         jwks(`{"kty":"OKP","crv":"Ed25519","alg":"RS256","x":"` + b64(testEdKey.Public().(ed25519.PublicKey)) + `"}`),
+			expectedError: errors.New(`parsing key 0 of JWKS: algorithm "RS256" does not match key type "OKP"`),
+		},
+		{
+			name:          "small RSA key",
+			input:         jwks(rsaJWK("small", smallRSAKey)),
+			expectedError: errors.New("parsing key 0 of JWKS: RSA keys must have at least 2048 bits"),
+		},
+		{
+			name:          "invalid modulus",
+			input:         jwks(`{"kty":"RSA","n":"!","e":"AQAB"}`),
+			expectedError: errors.New(`parsing key 0 of JWKS: invalid "n"`),
+		},
+		{
+			name:          "point not on the curve",
+			input:         jwks(`{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}`),
+			expectedError: errors.New("parsing key 0 of JWKS: point is not on the curve"),
+		},
+		{
+			name:          "unsupported curve",
+			input:         jwks(`{"kty":"EC","crv":"P-192","x":"AQ","y":"AQ"}`),
+			expectedError: errors.New(`parsing key 0 of JWKS: unsupported curve "P-192"`),
+		},
+		{
+			name:          "invalid Ed25519 key",
+			input:         jwks(`{"kty":"OKP","crv":"Ed25519","x":"AQ"}`),
+			expectedError: errors.New("parsing key 0 of JWKS: invalid Ed25519 public key"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := parseJWKS(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				var methods [][]string
+				for _, k := range output {
+					methods = append(methods, k.methods)
+				}
+				require.Equal(t, tc.expectedMethods, methods)
+			}
+		})
+	}
+}
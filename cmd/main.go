@@ -5,7 +5,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -13,14 +15,28 @@ import (
 	"syscall"
 
 	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/auth"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/config"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/mapper"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/server"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Storage backends that can be selected with the STORAGE_BACKEND
+// environment variable, or the -storage flag.
+const (
+	mongodbStorage = "mongodb"
+	boltStorage    = "bolt"
+	memoryStorage  = "memory"
 )
 
 // run is the main entry point for the gRPC server.
 // It sets up the server, initializes the necessary dependencies, and starts the server to listen for incoming requests.
-func run(log *log.Logger) error {
+func run(log *log.Logger, storage string) error {
 	log.Println("main: initializing gRPC server")
 	defer log.Println("main: Completed")
 
@@ -34,11 +50,32 @@ func run(log *log.Logger) error {
 		return errors.Wrap(err, "reading config")
 	}
 
+	// =========================================================================
+	// Transport security
+	serverOpts, err := tlsOptions(log, cfg)
+	if err != nil {
+		return err
+	}
+
+	// =========================================================================
+	// Authentication
+	authOpts, err := authOptions(log, cfg)
+	if err != nil {
+		return err
+	}
+	serverOpts = append(serverOpts, authOpts...)
+
 	// =========================================================================
 	// Database support
-	db, err := store.Connect(ctx, cfg.MongodbHostName, cfg.MongodbDatabase, cfg.MongodbPort)
+	if storage == "" {
+		storage = cfg.StorageBackend
+	}
+	repo, err := newRepository(ctx, log, cfg, storage)
 	if err != nil {
-		return errors.Wrap(err, "connecting to database")
+		return err
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
 	}
 
 	// =========================================================================
@@ -51,7 +88,7 @@ func run(log *log.Logger) error {
 
 	// =========================================================================
 	// Server init
-	srv := server.New(db)
+	srv := server.New(repo, mapper.Mapper{EscapeAttributeKeys: cfg.EscapeAttributeKeys}, serverOpts...)
 
 	// Make a channel to listen for an interrupt or terminate signal from the OS.
 	// Use a buffered channel because the signal package requires it.
@@ -81,9 +118,133 @@ func run(log *log.Logger) error {
 	return nil
 }
 
+// newRepository creates the product repository for the given storage backend.
+func newRepository(ctx context.Context, log *log.Logger, cfg *config.Config, storage string) (product.ProductRepository, error) {
+	switch storage {
+	case mongodbStorage:
+		db, err := store.Connect(ctx, cfg.MongodbHostName, cfg.MongodbDatabase, cfg.MongodbPort)
+		if err != nil {
+			return nil, errors.Wrap(err, "connecting to database")
+		}
+		repo := product.NewMongoRepository(db)
+		repo.SetMaxWatchedProducts(cfg.WatchMaxProducts)
+		if cfg.MigrateOnStartup {
+			applied, err := store.NewMigrator(db, repo.Migrations()).Migrate(ctx)
+			for _, m := range applied {
+				log.Printf("main: applied migration %d: %s", m.Version, m.Description)
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "migrating database")
+			}
+		}
+		// Without pre-images, watched changes lack the products before being changed,
+		// which is no reason not to serve the rest of the API.
+		if err := repo.EnablePreImages(ctx); err != nil {
+			log.Printf("main: change stream pre-images not enabled: %v", err)
+		}
+		return repo, nil
+	case boltStorage:
+		log.Printf("main: using bbolt storage at %s", cfg.BoltDatabasePath)
+		repo, err := product.NewBoltRepository(cfg.BoltDatabasePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening bbolt database")
+		}
+		repo.SetMaxWatchedProducts(cfg.WatchMaxProducts)
+		return repo, nil
+	case memoryStorage:
+		log.Println("main: using in-memory storage, products will be lost on shutdown")
+		repo := product.NewMemoryRepository()
+		repo.SetMaxWatchedProducts(cfg.WatchMaxProducts)
+		return repo, nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q, must be %s, %s or %s", storage, mongodbStorage, boltStorage, memoryStorage)
+}
+
+// tlsOptions returns the gRPC server options making the listener serve TLS
+// with the certificates of the config, reloaded when they change on disk.
+func tlsOptions(log *log.Logger, cfg *config.Config) ([]grpc.ServerOption, error) {
+	if cfg.TlsCertPath == "" && cfg.TlsKeyPath == "" {
+		if cfg.TlsClientAuth != tlsconfig.ClientAuthNone {
+			return nil, errors.New("client certificates require TLS, which needs a certificate and a key")
+		}
+		log.Println("main: TLS disabled, RPCs and their credentials travel in plain text")
+		return nil, nil
+	}
+	reloader, err := tlsconfig.New(tlsconfig.Config{
+		CertFile:     cfg.TlsCertPath,
+		KeyFile:      cfg.TlsKeyPath,
+		ClientCAFile: cfg.TlsClientCaPath,
+		ClientAuth:   cfg.TlsClientAuth,
+		OnReload: func(err error) {
+			if err != nil {
+				log.Printf("main: TLS certificates not reloaded: %v", err)
+				return
+			}
+			log.Println("main: TLS certificates reloaded")
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "configuring TLS")
+	}
+	log.Printf("main: serving TLS, client certificates: %s", cfg.TlsClientAuth)
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(reloader.TLSConfig()))}, nil
+}
+
+// authOptions returns the gRPC server options making every RPC authenticate its caller
+// with the credentials of the config, and authorize it with the RBAC policy, if any.
+func authOptions(log *log.Logger, cfg *config.Config) ([]grpc.ServerOption, error) {
+	if cfg.AuthDisabled {
+		if cfg.RbacPolicyPath != "" {
+			return nil, errors.New("an RBAC policy cannot be enforced with authentication disabled")
+		}
+		log.Println("main: authentication disabled, any caller can read and write the catalog")
+		return nil, nil
+	}
+	authCfg := auth.Config{
+		Secret:             []byte(cfg.JwtSecret),
+		Issuer:             cfg.JwtIssuer,
+		Audience:           cfg.JwtAudience,
+		APIKeys:            cfg.ApiKeys,
+		ClientCertificates: cfg.TlsCertPath != "" && cfg.TlsClientAuth != tlsconfig.ClientAuthNone,
+	}
+	if cfg.JwtJwksPath != "" {
+		jwks, err := os.ReadFile(cfg.JwtJwksPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading JWKS file")
+		}
+		authCfg.JWKS = jwks
+	}
+	authenticator, err := auth.New(authCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "configuring authentication")
+	}
+	unary := []grpc.UnaryServerInterceptor{authenticator.UnaryServerInterceptor()}
+	stream := []grpc.StreamServerInterceptor{authenticator.StreamServerInterceptor()}
+	if cfg.RbacPolicyPath == "" {
+		log.Println("main: no RBAC policy, any authenticated caller can read and write the catalog")
+	} else {
+		data, err := os.ReadFile(cfg.RbacPolicyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading RBAC policy file")
+		}
+		policy, err := server.ParsePolicy(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "configuring authorization")
+		}
+		unary = append(unary, policy.UnaryServerInterceptor())
+		stream = append(stream, policy.StreamServerInterceptor())
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}, nil
+}
+
 func main() {
+	storage := flag.String("storage", "", "storage backend: mongodb, bolt or memory; overrides STORAGE_BACKEND")
+	flag.Parse()
 	log := log.New(os.Stdout, "GRPC SERVER : ", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
-	if err := run(log); err != nil {
+	if err := run(log, *storage); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
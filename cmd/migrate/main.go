@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/config"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+)
+
+// run applies the pending MongoDB migrations, or only lists them when dryRun is set.
+// It is meant for deployments that set MIGRATE_ON_STARTUP to false,
+// migrating the database before rolling out the servers.
+func run(log *log.Logger, dryRun bool) error {
+	ctx := context.Background()
+
+	const envFilePath = ".env"
+	cfg, err := config.Read(envFilePath)
+	if err != nil {
+		return errors.Wrap(err, "reading config")
+	}
+	db, err := store.Connect(ctx, cfg.MongodbHostName, cfg.MongodbDatabase, cfg.MongodbPort)
+	if err != nil {
+		return errors.Wrap(err, "connecting to database")
+	}
+	defer db.Disconnect(ctx)
+	migrator := store.NewMigrator(db, product.NewMongoRepository(db).Migrations())
+
+	if dryRun {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			log.Printf("migrate: pending migration %d: %s", m.Version, m.Description)
+		}
+		log.Printf("migrate: %d pending migrations", len(pending))
+		return nil
+	}
+	applied, err := migrator.Migrate(ctx)
+	for _, m := range applied {
+		log.Printf("migrate: applied migration %d: %s", m.Version, m.Description)
+	}
+	if err != nil {
+		return errors.Wrap(err, "migrating database")
+	}
+	log.Printf("migrate: %d migrations applied", len(applied))
+	return nil
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list the pending migrations without applying them")
+	flag.Parse()
+	log := log.New(os.Stdout, "MIGRATE : ", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)
+	if err := run(log, *dryRun); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
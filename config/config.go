@@ -22,6 +22,37 @@ type Config struct {
 	MongodbTestHostName string `envconfig:"MONGODB_TEST_HOST_NAME" required:"true"`
 	MongodbTestPort     int    `envconfig:"MONGODB_TEST_PORT" required:"true"`
 	GrpcServerṔort      int    `envconfig:"GRPC_SERVER_PORT" required:"true"`
+	StorageBackend      string `envconfig:"STORAGE_BACKEND" default:"mongodb"`
+	BoltDatabasePath    string `envconfig:"BOLT_DATABASE_PATH" default:"products.db"`
+	MigrateOnStartup    bool   `envconfig:"MIGRATE_ON_STARTUP" default:"true"`
+	EscapeAttributeKeys bool   `envconfig:"ESCAPE_ATTRIBUTE_KEYS" default:"false"`
+
+	// WatchMaxProducts is the largest number of products read by watches that poll
+	// for changes, as they do without MongoDB change streams.
+	WatchMaxProducts int `envconfig:"WATCH_MAX_PRODUCTS" default:"100000"`
+
+	// Callers authenticate with a JWT signed with JwtSecret or a key of the JWKS file
+	// at JwtJwksPath, or with one of ApiKeys, given as key:caller pairs separated by commas.
+	// Starting without any of them fails, unless AuthDisabled is set.
+	AuthDisabled bool              `envconfig:"AUTH_DISABLED" default:"false"`
+	JwtSecret    string            `envconfig:"JWT_SECRET"`
+	JwtJwksPath  string            `envconfig:"JWT_JWKS_PATH"`
+	JwtIssuer    string            `envconfig:"JWT_ISSUER"`
+	JwtAudience  string            `envconfig:"JWT_AUDIENCE"`
+	ApiKeys      map[string]string `envconfig:"API_KEYS"`
+
+	// The gRPC listener serves TLS with the certificate and key at TlsCertPath and TlsKeyPath,
+	// reloaded when they change on disk, and plain text without them. TlsClientAuth, none,
+	// optional or require, asks clients for certificates, verified with the authorities at
+	// TlsClientCaPath; callers presenting one are then authenticated by their certificate.
+	TlsCertPath     string `envconfig:"TLS_CERT_PATH"`
+	TlsKeyPath      string `envconfig:"TLS_KEY_PATH"`
+	TlsClientCaPath string `envconfig:"TLS_CLIENT_CA_PATH"`
+	TlsClientAuth   string `envconfig:"TLS_CLIENT_AUTH" default:"none"`
+
+	// RbacPolicyPath is the YAML file of the policy authorizing authenticated callers.
+	// Without one, every authenticated caller may call every method.
+	RbacPolicyPath string `envconfig:"RBAC_POLICY_PATH"`
 }
 
 // For ease of unit testing.
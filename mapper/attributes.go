@@ -0,0 +1,334 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// typedAttributesField is the field of a Protobuf Product holding its typed attributes.
+const typedAttributesField = "typed_attributes"
+
+// attributePathPrefix prefixes the update mask paths naming a single attribute.
+const attributePathPrefix = "attributes."
+
+// jsonSchemaField is the field of a Protobuf ProductType holding its JSON Schema document.
+const jsonSchemaField = "json_schema"
+
+// valueKeywords are the JSON Schema keywords whose values are instances, not schemas.
+var valueKeywords = map[string]bool{"const": true, "default": true, "enum": true, "examples": true}
+
+// keyPatternKeywords are the JSON Schema keywords constraining keys by their text,
+// which escaping changes.
+var keyPatternKeywords = map[string]bool{"patternProperties": true, "propertyNames": true}
+
+// schemaMapKeywords are the JSON Schema keywords whose values map names to schemas.
+var schemaMapKeywords = map[string]bool{
+	"$defs": true, "definitions": true, "dependencies": true, "dependentRequired": true,
+	"dependentSchemas": true, "properties": true,
+}
+
+// UpdateMaskPathsToModel converts the paths of a Protobuf update mask to the paths of
+// a MongoDB Product model, escaping the attribute keys they name when EscapeAttributeKeys
+// is set. Everything after the attributes prefix of a path is then a single key.
+func (m Mapper) UpdateMaskPathsToModel(paths []string) []string {
+	if !m.EscapeAttributeKeys {
+		return paths
+	}
+	converted := make([]string, len(paths))
+	for i, path := range paths {
+		if strings.HasPrefix(path, attributePathPrefix) {
+			path = attributePathPrefix + product.EscapeAttributeKey(strings.TrimPrefix(path, attributePathPrefix))
+		}
+		converted[i] = path
+	}
+	return converted
+}
+
+// escapeAttributeKeys escapes the keys of attributes, at every nesting level,
+// when EscapeAttributeKeys is set.
+func (m Mapper) escapeAttributeKeys(attributes map[string]interface{}) map[string]interface{} {
+	if !m.EscapeAttributeKeys || attributes == nil {
+		return attributes
+	}
+	return convertKeys(attributes, product.EscapeAttributeKey).(map[string]interface{})
+}
+
+// unescapeAttributeKeys reverses escapeAttributeKeys.
+func (m Mapper) unescapeAttributeKeys(attributes map[string]interface{}) map[string]interface{} {
+	if !m.EscapeAttributeKeys || attributes == nil {
+		return attributes
+	}
+	return convertKeys(attributes, product.UnescapeAttributeKey).(map[string]interface{})
+}
+
+// checkJSONSchemaKeys returns an error when EscapeAttributeKeys is set and a JSON Schema
+// document names attribute keys that escaping changes, or constrains keys by their text:
+// the store validates the escaped keys, which would not match. Documents that are not
+// valid JSON are left for the store to reject.
+func (m Mapper) checkJSONSchemaKeys(doc string) error {
+	if !m.EscapeAttributeKeys || doc == "" {
+		return nil
+	}
+	var schema interface{}
+	if err := json.Unmarshal([]byte(doc), &schema); err != nil {
+		return nil
+	}
+	if description := escapedSchemaKeys(schema); description != "" {
+		return product.NewInvalidArgumentError(product.FieldViolation{Field: jsonSchemaField, Description: description})
+	}
+	return nil
+}
+
+// escapedSchemaKeys describes the first key a JSON Schema names that escaping changes,
+// or the first keyword constraining keys by their text, walking keywords in order.
+// It returns an empty string when there is none.
+func escapedSchemaKeys(v interface{}) string {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if description := escapedSchemaKeys(e); description != "" {
+				return description
+			}
+		}
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			if keyPatternKeywords[k] {
+				return fmt.Sprintf("must not use %s while attribute keys are escaped", k)
+			}
+			if valueKeywords[k] {
+				continue
+			}
+			for _, key := range schemaKeys(k, v[k]) {
+				if product.EscapeAttributeKey(key) != key {
+					return fmt.Sprintf("must not name key %q while attribute keys are escaped", key)
+				}
+			}
+			if !schemaMapKeywords[k] {
+				if description := escapedSchemaKeys(v[k]); description != "" {
+					return description
+				}
+				continue
+			}
+			// The keys of these keywords are names, not keywords.
+			schemas, _ := v[k].(map[string]interface{})
+			for _, name := range sortedKeys(schemas) {
+				if description := escapedSchemaKeys(schemas[name]); description != "" {
+					return description
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// schemaKeys returns the keys of instances named by the value of a JSON Schema keyword, in order.
+func schemaKeys(keyword string, value interface{}) []string {
+	var keys []string
+	switch keyword {
+	case "required":
+		keys = stringElements(value)
+	case "properties", "dependentSchemas", "dependentRequired", "dependencies":
+		doc, _ := value.(map[string]interface{})
+		for k, e := range doc {
+			keys = append(keys, k)
+			keys = append(keys, stringElements(e)...)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringElements returns the strings of a JSON array, if value is one.
+func stringElements(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var elements []string
+	for _, e := range list {
+		if s, ok := e.(string); ok {
+			elements = append(elements, s)
+		}
+	}
+	return elements
+}
+
+// sortedKeys returns the keys of a JSON object, in order.
+func sortedKeys(doc map[string]interface{}) []string {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// convertKeys returns a copy of a value whose document keys are converted by convert.
+func convertKeys(v interface{}, convert func(string) string) interface{} {
+	switch v := v.(type) {
+	case primitive.A:
+		return convertKeys([]interface{}(v), convert)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = convertKeys(e, convert)
+		}
+		return list
+	case primitive.D:
+		return convertKeys(map[string]interface{}(v.Map()), convert)
+	case primitive.M:
+		return convertKeys(map[string]interface{}(v), convert)
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			doc[convert(k)] = convertKeys(e, convert)
+		}
+		return doc
+	}
+	return v
+}
+
+// toModelKey converts an attribute key of a Protobuf message to the key of a MongoDB model.
+func (m Mapper) toModelKey(key string) string {
+	if m.EscapeAttributeKeys {
+		return product.EscapeAttributeKey(key)
+	}
+	return key
+}
+
+// fromModelKey converts an attribute key of a MongoDB model to the key of a Protobuf message.
+func (m Mapper) fromModelKey(key string) string {
+	if m.EscapeAttributeKeys {
+		return product.UnescapeAttributeKey(key)
+	}
+	return key
+}
+
+// attributeValueToModel converts a Protobuf AttributeValue message, found at the given field,
+// to the value held by a MongoDB Product model. Integers are kept as int64, decimals
+// as Decimal128, timestamps as BSON dates, and bytes as generic binary data.
+func attributeValueToModel(field string, value *productcatalog.AttributeValue) (interface{}, error) {
+	switch v := value.GetKind().(type) {
+	case *productcatalog.AttributeValue_IntValue:
+		return v.IntValue, nil
+	case *productcatalog.AttributeValue_DoubleValue:
+		return v.DoubleValue, nil
+	case *productcatalog.AttributeValue_DecimalValue:
+		d, err := primitive.ParseDecimal128(v.DecimalValue)
+		if err != nil {
+			return nil, invalidAttributeError(field, "must be a valid decimal")
+		}
+		return d, nil
+	case *productcatalog.AttributeValue_TimestampValue:
+		if err := v.TimestampValue.CheckValid(); err != nil {
+			return nil, invalidAttributeError(field, "must be a valid timestamp")
+		}
+		return primitive.NewDateTimeFromTime(v.TimestampValue.AsTime()), nil
+	case *productcatalog.AttributeValue_BytesValue:
+		return primitive.Binary{Data: v.BytesValue}, nil
+	case *productcatalog.AttributeValue_StringValue:
+		return v.StringValue, nil
+	case *productcatalog.AttributeValue_BoolValue:
+		return v.BoolValue, nil
+	case *productcatalog.AttributeValue_ObjectIdValue:
+		id, err := primitive.ObjectIDFromHex(v.ObjectIdValue)
+		if err != nil {
+			return nil, invalidAttributeError(field, "must be a valid ObjectID")
+		}
+		return id, nil
+	case *productcatalog.AttributeValue_ListValue:
+		list := make([]interface{}, len(v.ListValue.GetValues()))
+		for i, e := range v.ListValue.GetValues() {
+			var err error
+			if list[i], err = attributeValueToModel(fmt.Sprintf("%s[%d]", field, i), e); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *productcatalog.AttributeValue_MapValue:
+		doc := make(map[string]interface{}, len(v.MapValue.GetFields()))
+		for k, e := range v.MapValue.GetFields() {
+			var err error
+			if doc[k], err = attributeValueToModel(field+"."+k, e); err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	}
+	// Null values and values without a kind.
+	return nil, nil
+}
+
+// invalidAttributeError returns an error stating that the attribute at the given field is invalid.
+func invalidAttributeError(field, description string) error {
+	return product.NewInvalidArgumentError(product.FieldViolation{Field: field, Description: description})
+}
+
+// attributeValueFromModel converts a value held by a MongoDB Product model,
+// as written by attributeValueToModel or decoded from BSON, to a Protobuf AttributeValue message.
+func attributeValueFromModel(value interface{}) (*productcatalog.AttributeValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_NullValue{}}, nil
+	case bool:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BoolValue{BoolValue: v}}, nil
+	case string:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_StringValue{StringValue: v}}, nil
+	case int:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_IntValue{IntValue: int64(v)}}, nil
+	case int32:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_IntValue{IntValue: int64(v)}}, nil
+	case int64:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_IntValue{IntValue: v}}, nil
+	case float32:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DoubleValue{DoubleValue: float64(v)}}, nil
+	case float64:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DoubleValue{DoubleValue: v}}, nil
+	case primitive.Decimal128:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: v.String()}}, nil
+	case primitive.DateTime:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(v.Time())}}, nil
+	case time.Time:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(v)}}, nil
+	case primitive.Binary:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: v.Data}}, nil
+	case []byte:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: v}}, nil
+	case primitive.ObjectID:
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: v.Hex()}}, nil
+	case primitive.A:
+		return attributeValueFromModel([]interface{}(v))
+	case []interface{}:
+		list := &productcatalog.AttributeList{Values: make([]*productcatalog.AttributeValue, len(v))}
+		for i, e := range v {
+			var err error
+			if list.Values[i], err = attributeValueFromModel(e); err != nil {
+				return nil, err
+			}
+		}
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ListValue{ListValue: list}}, nil
+	case primitive.D:
+		return attributeValueFromModel(map[string]interface{}(v.Map()))
+	case primitive.M:
+		return attributeValueFromModel(map[string]interface{}(v))
+	case map[string]interface{}:
+		doc := &productcatalog.AttributeMap{Fields: make(map[string]*productcatalog.AttributeValue, len(v))}
+		for k, e := range v {
+			var err error
+			if doc.Fields[k], err = attributeValueFromModel(e); err != nil {
+				return nil, err
+			}
+		}
+		return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_MapValue{MapValue: doc}}, nil
+	}
+	return nil, errors.Errorf("unsupported value of type %T", value)
+}
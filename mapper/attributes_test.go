@@ -0,0 +1,340 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package mapper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func stringAttribute(s string) *productcatalog.AttributeValue {
+	return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_StringValue{StringValue: s}}
+}
+
+func doubleAttribute(f float64) *productcatalog.AttributeValue {
+	return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DoubleValue{DoubleValue: f}}
+}
+
+func intAttribute(i int64) *productcatalog.AttributeValue {
+	return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_IntValue{IntValue: i}}
+}
+
+func listAttribute(values ...*productcatalog.AttributeValue) *productcatalog.AttributeValue {
+	return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ListValue{ListValue: &productcatalog.AttributeList{Values: values}}}
+}
+
+func mapAttribute(fields map[string]*productcatalog.AttributeValue) *productcatalog.AttributeValue {
+	return &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_MapValue{MapValue: &productcatalog.AttributeMap{Fields: fields}}}
+}
+
+func decimal128(s string) primitive.Decimal128 {
+	d, err := primitive.ParseDecimal128(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func objectId(s string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func TestAttributeValueToModel(t *testing.T) {
+	released := time.Date(2023, 7, 22, 4, 26, 40, 123456789, time.UTC)
+	testCases := []struct {
+		name           string
+		input          *productcatalog.AttributeValue
+		expectedOutput interface{}
+		expectedError  error
+	}{
+		{
+			name:           "null",
+			input:          &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_NullValue{}},
+			expectedOutput: nil,
+		},
+		{
+			name:           "without a kind",
+			input:          &productcatalog.AttributeValue{},
+			expectedOutput: nil,
+		},
+		{
+			name:           "int",
+			input:          intAttribute(3),
+			expectedOutput: int64(3),
+		},
+		{
+			name:           "double",
+			input:          doubleAttribute(2.5),
+			expectedOutput: 2.5,
+		},
+		{
+			name:           "decimal",
+			input:          &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "19.99"}},
+			expectedOutput: decimal128("19.99"),
+		},
+		{
+			name:           "timestamp",
+			input:          &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(released)}},
+			expectedOutput: primitive.DateTime(1690000000123),
+		},
+		{
+			name:           "bytes",
+			input:          &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: []byte("abc")}},
+			expectedOutput: primitive.Binary{Data: []byte("abc")},
+		},
+		{
+			name:           "object id",
+			input:          &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: "64b7f0c2a1b2c3d4e5f60718"}},
+			expectedOutput: objectId("64b7f0c2a1b2c3d4e5f60718"),
+		},
+		{
+			name: "list and map",
+			input: listAttribute(
+				stringAttribute("a"),
+				&productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BoolValue{BoolValue: true}},
+				mapAttribute(map[string]*productcatalog.AttributeValue{"n": intAttribute(1)}),
+			),
+			expectedOutput: []interface{}{"a", true, map[string]interface{}{"n": int64(1)}},
+		},
+		{
+			name:          "invalid decimal",
+			input:         &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "1,5"}},
+			expectedError: errors.New("invalid argument: typed_attributes.k: must be a valid decimal"),
+		},
+		{
+			name: "invalid timestamp",
+			input: &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{
+				TimestampValue: &timestamppb.Timestamp{Nanos: -1},
+			}},
+			expectedError: errors.New("invalid argument: typed_attributes.k: must be a valid timestamp"),
+		},
+		{
+			name: "invalid object id in a list",
+			input: listAttribute(
+				&productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: "64b7f0c2a1b2c3d4e5f60718"}},
+				&productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: "1"}},
+			),
+			expectedError: errors.New("invalid argument: typed_attributes.k[1]: must be a valid ObjectID"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := attributeValueToModel("typed_attributes.k", tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestAttributeValueFromModel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          interface{}
+		expectedOutput *productcatalog.AttributeValue
+		expectedError  error
+	}{
+		{
+			name:           "null",
+			input:          nil,
+			expectedOutput: &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_NullValue{}},
+		},
+		{
+			name:           "int32",
+			input:          int32(3),
+			expectedOutput: intAttribute(3),
+		},
+		{
+			name:           "time",
+			input:          time.UnixMilli(1690000000123),
+			expectedOutput: &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(time.UnixMilli(1690000000123))}},
+		},
+		{
+			name:  "lists and documents decoded from BSON",
+			input: primitive.A{primitive.D{{Key: "sizes", Value: primitive.A{"S"}}}, primitive.M{"n": 1.5}},
+			expectedOutput: listAttribute(
+				mapAttribute(map[string]*productcatalog.AttributeValue{"sizes": listAttribute(stringAttribute("S"))}),
+				mapAttribute(map[string]*productcatalog.AttributeValue{"n": doubleAttribute(1.5)}),
+			),
+		},
+		{
+			name:          "unsupported",
+			input:         primitive.A{primitive.Regex{Pattern: "^a"}},
+			expectedError: errors.New("unsupported value of type primitive.Regex"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := attributeValueFromModel(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.True(t, proto.Equal(tc.expectedOutput, output), "got %v", output)
+			}
+		})
+	}
+}
+
+func TestAttributeValueRoundTrip(t *testing.T) {
+	// Values that google.protobuf.Value would turn into doubles or strings
+	// come back with their type.
+	input := mapAttribute(map[string]*productcatalog.AttributeValue{
+		"stock":    intAttribute(3),
+		"price":    &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "19.99"}},
+		"released": &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(time.UnixMilli(1690000000123))}},
+		"checksum": &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: []byte{0, 1}}},
+		"tags":     listAttribute(stringAttribute("new"), &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_NullValue{}}),
+	})
+	v, err := attributeValueToModel("typed_attributes.k", input)
+	require.Nil(t, err)
+	output, err := attributeValueFromModel(v)
+	require.Nil(t, err)
+	require.True(t, proto.Equal(input, output), "got %v", output)
+
+	// The value converted to google.protobuf.Value keeps what it can.
+	value, err := structpb.NewValue(fromBSON(v))
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"stock":    3.0,
+		"price":    "19.99",
+		"released": "2023-07-22T04:26:40.123Z",
+		"checksum": "AAE=",
+		"tags":     []interface{}{"new", nil},
+	}, value.AsInterface())
+}
+
+func TestEscapeAttributeKeys(t *testing.T) {
+	m := Mapper{EscapeAttributeKeys: true}
+	p := &productcatalog.Product{
+		Name: "name",
+		Attributes: map[string]*structpb.Value{
+			"$price":     structpb.NewNumberValue(1),
+			"dimensions": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"w.cm": structpb.NewNumberValue(2)}}),
+		},
+		TypedAttributes: map[string]*productcatalog.AttributeValue{
+			"100%": intAttribute(3),
+		},
+	}
+	dbProduct, err := m.ProductProtobufToProductModel(p)
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"%24price":   1.0,
+		"dimensions": map[string]interface{}{"w%2Ecm": 2.0},
+		"100%25":     int64(3),
+	}, dbProduct.Attributes)
+
+	output, err := m.ProductModelToProductProtobuf(dbProduct)
+	require.Nil(t, err)
+	require.True(t, proto.Equal(p.Attributes["dimensions"], output.Attributes["dimensions"]))
+	require.ElementsMatch(t, []string{"$price", "dimensions", "100%"}, keysOf(output.TypedAttributes))
+
+	require.Equal(t, []string{"name", "attributes.%24price", "attributes.w%2Ecm"},
+		m.UpdateMaskPathsToModel([]string{"name", "attributes.$price", "attributes.w.cm"}))
+	require.Equal(t, []string{"attributes.$price"}, Mapper{}.UpdateMaskPathsToModel([]string{"attributes.$price"}))
+}
+
+func TestEscapedJSONSchema(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		escape        bool
+		expectedError error
+	}{
+		{
+			name:   "keys escaping keeps",
+			input:  `{"type": "object", "properties": {"size": {"type": "string", "enum": [{"w.cm": 1}]}}, "required": ["size"]}`,
+			escape: true,
+		},
+		{
+			name:   "keys escaping changes, without escaping",
+			input:  `{"properties": {"w.cm": {"type": "number"}}}`,
+			escape: false,
+		},
+		{
+			name:          "property escaping changes",
+			input:         `{"properties": {"dimensions": {"properties": {"w.cm": {"type": "number"}}}}}`,
+			escape:        true,
+			expectedError: errors.New(`invalid argument: json_schema: must not name key "w.cm" while attribute keys are escaped`),
+		},
+		{
+			name:          "required key escaping changes",
+			input:         `{"required": ["$ref"]}`,
+			escape:        true,
+			expectedError: errors.New(`invalid argument: json_schema: must not name key "$ref" while attribute keys are escaped`),
+		},
+		{
+			name:          "dependent key escaping changes",
+			input:         `{"dependentRequired": {"size": ["100%"]}}`,
+			escape:        true,
+			expectedError: errors.New(`invalid argument: json_schema: must not name key "100%" while attribute keys are escaped`),
+		},
+		{
+			name:          "keys constrained by their text",
+			input:         `{"allOf": [{"propertyNames": {"maxLength": 8}}]}`,
+			escape:        true,
+			expectedError: errors.New("invalid argument: json_schema: must not use propertyNames while attribute keys are escaped"),
+		},
+		{
+			name:   "property named after a keyword",
+			input:  `{"properties": {"patternProperties": {"type": "string"}}}`,
+			escape: true,
+		},
+		{
+			name:   "invalid document",
+			input:  `{"properties"`,
+			escape: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := Mapper{EscapeAttributeKeys: tc.escape}
+			output, err := m.ProductTypeProtobufToProductTypeModel(&productcatalog.ProductType{Name: "shirt", JsonSchema: tc.input})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.input, output.JsonSchema)
+			}
+		})
+	}
+}
+
+func keysOf(m map[string]*productcatalog.AttributeValue) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -10,57 +10,173 @@
 package mapper
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // For ease of unit testing.
 var structpbNewValue = structpb.NewValue
 
+// Mapper converts between Protobuf messages and MongoDB models.
+// Its zero value keeps attribute keys as they are.
+type Mapper struct {
+	// EscapeAttributeKeys makes the conversions escape the attribute keys that MongoDB
+	// reserves, at every nesting level, instead of leaving them for the store to reject,
+	// and unescape them back. Product types are then rejected when their JSON Schema
+	// names keys that escaping would change, as it would validate the escaped keys.
+	EscapeAttributeKeys bool
+}
+
 // ProductProtobufToProductModel converts a Protobuf Product message to a MongoDB Product model.
-func ProductProtobufToProductModel(product *productcatalog.Product) (*models.Product, error) {
+func (m Mapper) ProductProtobufToProductModel(product *productcatalog.Product) (*models.Product, error) {
 	dbProduct := &models.Product{
-		Uuid:        product.Uuid,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
+		Uuid:        product.GetUuid(),
+		Name:        product.GetName(),
+		Description: product.GetDescription(),
+		Sku:         product.GetSku(),
+		Slug:        product.GetSlug(),
+		ProductType: product.GetProductType(),
+		Revision:    product.GetRevision(),
+	}
+	// The price is output only, being derived from the prices by the store.
+	for i, amount := range product.GetPrices() {
+		price, err := moneyToModel(fmt.Sprintf("prices[%d]", i), amount)
+		if err != nil {
+			return nil, err
+		}
+		dbProduct.Prices = append(dbProduct.Prices, price)
 	}
 	attributes := make(map[string]interface{})
-	for k, p := range product.Attributes {
+	for k, p := range product.GetAttributes() {
 		attributes[k] = p.AsInterface()
 	}
-	dbProduct.Attributes = attributes
+	// Typed attributes are converted in key order, so that failures are reported consistently.
+	keys := make([]string, 0, len(product.GetTypedAttributes()))
+	for k := range product.GetTypedAttributes() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// Products read from the service carry every attribute in both fields, so a key may be set
+	// in both when they agree, in which case the typed value, which is more precise, is kept.
+	for _, k := range keys {
+		field := typedAttributesField + "." + k
+		v, err := attributeValueToModel(field, product.GetTypedAttributes()[k])
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := product.GetAttributes()[k]; ok && !sameAttributeValue(value, v) {
+			return nil, invalidAttributeError(field, "must not differ from attributes."+k)
+		}
+		attributes[k] = v
+	}
+	dbProduct.Attributes = m.escapeAttributeKeys(attributes)
 	return dbProduct, nil
 }
 
 // ProductModelToProductProtobuf converts a MongoDB Product model to a Protobuf Product message.
-func ProductModelToProductProtobuf(dbProduct *models.Product) (*productcatalog.Product, error) {
+func (m Mapper) ProductModelToProductProtobuf(dbProduct *models.Product) (*productcatalog.Product, error) {
 	product := &productcatalog.Product{
 		Uuid:        dbProduct.Uuid,
 		Name:        dbProduct.Name,
 		Description: dbProduct.Description,
 		Price:       dbProduct.Price,
+		Sku:         dbProduct.Sku,
+		Slug:        dbProduct.Slug,
+		ProductType: dbProduct.ProductType,
+		Revision:    dbProduct.Revision,
 	}
+	for _, amount := range dbProduct.Prices {
+		price, err := moneyFromModel(amount)
+		if err != nil {
+			return nil, errors.Wrapf(err, `parsing price in "%s"`, amount.CurrencyCode)
+		}
+		product.Prices = append(product.Prices, price)
+	}
+	dbAttributes := m.unescapeAttributeKeys(dbProduct.Attributes)
+	// Attributes are converted in key order, so that failures are reported consistently.
+	keys := make([]string, 0, len(dbAttributes))
+	for k := range dbAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	var err error
 	attributes := make(map[string]*structpb.Value)
-	for k, p := range dbProduct.Attributes {
-		attributes[k], err = structpbNewValue(p)
+	typedAttributes := make(map[string]*productcatalog.AttributeValue)
+	for _, k := range keys {
+		attributes[k], err = structpbNewValue(fromBSON(dbAttributes[k]))
+		if err != nil {
+			return nil, errors.Wrapf(err, `parsing attribute "%s"`, k)
+		}
+		typedAttributes[k], err = attributeValueFromModel(dbAttributes[k])
 		if err != nil {
 			return nil, errors.Wrapf(err, `parsing attribute "%s"`, k)
 		}
 	}
 	product.Attributes = attributes
+	product.TypedAttributes = typedAttributes
 	return product, nil
 }
 
-// ProductModelListToListProductsResponse converts a list of MongoDB Product models to a Protobuf ListProductsResponse message.
-func ProductModelListToListProductsResponse(dbProducts []*models.Product) (*productcatalog.ListProductsResponse, error) {
-	response := &productcatalog.ListProductsResponse{}
+// sameAttributeValue reports whether a Value-based attribute is the one
+// ProductModelToProductProtobuf outputs for the given value of a model.
+func sameAttributeValue(value *structpb.Value, v interface{}) bool {
+	converted, err := structpbNewValue(fromBSON(v))
+	return err == nil && proto.Equal(value, converted)
+}
+
+// fromBSON converts the values decoded from BSON, which structpb does not support,
+// into values it does: lists and documents become plain slices and maps, decimals,
+// dates and ObjectIDs become strings, and binary data becomes bytes, which structpb
+// encodes in base64. The typed attributes of a product keep these values as they are.
+func fromBSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case primitive.Decimal128:
+		return v.String()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.Binary:
+		return v.Data
+	case primitive.A:
+		return fromBSON([]interface{}(v))
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = fromBSON(e)
+		}
+		return list
+	case primitive.D:
+		return fromBSON(map[string]interface{}(v.Map()))
+	case primitive.M:
+		return fromBSON(map[string]interface{}(v))
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			doc[k] = fromBSON(e)
+		}
+		return doc
+	}
+	return v
+}
+
+// ProductModelListToListProductsResponse converts a page of MongoDB Product models to a Protobuf ListProductsResponse message.
+func (m Mapper) ProductModelListToListProductsResponse(dbProducts []*models.Product, nextPageToken string) (*productcatalog.ListProductsResponse, error) {
+	response := &productcatalog.ListProductsResponse{NextPageToken: nextPageToken}
 	products := []*productcatalog.Product{}
 	for _, dbProduct := range dbProducts {
-		product, err := ProductModelToProductProtobuf(dbProduct)
+		product, err := m.ProductModelToProductProtobuf(dbProduct)
 		if err != nil {
 			return nil, err
 		}
@@ -69,3 +185,96 @@ func ProductModelListToListProductsResponse(dbProducts []*models.Product) (*prod
 	response.Products = products
 	return response, nil
 }
+
+// ProductModelToDeleteProductResponse converts a deleted MongoDB Product model to a Protobuf DeleteProductResponse message.
+func (m Mapper) ProductModelToDeleteProductResponse(dbProduct *models.Product) (*productcatalog.DeleteProductResponse, error) {
+	product, err := m.ProductModelToProductProtobuf(dbProduct)
+	if err != nil {
+		return nil, err
+	}
+	return &productcatalog.DeleteProductResponse{
+		DeletedCount: 1,
+		Product:      product,
+	}, nil
+}
+
+// ProductTypeProtobufToProductTypeModel converts a Protobuf ProductType message to a MongoDB ProductType model.
+func (m Mapper) ProductTypeProtobufToProductTypeModel(productType *productcatalog.ProductType) (*models.ProductType, error) {
+	dbProductType := &models.ProductType{
+		Name:                      productType.GetName(),
+		Description:               productType.GetDescription(),
+		AllowAdditionalAttributes: productType.GetAllowAdditionalAttributes(),
+		JsonSchema:                productType.GetJsonSchema(),
+	}
+	if err := m.checkJSONSchemaKeys(dbProductType.JsonSchema); err != nil {
+		return nil, err
+	}
+	attributes := make(map[string]models.AttributeSchema)
+	for k, s := range productType.GetAttributes() {
+		schema := models.AttributeSchema{
+			Required: s.GetRequired(),
+			Minimum:  s.Minimum,
+			Maximum:  s.Maximum,
+			Pattern:  s.GetPattern(),
+		}
+		if s.GetType() != productcatalog.AttributeSchema_TYPE_UNSPECIFIED {
+			schema.Type = strings.ToLower(s.GetType().String())
+		}
+		for _, v := range s.GetAllowedValues() {
+			schema.AllowedValues = append(schema.AllowedValues, v.AsInterface())
+		}
+		attributes[m.toModelKey(k)] = schema
+	}
+	dbProductType.Attributes = attributes
+	return dbProductType, nil
+}
+
+// ProductTypeModelToProductTypeProtobuf converts a MongoDB ProductType model to a Protobuf ProductType message.
+func (m Mapper) ProductTypeModelToProductTypeProtobuf(dbProductType *models.ProductType) (*productcatalog.ProductType, error) {
+	productType := &productcatalog.ProductType{
+		Name:                      dbProductType.Name,
+		Description:               dbProductType.Description,
+		AllowAdditionalAttributes: dbProductType.AllowAdditionalAttributes,
+		JsonSchema:                dbProductType.JsonSchema,
+	}
+	// Attributes are converted in key order, so that failures are reported consistently.
+	keys := make([]string, 0, len(dbProductType.Attributes))
+	for k := range dbProductType.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attributes := make(map[string]*productcatalog.AttributeSchema)
+	for _, k := range keys {
+		s := dbProductType.Attributes[k]
+		schema := &productcatalog.AttributeSchema{
+			Type:     productcatalog.AttributeSchema_Type(productcatalog.AttributeSchema_Type_value[strings.ToUpper(s.Type)]),
+			Required: s.Required,
+			Minimum:  s.Minimum,
+			Maximum:  s.Maximum,
+			Pattern:  s.Pattern,
+		}
+		for _, v := range s.AllowedValues {
+			value, err := structpbNewValue(fromBSON(v))
+			if err != nil {
+				return nil, errors.Wrapf(err, `parsing allowed value of attribute "%s"`, k)
+			}
+			schema.AllowedValues = append(schema.AllowedValues, value)
+		}
+		attributes[m.fromModelKey(k)] = schema
+	}
+	productType.Attributes = attributes
+	return productType, nil
+}
+
+// ProductTypeModelListToListProductTypesResponse converts MongoDB ProductType models to a Protobuf ListProductTypesResponse message.
+func (m Mapper) ProductTypeModelListToListProductTypesResponse(dbProductTypes []*models.ProductType) (*productcatalog.ListProductTypesResponse, error) {
+	productTypes := []*productcatalog.ProductType{}
+	for _, dbProductType := range dbProductTypes {
+		productType, err := m.ProductTypeModelToProductTypeProtobuf(dbProductType)
+		if err != nil {
+			return nil, err
+		}
+		productTypes = append(productTypes, productType)
+	}
+	return &productcatalog.ListProductTypesResponse{ProductTypes: productTypes}, nil
+}
@@ -6,12 +6,16 @@ package mapper
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/type/money"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestProductProtobufToProductModel(t *testing.T) {
@@ -20,16 +24,88 @@ func TestProductProtobufToProductModel(t *testing.T) {
 		Name:        "Product1",
 		Description: "Product Description",
 		Price:       10.0,
+		Prices: []*money.Money{
+			{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+			{CurrencyCode: "EUR", Units: 18, Nanos: 500000000},
+		},
+		Sku:         "SKU-1",
+		Slug:        "product-1",
+		ProductType: "shirt",
 		Attributes:  map[string]*structpb.Value{"Color": structpb.NewStringValue("Blue")},
+		TypedAttributes: map[string]*productcatalog.AttributeValue{
+			"Stock": intAttribute(3),
+		},
+		Revision: 3,
 	}
 
-	dbProduct, err := ProductProtobufToProductModel(p)
+	dbProduct, err := Mapper{}.ProductProtobufToProductModel(p)
 	assert.Nil(t, err)
 	assert.Equal(t, "1", dbProduct.Uuid)
 	assert.Equal(t, "Product1", dbProduct.Name)
 	assert.Equal(t, "Product Description", dbProduct.Description)
-	assert.Equal(t, float32(10.0), dbProduct.Price)
-	assert.Equal(t, map[string]interface{}{"Color": "Blue"}, dbProduct.Attributes)
+	// The price is output only.
+	assert.Equal(t, float32(0), dbProduct.Price)
+	assert.Equal(t, []models.Money{
+		{CurrencyCode: "USD", Amount: decimal128("19.99")},
+		{CurrencyCode: "EUR", Amount: decimal128("18.5")},
+	}, dbProduct.Prices)
+	assert.Equal(t, "SKU-1", dbProduct.Sku)
+	assert.Equal(t, "product-1", dbProduct.Slug)
+	assert.Equal(t, "shirt", dbProduct.ProductType)
+	assert.Equal(t, map[string]interface{}{"Color": "Blue", "Stock": int64(3)}, dbProduct.Attributes)
+	assert.Equal(t, int64(3), dbProduct.Revision)
+
+	// Typed attributes must be valid, and agree with the attributes of the same keys.
+	p.TypedAttributes["Color"] = stringAttribute("Red")
+	_, err = Mapper{}.ProductProtobufToProductModel(p)
+	require.Equal(t, "invalid argument: typed_attributes.Color: must not differ from attributes.Color", err.Error())
+	p.TypedAttributes["Color"] = stringAttribute("Blue")
+	dbProduct, err = Mapper{}.ProductProtobufToProductModel(p)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"Color": "Blue", "Stock": int64(3)}, dbProduct.Attributes)
+	delete(p.TypedAttributes, "Color")
+	p.TypedAttributes["Price"] = &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "cheap"}}
+	_, err = Mapper{}.ProductProtobufToProductModel(p)
+	require.Equal(t, "invalid argument: typed_attributes.Price: must be a valid decimal", err.Error())
+	delete(p.TypedAttributes, "Price")
+
+	// So must prices.
+	p.Prices[1].Nanos = -1
+	_, err = Mapper{}.ProductProtobufToProductModel(p)
+	require.Equal(t, "invalid argument: prices[1].nanos: must be between -999999999 and 999999999, with the sign of units", err.Error())
+}
+
+func TestProductRoundTrip(t *testing.T) {
+	at := primitive.NewDateTimeFromTime(time.Date(2023, 7, 22, 4, 26, 40, 123000000, time.UTC))
+	id := primitive.NewObjectIDFromTimestamp(at.Time())
+	dbProduct := &models.Product{
+		Uuid:     "1",
+		Name:     "Product1",
+		Prices:   []models.Money{{CurrencyCode: "USD", Amount: decimal128("19.99")}},
+		Revision: 2,
+		Attributes: map[string]interface{}{
+			"stock":    int64(3),
+			"weight":   1.5,
+			"price":    decimal128("19.99"),
+			"released": at,
+			"checksum": primitive.Binary{Data: []byte{0, 1}},
+			"color":    "blue",
+			"new":      true,
+			"supplier": id,
+			"discount": nil,
+			"tags":     []interface{}{"new", int64(1)},
+			"dimensions": map[string]interface{}{
+				"width":    int64(10),
+				"measured": at,
+				"sizes":    []interface{}{decimal128("1.5"), map[string]interface{}{"id": id}},
+			},
+		},
+	}
+	product, err := Mapper{}.ProductModelToProductProtobuf(dbProduct)
+	require.Nil(t, err)
+	output, err := Mapper{}.ProductProtobufToProductModel(product)
+	require.Nil(t, err)
+	require.Equal(t, dbProduct, output)
 }
 
 func TestProdutcModelToProductProtobuf(t *testing.T) {
@@ -47,20 +123,92 @@ func TestProdutcModelToProductProtobuf(t *testing.T) {
 				Name:        "name",
 				Description: "description",
 				Price:       1,
+				Prices:      []models.Money{{CurrencyCode: "USD", Amount: decimal128("1")}},
+				Sku:         "sku",
+				Slug:        "slug",
 				Attributes: map[string]interface{}{
 					"color": "blue",
 					"size":  12.0,
 				},
+				Revision: 2,
 			},
 			expectedOutput: &productcatalog.Product{
 				Uuid:        "uuid",
 				Name:        "name",
 				Description: "description",
 				Price:       1,
+				Prices:      []*money.Money{{CurrencyCode: "USD", Units: 1}},
+				Sku:         "sku",
+				Slug:        "slug",
 				Attributes: map[string]*structpb.Value{
 					"color": structpb.NewStringValue("blue"),
 					"size":  structpb.NewNumberValue(12.0),
 				},
+				TypedAttributes: map[string]*productcatalog.AttributeValue{
+					"color": stringAttribute("blue"),
+					"size":  doubleAttribute(12),
+				},
+				Revision: 2,
+			},
+		},
+		{
+			name: "lists and documents decoded from BSON",
+			input: &models.Product{
+				Uuid: "uuid",
+				Attributes: map[string]interface{}{
+					"sizes":      primitive.A{"S", "M"},
+					"dimensions": primitive.D{{Key: "height", Value: 2.0}},
+					"variants":   primitive.A{primitive.M{"tags": primitive.A{"new"}}},
+				},
+			},
+			expectedOutput: &productcatalog.Product{
+				Uuid: "uuid",
+				Attributes: map[string]*structpb.Value{
+					"sizes":      structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("S"), structpb.NewStringValue("M")}}),
+					"dimensions": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"height": structpb.NewNumberValue(2)}}),
+					"variants": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+						structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+							"tags": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("new")}}),
+						}}),
+					}}),
+				},
+				TypedAttributes: map[string]*productcatalog.AttributeValue{
+					"sizes":      listAttribute(stringAttribute("S"), stringAttribute("M")),
+					"dimensions": mapAttribute(map[string]*productcatalog.AttributeValue{"height": doubleAttribute(2)}),
+					"variants": listAttribute(mapAttribute(map[string]*productcatalog.AttributeValue{
+						"tags": listAttribute(stringAttribute("new")),
+					})),
+				},
+			},
+		},
+		{
+			name: "values structpb does not support",
+			input: &models.Product{
+				Uuid: "uuid",
+				Attributes: map[string]interface{}{
+					"stock":    int64(3),
+					"price":    decimal128("19.99"),
+					"released": primitive.DateTime(1690000000123),
+					"ref":      objectId("64b7f0c2a1b2c3d4e5f60718"),
+					"checksum": primitive.Binary{Data: []byte("abc")},
+				},
+			},
+			expectedOutput: &productcatalog.Product{
+				Uuid: "uuid",
+				Attributes: map[string]*structpb.Value{
+					"stock":    structpb.NewNumberValue(3),
+					"price":    structpb.NewStringValue("19.99"),
+					"released": structpb.NewStringValue("2023-07-22T04:26:40.123Z"),
+					"ref":      structpb.NewStringValue("64b7f0c2a1b2c3d4e5f60718"),
+					"checksum": structpb.NewStringValue("YWJj"),
+				},
+				TypedAttributes: map[string]*productcatalog.AttributeValue{
+					"stock":    intAttribute(3),
+					"price":    {Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "19.99"}},
+					"released": {Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(time.UnixMilli(1690000000123))}},
+					"ref":      {Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: "64b7f0c2a1b2c3d4e5f60718"}},
+					"checksum": {Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: []byte("abc")}},
+				},
 			},
 		},
 		{
@@ -80,6 +228,14 @@ func TestProdutcModelToProductProtobuf(t *testing.T) {
 			},
 			expectedError: errors.New(`parsing attribute "color": random error`),
 		},
+		{
+			name: "price that cannot be represented",
+			input: &models.Product{
+				Uuid:   "uuid",
+				Prices: []models.Money{{CurrencyCode: "USD", Amount: decimal128("0.0000000001")}},
+			},
+			expectedError: errors.New(`parsing price in "USD": amount 1E-10 has more than 9 decimal places`),
+		},
 	}
 	originalStructpbNewValue := structpbNewValue
 	for _, tc := range testCases {
@@ -90,7 +246,7 @@ func TestProdutcModelToProductProtobuf(t *testing.T) {
 				structpbNewValue = originalStructpbNewValue
 			}
 			defer func() { structpbNewValue = originalStructpbNewValue }()
-			output, err := ProductModelToProductProtobuf(tc.input)
+			output, err := Mapper{}.ProductModelToProductProtobuf(tc.input)
 			if err != nil {
 				if tc.expectedError == nil {
 					t.Fatalf("expected no error, got %v", err)
@@ -139,6 +295,10 @@ func TestProductModelListToListProductsResponse(t *testing.T) {
 							"color": structpb.NewStringValue("blue"),
 							"size":  structpb.NewNumberValue(12.0),
 						},
+						TypedAttributes: map[string]*productcatalog.AttributeValue{
+							"color": stringAttribute("blue"),
+							"size":  doubleAttribute(12),
+						},
 					},
 				},
 			},
@@ -172,7 +332,185 @@ func TestProductModelListToListProductsResponse(t *testing.T) {
 				structpbNewValue = originalStructpbNewValue
 			}
 			defer func() { structpbNewValue = originalStructpbNewValue }()
-			output, err := ProductModelListToListProductsResponse(tc.input)
+			output, err := Mapper{}.ProductModelListToListProductsResponse(tc.input, "")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestProductModelToDeleteProductResponse(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		input                *models.Product
+		mockStructpbNewValue func(v interface{}) (*structpb.Value, error)
+		expectedOutput       *productcatalog.DeleteProductResponse
+		expectedError        error
+	}{
+		{
+			name: "happy path",
+			input: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "description",
+				Price:       1,
+				Attributes: map[string]interface{}{
+					"color": "blue",
+				},
+			},
+			expectedOutput: &productcatalog.DeleteProductResponse{
+				DeletedCount: 1,
+				Product: &productcatalog.Product{
+					Uuid:        "uuid",
+					Name:        "name",
+					Description: "description",
+					Price:       1,
+					Attributes: map[string]*structpb.Value{
+						"color": structpb.NewStringValue("blue"),
+					},
+					TypedAttributes: map[string]*productcatalog.AttributeValue{
+						"color": stringAttribute("blue"),
+					},
+				},
+			},
+		},
+		{
+			name: "error",
+			input: &models.Product{
+				Uuid: "uuid",
+				Attributes: map[string]interface{}{
+					"color": "blue",
+				},
+			},
+			mockStructpbNewValue: func(v interface{}) (*structpb.Value, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New(`parsing attribute "color": random error`),
+		},
+	}
+	originalStructpbNewValue := structpbNewValue
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockStructpbNewValue != nil {
+				structpbNewValue = tc.mockStructpbNewValue
+			} else {
+				structpbNewValue = originalStructpbNewValue
+			}
+			defer func() { structpbNewValue = originalStructpbNewValue }()
+			output, err := Mapper{}.ProductModelToDeleteProductResponse(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestProductTypeProtobufToProductTypeModel(t *testing.T) {
+	minimum := 1.0
+	pt := &productcatalog.ProductType{
+		Name:        "shirt",
+		Description: "shirts",
+		Attributes: map[string]*productcatalog.AttributeSchema{
+			"size": {
+				Type:          productcatalog.AttributeSchema_STRING,
+				Required:      true,
+				AllowedValues: []*structpb.Value{structpb.NewStringValue("S")},
+				Pattern:       "^[A-Z]+$",
+			},
+			"chest": {Type: productcatalog.AttributeSchema_INTEGER, Minimum: &minimum},
+			"fit":   {},
+		},
+		AllowAdditionalAttributes: true,
+		JsonSchema:                `{"type": "object"}`,
+	}
+
+	dbProductType, err := Mapper{}.ProductTypeProtobufToProductTypeModel(pt)
+	require.Nil(t, err)
+	require.Equal(t, &models.ProductType{
+		Name:        "shirt",
+		Description: "shirts",
+		Attributes: map[string]models.AttributeSchema{
+			"size":  {Type: "string", Required: true, AllowedValues: []interface{}{"S"}, Pattern: "^[A-Z]+$"},
+			"chest": {Type: "integer", Minimum: &minimum},
+			"fit":   {},
+		},
+		AllowAdditionalAttributes: true,
+		JsonSchema:                `{"type": "object"}`,
+	}, dbProductType)
+}
+
+func TestProductTypeModelToProductTypeProtobuf(t *testing.T) {
+	maximum := 2.0
+	testCases := []struct {
+		name                 string
+		input                *models.ProductType
+		mockStructpbNewValue func(v interface{}) (*structpb.Value, error)
+		expectedOutput       *productcatalog.ProductType
+		expectedError        error
+	}{
+		{
+			name: "happy path",
+			input: &models.ProductType{
+				Name: "shirt",
+				Attributes: map[string]models.AttributeSchema{
+					"sizes": {Type: "list", AllowedValues: []interface{}{primitive.A{"S", "M"}}},
+					"width": {Type: "number", Maximum: &maximum},
+				},
+				JsonSchema: `{"type": "object"}`,
+			},
+			expectedOutput: &productcatalog.ProductType{
+				Name: "shirt",
+				Attributes: map[string]*productcatalog.AttributeSchema{
+					"sizes": {
+						Type: productcatalog.AttributeSchema_LIST,
+						AllowedValues: []*structpb.Value{
+							structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("S"), structpb.NewStringValue("M")}}),
+						},
+					},
+					"width": {Type: productcatalog.AttributeSchema_NUMBER, Maximum: &maximum},
+				},
+				JsonSchema: `{"type": "object"}`,
+			},
+		},
+		{
+			name: "error",
+			input: &models.ProductType{
+				Name:       "shirt",
+				Attributes: map[string]models.AttributeSchema{"size": {Type: "string", AllowedValues: []interface{}{"S"}}},
+			},
+			mockStructpbNewValue: func(v interface{}) (*structpb.Value, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New(`parsing allowed value of attribute "size": random error`),
+		},
+	}
+	originalStructpbNewValue := structpbNewValue
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockStructpbNewValue != nil {
+				structpbNewValue = tc.mockStructpbNewValue
+			} else {
+				structpbNewValue = originalStructpbNewValue
+			}
+			defer func() { structpbNewValue = originalStructpbNewValue }()
+			output, err := Mapper{}.ProductTypeModelToProductTypeProtobuf(tc.input)
 			if err != nil {
 				if tc.expectedError == nil {
 					t.Fatalf("expected no error, got %v", err)
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package mapper
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/type/money"
+)
+
+// nanosPerUnit is the number of nanos in a unit of a Protobuf Money message.
+const nanosPerUnit = 1_000_000_000
+
+// moneyToModel converts a Protobuf Money message, found at the given field,
+// to a MongoDB Money model whose amount is the exact decimal of its units and nanos.
+func moneyToModel(field string, m *money.Money) (models.Money, error) {
+	units, nanos := m.GetUnits(), int64(m.GetNanos())
+	if nanos <= -nanosPerUnit || nanos >= nanosPerUnit || (units > 0 && nanos < 0) || (units < 0 && nanos > 0) {
+		return models.Money{}, product.NewInvalidArgumentError(product.FieldViolation{
+			Field:       field + ".nanos",
+			Description: "must be between -999999999 and 999999999, with the sign of units",
+		})
+	}
+	n := new(big.Int).Mul(big.NewInt(units), big.NewInt(nanosPerUnit))
+	n.Add(n, big.NewInt(nanos))
+	// Trailing zeros are dropped, so that 19.99 is not stored as 19.990000000.
+	exp := -9
+	ten, r := big.NewInt(10), new(big.Int)
+	for exp < 0 && n.Sign() != 0 {
+		q, _ := new(big.Int).QuoRem(n, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		n, exp = q, exp+1
+	}
+	if n.Sign() == 0 {
+		exp = 0
+	}
+	// Every amount of a Money message fits in the 34 digits of a Decimal128.
+	amount, _ := primitive.ParseDecimal128FromBigInt(n, exp)
+	return models.Money{CurrencyCode: m.GetCurrencyCode(), Amount: amount}, nil
+}
+
+// moneyFromModel converts a MongoDB Money model to a Protobuf Money message,
+// failing if its amount cannot be represented exactly in units and nanos.
+func moneyFromModel(m models.Money) (*money.Money, error) {
+	n, exp, err := m.Amount.BigInt()
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting amount %s", m.Amount)
+	}
+	// Scale the amount to nanos.
+	switch {
+	case exp > -9:
+		n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp+9)), nil))
+	case exp < -9:
+		q, r := new(big.Int).QuoRem(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-9-exp)), nil), new(big.Int))
+		if r.Sign() != 0 {
+			return nil, errors.Errorf("amount %s has more than 9 decimal places", m.Amount)
+		}
+		n = q
+	}
+	units, nanos := new(big.Int).QuoRem(n, big.NewInt(nanosPerUnit), new(big.Int))
+	if !units.IsInt64() {
+		return nil, errors.Errorf("amount %s is out of range", m.Amount)
+	}
+	return &money.Money{CurrencyCode: m.CurrencyCode, Units: units.Int64(), Nanos: int32(nanos.Int64())}, nil
+}
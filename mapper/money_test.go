@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package mapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMoneyToModel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          *money.Money
+		expectedOutput models.Money
+		expectedError  error
+	}{
+		{
+			name:           "units and nanos",
+			input:          &money.Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+			expectedOutput: models.Money{CurrencyCode: "USD", Amount: decimal128("19.99")},
+		},
+		{
+			name:           "units only",
+			input:          &money.Money{CurrencyCode: "JPY", Units: 1500},
+			expectedOutput: models.Money{CurrencyCode: "JPY", Amount: decimal128("1500")},
+		},
+		{
+			name:           "nanos only",
+			input:          &money.Money{CurrencyCode: "BTC", Nanos: 1},
+			expectedOutput: models.Money{CurrencyCode: "BTC", Amount: decimal128("0.000000001")},
+		},
+		{
+			name:           "zero",
+			input:          &money.Money{CurrencyCode: "EUR"},
+			expectedOutput: models.Money{CurrencyCode: "EUR", Amount: decimal128("0")},
+		},
+		{
+			name:           "negative",
+			input:          &money.Money{CurrencyCode: "USD", Units: -1, Nanos: -750000000},
+			expectedOutput: models.Money{CurrencyCode: "USD", Amount: decimal128("-1.75")},
+		},
+		{
+			name:           "largest",
+			input:          &money.Money{CurrencyCode: "USD", Units: 9223372036854775807, Nanos: 999999999},
+			expectedOutput: models.Money{CurrencyCode: "USD", Amount: decimal128("9223372036854775807.999999999")},
+		},
+		{
+			name:          "nanos out of range",
+			input:         &money.Money{CurrencyCode: "USD", Nanos: 1000000000},
+			expectedError: errors.New("invalid argument: prices[0].nanos: must be between -999999999 and 999999999, with the sign of units"),
+		},
+		{
+			name:          "nanos with another sign than units",
+			input:         &money.Money{CurrencyCode: "USD", Units: 1, Nanos: -1},
+			expectedError: errors.New("invalid argument: prices[0].nanos: must be between -999999999 and 999999999, with the sign of units"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := moneyToModel("prices[0]", tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestMoneyFromModel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          models.Money
+		expectedOutput *money.Money
+		expectedError  error
+	}{
+		{
+			name:           "decimal",
+			input:          models.Money{CurrencyCode: "USD", Amount: decimal128("19.99")},
+			expectedOutput: &money.Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+		},
+		{
+			name:           "trailing zeros",
+			input:          models.Money{CurrencyCode: "USD", Amount: decimal128("19.9900000000000")},
+			expectedOutput: &money.Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+		},
+		{
+			name:           "exponent",
+			input:          models.Money{CurrencyCode: "JPY", Amount: decimal128("1.5E+3")},
+			expectedOutput: &money.Money{CurrencyCode: "JPY", Units: 1500},
+		},
+		{
+			name:           "negative",
+			input:          models.Money{CurrencyCode: "USD", Amount: decimal128("-1.75")},
+			expectedOutput: &money.Money{CurrencyCode: "USD", Units: -1, Nanos: -750000000},
+		},
+		{
+			name:          "too many decimal places",
+			input:         models.Money{CurrencyCode: "USD", Amount: decimal128("0.0000000001")},
+			expectedError: errors.New("amount 1E-10 has more than 9 decimal places"),
+		},
+		{
+			name:          "out of range",
+			input:         models.Money{CurrencyCode: "USD", Amount: decimal128("1E+19")},
+			expectedError: errors.New("amount 1E+19 is out of range"),
+		},
+		{
+			name:          "not a number",
+			input:         models.Money{CurrencyCode: "USD", Amount: decimal128("NaN")},
+			expectedError: errors.New("converting amount NaN: cannot parse NaN as a *big.Int"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := moneyFromModel(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.True(t, proto.Equal(tc.expectedOutput, output), "got %v", output)
+			}
+		})
+	}
+}
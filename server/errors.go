@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package server
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// productResourceType is the resource type reported in ResourceInfo error details.
+const productResourceType = "productcatalog.Product"
+
+// kindToCode maps the kinds of errors returned by the product package to gRPC codes.
+var kindToCode = map[product.Kind]codes.Code{
+	product.KindNotFound:           codes.NotFound,
+	product.KindAlreadyExists:      codes.AlreadyExists,
+	product.KindInvalidArgument:    codes.InvalidArgument,
+	product.KindConflict:           codes.Aborted,
+	product.KindUnavailable:        codes.Unavailable,
+	product.KindFailedPrecondition: codes.FailedPrecondition,
+}
+
+// toStatus translates an error into a gRPC status error,
+// attaching google.rpc error details when they help the client.
+// Errors that cannot be classified are reported as codes.Internal.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	var perr *product.Error
+	if !errors.As(err, &perr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := kindToCode[perr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+	st := status.New(code, err.Error())
+	var details []protoiface.MessageV1
+	switch perr.Kind {
+	case product.KindInvalidArgument:
+		br := &errdetails.BadRequest{}
+		for _, v := range perr.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, br)
+	case product.KindNotFound, product.KindAlreadyExists, product.KindConflict:
+		if name := resourceName(perr); name != "" {
+			details = append(details, &errdetails.ResourceInfo{
+				ResourceType: productResourceType,
+				ResourceName: name,
+				Description:  err.Error(),
+			})
+		}
+	}
+	if len(details) == 0 {
+		return st.Err()
+	}
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// resourceName returns the name of the product an error refers to: its uuid,
+// or, for products only known by a natural key, the key as in sku/SKU-1.
+func resourceName(perr *product.Error) string {
+	if perr.Uuid != "" || perr.Key == "" {
+		return perr.Uuid
+	}
+	return perr.KeyField + "/" + perr.Key
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           error
+		expectedCode    codes.Code
+		expectedDetails int
+	}{
+		{
+			name:            "not found",
+			input:           &product.Error{Kind: product.KindNotFound, Uuid: "uuid"},
+			expectedCode:    codes.NotFound,
+			expectedDetails: 1,
+		},
+		{
+			name:            "already exists",
+			input:           &product.Error{Kind: product.KindAlreadyExists, Uuid: "uuid"},
+			expectedCode:    codes.AlreadyExists,
+			expectedDetails: 1,
+		},
+		{
+			name: "invalid argument",
+			input: &product.Error{Kind: product.KindInvalidArgument, Violations: []product.FieldViolation{
+				{Field: "name", Description: "must not be empty"},
+			}},
+			expectedCode:    codes.InvalidArgument,
+			expectedDetails: 1,
+		},
+		{
+			name:         "conflict",
+			input:        &product.Error{Kind: product.KindConflict},
+			expectedCode: codes.Aborted,
+		},
+		{
+			name:         "unavailable",
+			input:        &product.Error{Kind: product.KindUnavailable},
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "failed precondition",
+			input:        &product.Error{Kind: product.KindFailedPrecondition},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "deadline exceeded",
+			input:        context.DeadlineExceeded,
+			expectedCode: codes.DeadlineExceeded,
+		},
+		{
+			name:         "canceled",
+			input:        context.Canceled,
+			expectedCode: codes.Canceled,
+		},
+		{
+			name:         "unknown error",
+			input:        errors.New("random error"),
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "status error",
+			input:        status.Error(codes.PermissionDenied, "random error"),
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(toStatus(tc.input))
+			require.True(t, ok)
+			require.Equal(t, tc.expectedCode, st.Code())
+			require.Len(t, st.Details(), tc.expectedDetails)
+		})
+	}
+}
+
+func TestToStatusFieldViolations(t *testing.T) {
+	err := &product.Error{Kind: product.KindInvalidArgument, Violations: []product.FieldViolation{
+		{Field: "name", Description: "must not be empty"},
+	}}
+	st, _ := status.FromError(toStatus(err))
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Equal(t, "name", br.FieldViolations[0].Field)
+	require.Equal(t, "must not be empty", br.FieldViolations[0].Description)
+}
+
+func TestToStatusResourceName(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          *product.Error
+		expectedOutput string
+	}{
+		{
+			name:           "uuid",
+			input:          &product.Error{Kind: product.KindNotFound, Uuid: "uuid"},
+			expectedOutput: "uuid",
+		},
+		{
+			name:           "natural key",
+			input:          &product.Error{Kind: product.KindNotFound, KeyField: "sku", Key: "SKU-1"},
+			expectedOutput: "sku/SKU-1",
+		},
+		{
+			name:           "uuid and natural key",
+			input:          &product.Error{Kind: product.KindAlreadyExists, Uuid: "uuid", KeyField: "slug", Key: "a"},
+			expectedOutput: "uuid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, _ := status.FromError(toStatus(tc.input))
+			info, ok := st.Details()[0].(*errdetails.ResourceInfo)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedOutput, info.ResourceName)
+		})
+	}
+}
+
+func TestToStatusNil(t *testing.T) {
+	require.Nil(t, toStatus(nil))
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/auth"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// A policy grants roles to callers, and each role the methods of the product catalog
+// service it may call. Some fields, such as prices, may be protected: writing them,
+// or anything under them, also requires a role granting that field. A caller has the
+// roles its bearer token names, and the ones the policy binds to its subject, named
+// after how the caller was authenticated: apikey:<name> for API keys, jwt:<sub> for
+// bearer tokens and cert:<common name> for client certificates.
+//
+//	protected_fields: [prices, attributes.pricing]
+//	roles:
+//	  merchandiser:
+//	    methods: [GetProduct, ListProducts, CreateProduct, UpdateProduct]
+//	  pricing:
+//	    methods: [GetProduct, UpdateProduct]
+//	    fields: [prices, attributes.pricing]
+//	subjects:
+//	  apikey:pricing-service: [pricing]
+//
+// A * grants every method or every protected field.
+
+// wildcard grants every method or protected field.
+const wildcard = "*"
+
+// productFields are the fields of a product a write may touch,
+// and that policies may protect, besides attributes.<key>.
+var productFields = []string{"name", "description", "prices", "sku", "slug", "product_type", "attributes"}
+
+// subjectPrefixes are the prefixes of the subjects of policies by authentication method,
+// so that a certificate does not get the roles of an API key of the same name.
+var subjectPrefixes = map[string]string{
+	auth.MethodAPIKey:            "apikey:",
+	auth.MethodJWT:               "jwt:",
+	auth.MethodClientCertificate: "cert:",
+}
+
+// policyFile is the YAML representation of a policy.
+type policyFile struct {
+	ProtectedFields []string `yaml:"protected_fields"`
+	Roles           map[string]struct {
+		Methods []string `yaml:"methods"`
+		Fields  []string `yaml:"fields"`
+	} `yaml:"roles"`
+	Subjects map[string][]string `yaml:"subjects"`
+}
+
+// role is what a role of a policy grants.
+type role struct {
+	methods map[string]bool
+	fields  map[string]bool
+}
+
+// Policy is a role-based access control policy of the product catalog service.
+type Policy struct {
+	protected []string
+	roles     map[string]role
+	subjects  map[string][]string
+}
+
+// ParsePolicy parses the YAML policy in data.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var f policyFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "parsing policy")
+	}
+	p := &Policy{roles: make(map[string]role), subjects: f.Subjects}
+	for _, field := range f.ProtectedFields {
+		if !isProductField(field) {
+			return nil, errors.Errorf(`protected field "%s" is not a field of products`, field)
+		}
+		p.protected = append(p.protected, field)
+	}
+	methods := serviceMethods()
+	for name, r := range f.Roles {
+		granted := role{methods: make(map[string]bool), fields: make(map[string]bool)}
+		for _, m := range r.Methods {
+			if m != wildcard && !methods[m] {
+				return nil, errors.Errorf(`role "%s" grants unknown method "%s"`, name, m)
+			}
+			granted.methods[m] = true
+		}
+		for _, field := range r.Fields {
+			if field != wildcard && !contains(p.protected, field) {
+				return nil, errors.Errorf(`role "%s" grants field "%s", which is not protected`, name, field)
+			}
+			granted.fields[field] = true
+		}
+		p.roles[name] = granted
+	}
+	for subject, roles := range f.Subjects {
+		if !isPolicySubject(subject) {
+			return nil, errors.Errorf(`subject "%s" must start with apikey:, jwt: or cert:`, subject)
+		}
+		for _, r := range roles {
+			if _, ok := p.roles[r]; !ok {
+				return nil, errors.Errorf(`subject "%s" has unknown role "%s"`, subject, r)
+			}
+		}
+	}
+	return p, nil
+}
+
+// isPolicySubject reports whether subject names a subject authenticated by some method.
+func isPolicySubject(subject string) bool {
+	for _, prefix := range subjectPrefixes {
+		if strings.HasPrefix(subject, prefix) && len(subject) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// policySubject returns the subject of a caller in policies,
+// or an empty string when it was authenticated by an unknown method.
+func policySubject(id *auth.Identity) string {
+	prefix, ok := subjectPrefixes[id.Method]
+	if !ok {
+		return ""
+	}
+	return prefix + id.Subject
+}
+
+// serviceMethods returns the names of the methods of the product catalog service.
+func serviceMethods() map[string]bool {
+	desc := productcatalog.ProductCatalogService_ServiceDesc
+	methods := make(map[string]bool)
+	for _, m := range desc.Methods {
+		methods[m.MethodName] = true
+	}
+	for _, s := range desc.Streams {
+		methods[s.StreamName] = true
+	}
+	return methods
+}
+
+// isProductField reports whether field is a field of products,
+// or the path of an attribute.
+func isProductField(field string) bool {
+	if key := strings.TrimPrefix(field, "attributes."); key != field {
+		return key != ""
+	}
+	return contains(productFields, field)
+}
+
+// contains reports whether values holds v.
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether writing the field at path writes the protected field,
+// either because it is under the other, or replaces it along with its parent.
+func overlaps(path, protected string) bool {
+	return path == wildcard || path == protected ||
+		strings.HasPrefix(path, protected+".") || strings.HasPrefix(protected, path+".")
+}
+
+// authorize returns a PermissionDenied error unless the caller of the given
+// context may call method, writing the fields at the given paths.
+func (p *Policy) authorize(ctx context.Context, method string, paths []string) error {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "caller is not authenticated")
+	}
+	var roles []role
+	for _, name := range append(append([]string{}, id.Roles...), p.subjects[policySubject(id)]...) {
+		if r, ok := p.roles[name]; ok {
+			roles = append(roles, r)
+		}
+	}
+	if !grants(roles, func(r role) bool { return r.methods[wildcard] || r.methods[method] }) {
+		return status.Errorf(codes.PermissionDenied, `"%s" may not call %s`, id.Subject, method)
+	}
+	for _, protected := range p.protected {
+		for _, path := range paths {
+			if !overlaps(path, protected) {
+				continue
+			}
+			if !grants(roles, func(r role) bool { return r.fields[wildcard] || r.fields[protected] }) {
+				return status.Errorf(codes.PermissionDenied, `"%s" may not write %s`, id.Subject, protected)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// grants reports whether any of the given roles is granted.
+func grants(roles []role, granted func(role) bool) bool {
+	for _, r := range roles {
+		if granted(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenPaths returns the paths of the fields a request writes.
+// Products being created, or updated without an update mask, write their
+// populated fields.
+func writtenPaths(req interface{}) []string {
+	var paths []string
+	switch r := req.(type) {
+	case *productcatalog.CreateProductRequest:
+		paths = populatedPaths(r.GetProduct())
+	case *productcatalog.UpdateProductRequest:
+		paths = updatedPaths(r)
+	case *productcatalog.BatchCreateProductsRequest:
+		for _, p := range r.GetProducts() {
+			paths = append(paths, populatedPaths(p)...)
+		}
+	case *productcatalog.BatchUpdateProductsRequest:
+		for _, u := range r.GetRequests() {
+			paths = append(paths, updatedPaths(u)...)
+		}
+	}
+	return paths
+}
+
+// updatedPaths returns the paths of the fields an update writes, as the
+// repository trims them. Updates whose mask it rejects write nothing.
+func updatedPaths(r *productcatalog.UpdateProductRequest) []string {
+	paths := r.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return populatedPaths(r.GetProduct())
+	}
+	trimmed, err := product.TrimUpdateMask(paths)
+	if err != nil {
+		return nil
+	}
+	return trimmed
+}
+
+// populatedPaths returns the paths of the populated fields of a product.
+func populatedPaths(p *productcatalog.Product) []string {
+	var paths []string
+	for field, populated := range map[string]bool{
+		"name":         p.GetName() != "",
+		"description":  p.GetDescription() != "",
+		"prices":       len(p.GetPrices()) > 0,
+		"sku":          p.GetSku() != "",
+		"slug":         p.GetSlug() != "",
+		"product_type": p.GetProductType() != "",
+	} {
+		if populated {
+			paths = append(paths, field)
+		}
+	}
+	for key := range p.GetAttributes() {
+		paths = append(paths, "attributes."+key)
+	}
+	for key := range p.GetTypedAttributes() {
+		paths = append(paths, "attributes."+key)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// serviceMethod returns the name of the product catalog service method
+// of a full method name, or false for methods of other services.
+func serviceMethod(fullMethod string) (string, bool) {
+	prefix := fmt.Sprintf("/%s/", productcatalog.ProductCatalogService_ServiceDesc.ServiceName)
+	method := strings.TrimPrefix(fullMethod, prefix)
+	return method, method != fullMethod
+}
+
+// UnaryServerInterceptor returns a unary interceptor enforcing the policy on the
+// methods of the product catalog service. It must follow the interceptor
+// authenticating callers. Methods of other services, such as reflection, are allowed.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if method, ok := serviceMethod(info.FullMethod); ok {
+			if err := p.authorize(ctx, method, writtenPaths(req)); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor enforcing the policy on the
+// methods of the product catalog service, like UnaryServerInterceptor.
+// As imported products replace every field, importing requires every protected field.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if method, ok := serviceMethod(info.FullMethod); ok {
+			var paths []string
+			if info.IsClientStream {
+				paths = []string{wildcard}
+			}
+			if err := p.authorize(ss.Context(), method, paths); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
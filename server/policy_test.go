@@ -0,0 +1,349 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/auth"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const testPolicy = `
+protected_fields: [prices, attributes.pricing]
+roles:
+  reader:
+    methods: [GetProduct, ListProducts, StreamProducts]
+  merchandiser:
+    methods: [GetProduct, ListProducts, CreateProduct, UpdateProduct, BatchUpdateProducts]
+  pricing:
+    methods: [GetProduct, UpdateProduct]
+    fields: [prices, attributes.pricing]
+  loader:
+    methods: [ImportProducts]
+  admin:
+    methods: ["*"]
+    fields: ["*"]
+subjects:
+  apikey:importer: [admin]
+  apikey:loader: [loader]
+  cert:pricing-service: [pricing]
+`
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expectedError error
+	}{
+		{
+			name:  "happy path",
+			input: testPolicy,
+		},
+		{
+			name:          "invalid YAML",
+			input:         "roles: [",
+			expectedError: errors.New("parsing policy: yaml: line 1: did not find expected node content"),
+		},
+		{
+			name:          "unknown key",
+			input:         "role: {}",
+			expectedError: errors.New("parsing policy: yaml: unmarshal errors:\n  line 1: field role not found in type server.policyFile"),
+		},
+		{
+			name:          "unknown protected field",
+			input:         "protected_fields: [price]",
+			expectedError: errors.New(`protected field "price" is not a field of products`),
+		},
+		{
+			name:          "unknown method",
+			input:         "roles: {merchandiser: {methods: [RemoveProduct]}}",
+			expectedError: errors.New(`role "merchandiser" grants unknown method "RemoveProduct"`),
+		},
+		{
+			name:          "field not protected",
+			input:         "roles: {merchandiser: {fields: [name]}}",
+			expectedError: errors.New(`role "merchandiser" grants field "name", which is not protected`),
+		},
+		{
+			name:          "unknown role",
+			input:         "subjects: {apikey:importer: [admin]}",
+			expectedError: errors.New(`subject "apikey:importer" has unknown role "admin"`),
+		},
+		{
+			name:          "subject without authentication method",
+			input:         "roles: {admin: {}}\nsubjects: {importer: [admin]}",
+			expectedError: errors.New(`subject "importer" must start with apikey:, jwt: or cert:`),
+		},
+		{
+			name:          "subject without name",
+			input:         "roles: {admin: {}}\nsubjects: {\"jwt:\": [admin]}",
+			expectedError: errors.New(`subject "jwt:" must start with apikey:, jwt: or cert:`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tc.input))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.Nil(t, err)
+	merchandiser := &auth.Identity{Subject: "alice", Roles: []string{"merchandiser"}}
+	testCases := []struct {
+		name          string
+		identity      *auth.Identity
+		method        string
+		paths         []string
+		expectedError error
+	}{
+		{
+			name:     "method granted by a role of the token",
+			identity: merchandiser,
+			method:   "UpdateProduct",
+			paths:    []string{"name", "attributes.color"},
+		},
+		{
+			name:          "method not granted",
+			identity:      merchandiser,
+			method:        "DeleteProduct",
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "alice" may not call DeleteProduct`),
+		},
+		{
+			name:          "protected field",
+			identity:      merchandiser,
+			method:        "UpdateProduct",
+			paths:         []string{"name", "prices"},
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "alice" may not write prices`),
+		},
+		{
+			name:          "under a protected attribute",
+			identity:      merchandiser,
+			method:        "UpdateProduct",
+			paths:         []string{"attributes.pricing.cost"},
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "alice" may not write attributes.pricing`),
+		},
+		{
+			name:          "replacing every attribute",
+			identity:      merchandiser,
+			method:        "UpdateProduct",
+			paths:         []string{"attributes"},
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "alice" may not write attributes.pricing`),
+		},
+		{
+			name:          "replacing every field",
+			identity:      merchandiser,
+			method:        "UpdateProduct",
+			paths:         []string{"*"},
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "alice" may not write prices`),
+		},
+		{
+			name:     "attribute sharing a prefix with a protected one",
+			identity: merchandiser,
+			method:   "UpdateProduct",
+			paths:    []string{"attributes.pricingNotes"},
+		},
+		{
+			name:     "protected fields granted by a role of the subject",
+			identity: &auth.Identity{Subject: "pricing-service", Method: auth.MethodClientCertificate},
+			method:   "UpdateProduct",
+			paths:    []string{"prices", "attributes.pricing"},
+		},
+		{
+			name:     "wildcards",
+			identity: &auth.Identity{Subject: "importer", Method: auth.MethodAPIKey},
+			method:   "ImportProducts",
+			paths:    []string{"*"},
+		},
+		{
+			name:     "roles of the token and of the subject",
+			identity: &auth.Identity{Subject: "pricing-service", Method: auth.MethodClientCertificate, Roles: []string{"merchandiser"}},
+			method:   "CreateProduct",
+			paths:    []string{"name", "prices"},
+		},
+		{
+			name:          "certificate named after an API key",
+			identity:      &auth.Identity{Subject: "importer", Method: auth.MethodClientCertificate},
+			method:        "GetProduct",
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "importer" may not call GetProduct`),
+		},
+		{
+			name:          "token named after a certificate",
+			identity:      &auth.Identity{Subject: "pricing-service", Method: auth.MethodJWT},
+			method:        "GetProduct",
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "pricing-service" may not call GetProduct`),
+		},
+		{
+			name:          "unknown roles",
+			identity:      &auth.Identity{Subject: "bob", Roles: []string{"owner"}},
+			method:        "GetProduct",
+			expectedError: errors.New(`rpc error: code = PermissionDenied desc = "bob" may not call GetProduct`),
+		},
+		{
+			name:          "not authenticated",
+			method:        "GetProduct",
+			expectedError: errors.New("rpc error: code = PermissionDenied desc = caller is not authenticated"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			if tc.identity != nil {
+				ctx = auth.NewContext(ctx, tc.identity)
+			}
+			err := policy.authorize(ctx, tc.method, tc.paths)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestWrittenPaths(t *testing.T) {
+	p := &productcatalog.Product{
+		Name:            "name",
+		Prices:          []*money.Money{{CurrencyCode: "USD", Units: 1}},
+		Attributes:      map[string]*structpb.Value{"color": structpb.NewStringValue("red")},
+		TypedAttributes: map[string]*productcatalog.AttributeValue{"pricing": {Kind: &productcatalog.AttributeValue_IntValue{IntValue: 1}}},
+	}
+	testCases := []struct {
+		name           string
+		input          interface{}
+		expectedOutput []string
+	}{
+		{
+			name:           "create",
+			input:          &productcatalog.CreateProductRequest{Product: p},
+			expectedOutput: []string{"attributes.color", "attributes.pricing", "name", "prices"},
+		},
+		{
+			name:           "update without update mask",
+			input:          &productcatalog.UpdateProductRequest{Product: p},
+			expectedOutput: []string{"attributes.color", "attributes.pricing", "name", "prices"},
+		},
+		{
+			name:           "update with update mask",
+			input:          &productcatalog.UpdateProductRequest{Product: p, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description"}}},
+			expectedOutput: []string{"description"},
+		},
+		{
+			name:           "update with padded update mask",
+			input:          &productcatalog.UpdateProductRequest{Product: p, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{" prices", "attributes.pricing ", "prices"}}},
+			expectedOutput: []string{"prices", "attributes.pricing"},
+		},
+		{
+			name:  "update with empty update mask path",
+			input: &productcatalog.UpdateProductRequest{Product: p, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"prices", " "}}},
+		},
+		{
+			name:           "batch create",
+			input:          &productcatalog.BatchCreateProductsRequest{Products: []*productcatalog.Product{{Sku: "sku"}, {Slug: "slug"}}},
+			expectedOutput: []string{"sku", "slug"},
+		},
+		{
+			name: "batch update",
+			input: &productcatalog.BatchUpdateProductsRequest{Requests: []*productcatalog.UpdateProductRequest{
+				{Product: &productcatalog.Product{ProductType: "shirt"}},
+				{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"*"}}},
+			}},
+			expectedOutput: []string{"product_type", "*"},
+		},
+		{
+			name:  "read",
+			input: &productcatalog.GetProductRequest{Uuid: "uuid"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, writtenPaths(tc.input))
+		})
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with the given context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestPolicyInterceptors(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.Nil(t, err)
+	merchandiser := auth.NewContext(context.TODO(), &auth.Identity{Subject: "alice", Roles: []string{"merchandiser"}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+	streamHandler := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+	unary := policy.UnaryServerInterceptor()
+	stream := policy.StreamServerInterceptor()
+
+	response, err := unary(merchandiser, &productcatalog.UpdateProductRequest{Product: &productcatalog.Product{Name: "name"}},
+		&grpc.UnaryServerInfo{FullMethod: productcatalog.ProductCatalogService_UpdateProduct_FullMethodName}, handler)
+	require.Nil(t, err)
+	require.Equal(t, "response", response)
+
+	prices := &productcatalog.Product{Prices: []*money.Money{{CurrencyCode: "USD", Units: 1}}}
+	_, err = unary(merchandiser, &productcatalog.UpdateProductRequest{Product: prices},
+		&grpc.UnaryServerInfo{FullMethod: productcatalog.ProductCatalogService_UpdateProduct_FullMethodName}, handler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "alice" may not write prices`, err.Error())
+
+	// Update mask paths are checked as the repository trims them.
+	_, err = unary(merchandiser, &productcatalog.UpdateProductRequest{Product: prices, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{" prices"}}},
+		&grpc.UnaryServerInfo{FullMethod: productcatalog.ProductCatalogService_UpdateProduct_FullMethodName}, handler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "alice" may not write prices`, err.Error())
+	_, err = unary(merchandiser, &productcatalog.BatchUpdateProductsRequest{Requests: []*productcatalog.UpdateProductRequest{
+		{Product: &productcatalog.Product{Uuid: "uuid"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+		{Product: &productcatalog.Product{Uuid: "uuid2"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.pricing "}}},
+	}}, &grpc.UnaryServerInfo{FullMethod: productcatalog.ProductCatalogService_BatchUpdateProducts_FullMethodName}, handler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "alice" may not write attributes.pricing`, err.Error())
+
+	_, err = unary(merchandiser, &productcatalog.DeleteProductRequest{Uuid: "uuid"},
+		&grpc.UnaryServerInfo{FullMethod: productcatalog.ProductCatalogService_DeleteProduct_FullMethodName}, handler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "alice" may not call DeleteProduct`, err.Error())
+
+	// Methods of other services are not subject to the policy.
+	_, err = unary(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.Nil(t, err)
+
+	err = stream(nil, &fakeServerStream{ctx: merchandiser},
+		&grpc.StreamServerInfo{FullMethod: productcatalog.ProductCatalogService_StreamProducts_FullMethodName, IsServerStream: true}, streamHandler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "alice" may not call StreamProducts`, err.Error())
+
+	// Importing replaces every field, protected ones included.
+	loader := auth.NewContext(context.TODO(), &auth.Identity{Subject: "loader", Method: auth.MethodAPIKey})
+	importInfo := &grpc.StreamServerInfo{FullMethod: productcatalog.ProductCatalogService_ImportProducts_FullMethodName, IsClientStream: true}
+	err = stream(nil, &fakeServerStream{ctx: loader}, importInfo, streamHandler)
+	require.Equal(t, `rpc error: code = PermissionDenied desc = "loader" may not write prices`, err.Error())
+	importer := auth.NewContext(context.TODO(), &auth.Identity{Subject: "importer", Method: auth.MethodAPIKey})
+	err = stream(nil, &fakeServerStream{ctx: importer}, importInfo, streamHandler)
+	require.Nil(t, err)
+}
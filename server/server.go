@@ -7,113 +7,431 @@
 //
 // The server package is responsible for setting up the gRPC server,
 // registering the product catalog service, and routing incoming gRPC
-// requests to the corresponding functions in the product package.
+// requests to the product repository the server was created with.
+//
+// Errors returned by the product package are translated into gRPC status
+// codes, with google.rpc error details where they are meaningful.
 package server
 
 import (
 	"context"
+	"io"
 
-	"github.com/pkg/errors"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/mapper"
-	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 // server implements the ProductCatalogServiceServer interface.
 // It handles the gRPC requests and delegates the actual processing to
-// the corresponding functions in the product package.
+// the product repository.
 type server struct {
 	productcatalog.UnimplementedProductCatalogServiceServer
 	GrpcSrv *grpc.Server
-	db      *store.MongoDb
+	repo    product.ProductRepository
+	mapper  mapper.Mapper
 }
 
-// New creates a new instance of the server with the provided database client.
-// It sets up the gRPC server, registers the product catalog service,
+// New creates a new instance of the server with the provided product repository,
+// converting messages with the given mapper. It sets up the gRPC server with the given options, such as the interceptors
+// authenticating callers, registers the product catalog service,
 // and initializes reflection for gRPC server debugging.
-func New(db *store.MongoDb) *server {
-	grpcServer := grpc.NewServer()
+func New(repo product.ProductRepository, m mapper.Mapper, opts ...grpc.ServerOption) *server {
+	grpcServer := grpc.NewServer(opts...)
 	srv := &server{
 		GrpcSrv: grpcServer,
-		db:      db}
+		repo:    repo,
+		mapper:  m}
 	productcatalog.RegisterProductCatalogServiceServer(grpcServer, srv)
 	reflection.Register(grpcServer)
 	return srv
 }
 
 // CreateProduct creates a new product in the catalog.
-// It delegates the actual creation logic to the product package's Create function.
-func (s *server) CreateProduct(ctx context.Context, in *productcatalog.Product) (*productcatalog.Product, error) {
-	newProduct, err := mapper.ProductProtobufToProductModel(in)
+// It delegates the actual creation logic to the repository's Create method.
+func (s *server) CreateProduct(ctx context.Context, in *productcatalog.CreateProductRequest) (*productcatalog.Product, error) {
+	newProduct, err := s.mapper.ProductProtobufToProductModel(in.GetProduct())
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
-	createdProduct, err := product.Create(ctx, s.db, newProduct)
+	createdProduct, err := s.repo.Create(ctx, newProduct, in.GetProductId(), in.GetRequestId())
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
-	protoResponse, err := mapper.ProductModelToProductProtobuf(createdProduct)
+	protoResponse, err := s.mapper.ProductModelToProductProtobuf(createdProduct)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return protoResponse, nil
 }
 
-// GetProduct retrieves a product by its ID from the catalog.
-// It delegates the actual retrieval logic to the product package's Get function.
+// GetProduct retrieves a product by its ID, SKU or slug from the catalog.
+// It delegates the actual retrieval logic to the repository's Get method.
 func (s *server) GetProduct(ctx context.Context, in *productcatalog.GetProductRequest) (*productcatalog.Product, error) {
-	product, err := product.Get(ctx, s.db, in)
+	product, err := s.repo.Get(ctx, in)
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting product with uuid %s", in.Uuid)
+		return nil, toStatus(err)
 	}
-	protoResponse, err := mapper.ProductModelToProductProtobuf(product)
+	protoResponse, err := s.mapper.ProductModelToProductProtobuf(product)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return protoResponse, nil
 }
 
-// UpdateProduct updates an existing product in the catalog.
-// It delegates the actual update logic to the product package's Update function.
-func (s *server) UpdateProduct(ctx context.Context, in *productcatalog.Product) (*productcatalog.Product, error) {
-	productToUpdate, err := mapper.ProductProtobufToProductModel(in)
+// UpdateProduct updates the fields of an existing product named by the update mask.
+// It delegates the actual update logic to the repository's Update method.
+func (s *server) UpdateProduct(ctx context.Context, in *productcatalog.UpdateProductRequest) (*productcatalog.Product, error) {
+	productToUpdate, err := s.mapper.ProductProtobufToProductModel(in.GetProduct())
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
-	updatedProduct, err := product.Update(ctx, s.db, productToUpdate)
+	updatedProduct, err := s.repo.Update(ctx, productToUpdate, s.mapper.UpdateMaskPathsToModel(in.GetUpdateMask().GetPaths()), in.GetExpectedRevision())
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
-	protoResponse, err := mapper.ProductModelToProductProtobuf(updatedProduct)
+	protoResponse, err := s.mapper.ProductModelToProductProtobuf(updatedProduct)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return protoResponse, nil
 }
 
 // DeleteProduct deletes a product from the catalog.
-// It delegates the actual deletion logic to the product package's Delete function.
+// It delegates the actual deletion logic to the repository's Delete method.
 func (s *server) DeleteProduct(ctx context.Context, in *productcatalog.DeleteProductRequest) (*productcatalog.DeleteProductResponse, error) {
-	resp, err := product.Delete(ctx, s.db, in)
+	deletedProduct, err := s.repo.Delete(ctx, in)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductModelToDeleteProductResponse(deletedProduct)
 	if err != nil {
-		return nil, errors.Wrapf(err, "deleting product with uuid %s", in.Uuid)
+		return nil, toStatus(err)
 	}
-	return resp, nil
+	return protoResponse, nil
 }
 
-// ListProducts lists all the products in the catalog.
-// It delegates the actual listing logic to the product package's ListProducts function.
+// ListProducts lists one page of products in the catalog.
+// It delegates the actual listing logic to the repository's List method.
 func (s *server) ListProducts(ctx context.Context, in *productcatalog.ListProductsRequest) (*productcatalog.ListProductsResponse, error) {
-	products, err := product.List(ctx, s.db, in)
+	products, nextPageToken, err := s.repo.List(ctx, in)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductModelListToListProductsResponse(products, nextPageToken)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return protoResponse, nil
+}
+
+// BatchCreateProducts creates several products in the catalog.
+// It delegates the actual creation logic to the repository's BatchCreate method.
+func (s *server) BatchCreateProducts(ctx context.Context, in *productcatalog.BatchCreateProductsRequest) (*productcatalog.BatchCreateProductsResponse, error) {
+	newProducts := make([]*models.Product, len(in.GetProducts()))
+	for i, p := range in.GetProducts() {
+		newProduct, err := s.mapper.ProductProtobufToProductModel(p)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		newProducts[i] = newProduct
+	}
+	results, err := s.repo.BatchCreate(ctx, newProducts, in.GetAllOrNothing())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &productcatalog.BatchCreateProductsResponse{Results: s.batchResults(results)}, nil
+}
+
+// BatchUpdateProducts updates several products in the catalog.
+// It delegates the actual update logic to the repository's BatchUpdate method.
+func (s *server) BatchUpdateProducts(ctx context.Context, in *productcatalog.BatchUpdateProductsRequest) (*productcatalog.BatchUpdateProductsResponse, error) {
+	updates := make([]product.ProductUpdate, len(in.GetRequests()))
+	for i, req := range in.GetRequests() {
+		productToUpdate, err := s.mapper.ProductProtobufToProductModel(req.GetProduct())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		updates[i] = product.ProductUpdate{
+			Product:          productToUpdate,
+			Paths:            s.mapper.UpdateMaskPathsToModel(req.GetUpdateMask().GetPaths()),
+			ExpectedRevision: req.GetExpectedRevision(),
+		}
+	}
+	results, err := s.repo.BatchUpdate(ctx, updates, in.GetAllOrNothing())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &productcatalog.BatchUpdateProductsResponse{Results: s.batchResults(results)}, nil
+}
+
+// BatchDeleteProducts deletes several products from the catalog.
+// It delegates the actual deletion logic to the repository's BatchDelete method.
+func (s *server) BatchDeleteProducts(ctx context.Context, in *productcatalog.BatchDeleteProductsRequest) (*productcatalog.BatchDeleteProductsResponse, error) {
+	results, err := s.repo.BatchDelete(ctx, in.GetRequests(), in.GetAllOrNothing())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &productcatalog.BatchDeleteProductsResponse{Results: s.batchResults(results)}, nil
+}
+
+// batchResults converts the outcomes of the items of a batch to Protobuf messages,
+// translating the errors of failed items as the single item calls do.
+func (s *server) batchResults(results []product.BatchResult) []*productcatalog.BatchProductResult {
+	protoResults := make([]*productcatalog.BatchProductResult, len(results))
+	for i, r := range results {
+		var protoProduct *productcatalog.Product
+		err := r.Err
+		if err == nil {
+			protoProduct, err = s.mapper.ProductModelToProductProtobuf(r.Product)
+		}
+		if err != nil {
+			protoResults[i] = &productcatalog.BatchProductResult{Status: status.Convert(toStatus(err)).Proto()}
+			continue
+		}
+		protoResults[i] = &productcatalog.BatchProductResult{
+			Status:  status.New(codes.OK, "").Proto(),
+			Product: protoProduct,
+		}
+	}
+	return protoResults
+}
+
+// StreamProducts streams every product in the catalog matching the request filter.
+// It delegates the actual reading to the repository's Stream method, sending each
+// product as soon as it is read. Send blocks while the client is not keeping up,
+// and the stream context is canceled when the client goes away.
+func (s *server) StreamProducts(in *productcatalog.StreamProductsRequest, stream productcatalog.ProductCatalogService_StreamProductsServer) error {
+	err := s.repo.Stream(stream.Context(), in, func(p *models.Product) error {
+		protoProduct, err := s.mapper.ProductModelToProductProtobuf(p)
+		if err != nil {
+			return err
+		}
+		return stream.Send(protoProduct)
+	})
+	return toStatus(err)
+}
+
+const (
+	// importChunkSize is the number of products the ImportProducts RPC buffers
+	// before writing them to the repository.
+	importChunkSize = 500
+	// maxImportFailures is the maximum number of failures reported by the ImportProducts RPC.
+	maxImportFailures = 1000
+)
+
+// importer accumulates the products received by the ImportProducts RPC,
+// writing them in chunks and summarizing the outcome.
+type importer struct {
+	repo     product.ProductRepository
+	mapper   mapper.Mapper
+	received int64
+	chunk    []*models.Product
+	indexes  []int64 // Stream position of each product of the chunk.
+	response *productcatalog.ImportProductsResponse
+}
+
+// fail records a product that could not be imported.
+func (imp *importer) fail(index int64, err error) {
+	imp.response.FailedCount++
+	if len(imp.response.Failures) < maxImportFailures {
+		imp.response.Failures = append(imp.response.Failures, &productcatalog.ImportFailure{
+			Index:  index,
+			Status: status.Convert(toStatus(err)).Proto(),
+		})
+	}
+}
+
+// add buffers a received product, writing the chunk when it is full.
+func (imp *importer) add(ctx context.Context, in *productcatalog.Product) error {
+	index := imp.received
+	imp.received++
+	p, err := imp.mapper.ProductProtobufToProductModel(in)
+	if err != nil {
+		imp.fail(index, err)
+		return nil
+	}
+	imp.chunk = append(imp.chunk, p)
+	imp.indexes = append(imp.indexes, index)
+	if len(imp.chunk) < importChunkSize {
+		return nil
+	}
+	return imp.flush(ctx)
+}
+
+// flush writes the buffered products.
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.chunk) == 0 {
+		return nil
+	}
+	results, err := imp.repo.BatchUpsert(ctx, imp.chunk)
+	if err != nil {
+		return err
+	}
+	for i, r := range results {
+		switch {
+		case r.Err != nil:
+			imp.fail(imp.indexes[i], r.Err)
+		case r.Created:
+			imp.response.InsertedCount++
+		default:
+			imp.response.UpdatedCount++
+		}
+	}
+	imp.chunk = imp.chunk[:0]
+	imp.indexes = imp.indexes[:0]
+	return nil
+}
+
+// ImportProducts creates or replaces the products streamed by the client.
+// Products are buffered and written in chunks through the repository's BatchUpsert
+// method, so that memory use does not grow with the size of the import.
+// Products that fail do not stop the import, and are reported in the response.
+func (s *server) ImportProducts(stream productcatalog.ProductCatalogService_ImportProductsServer) error {
+	imp := &importer{
+		repo:     s.repo,
+		mapper:   s.mapper,
+		response: new(productcatalog.ImportProductsResponse),
+	}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := imp.add(stream.Context(), in); err != nil {
+			return toStatus(err)
+		}
+	}
+	if err := imp.flush(stream.Context()); err != nil {
+		return toStatus(err)
+	}
+	return stream.SendAndClose(imp.response)
+}
+
+// changeTypes maps the types of the changes reported by the repository to Protobuf.
+var changeTypes = map[product.ChangeType]productcatalog.ProductChange_Type{
+	product.ChangeCreated: productcatalog.ProductChange_CREATED,
+	product.ChangeUpdated: productcatalog.ProductChange_UPDATED,
+	product.ChangeDeleted: productcatalog.ProductChange_DELETED,
+}
+
+// productChange converts a change reported by the repository to a Protobuf message.
+func (s *server) productChange(c *product.Change) (*productcatalog.ProductChange, error) {
+	protoChange := &productcatalog.ProductChange{
+		Type:        changeTypes[c.Type],
+		Uuid:        c.Uuid,
+		ResumeToken: c.ResumeToken,
+	}
+	var err error
+	if c.Before != nil {
+		if protoChange.Before, err = s.mapper.ProductModelToProductProtobuf(c.Before); err != nil {
+			return nil, err
+		}
+	}
+	if c.After != nil {
+		if protoChange.After, err = s.mapper.ProductModelToProductProtobuf(c.After); err != nil {
+			return nil, err
+		}
+	}
+	return protoChange, nil
+}
+
+// WatchProducts streams the changes made to products until the client goes away.
+// It delegates the actual watching to the repository's Watch method, sending each
+// change as soon as it is reported.
+func (s *server) WatchProducts(in *productcatalog.WatchProductsRequest, stream productcatalog.ProductCatalogService_WatchProductsServer) error {
+	err := s.repo.Watch(stream.Context(), in.GetResumeToken(), func(c *product.Change) error {
+		protoChange, err := s.productChange(c)
+		if err != nil {
+			return err
+		}
+		return stream.Send(protoChange)
+	})
+	return toStatus(err)
+}
+
+// CreateProductType creates a new product type in the catalog.
+// It delegates the actual creation logic to the repository's CreateType method.
+func (s *server) CreateProductType(ctx context.Context, in *productcatalog.CreateProductTypeRequest) (*productcatalog.ProductType, error) {
+	newType, err := s.mapper.ProductTypeProtobufToProductTypeModel(in.GetProductType())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	createdType, err := s.repo.CreateType(ctx, newType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductTypeModelToProductTypeProtobuf(createdType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return protoResponse, nil
+}
+
+// GetProductType retrieves a product type by its name from the catalog.
+// It delegates the actual retrieval logic to the repository's GetType method.
+func (s *server) GetProductType(ctx context.Context, in *productcatalog.GetProductTypeRequest) (*productcatalog.ProductType, error) {
+	productType, err := s.repo.GetType(ctx, in.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductTypeModelToProductTypeProtobuf(productType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return protoResponse, nil
+}
+
+// ListProductTypes lists every product type in the catalog.
+// It delegates the actual listing logic to the repository's ListTypes method.
+func (s *server) ListProductTypes(ctx context.Context, in *productcatalog.ListProductTypesRequest) (*productcatalog.ListProductTypesResponse, error) {
+	productTypes, err := s.repo.ListTypes(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductTypeModelListToListProductTypesResponse(productTypes)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return protoResponse, nil
+}
+
+// UpdateProductType replaces an existing product type.
+// It delegates the actual update logic to the repository's UpdateType method.
+func (s *server) UpdateProductType(ctx context.Context, in *productcatalog.UpdateProductTypeRequest) (*productcatalog.ProductType, error) {
+	productType, err := s.mapper.ProductTypeProtobufToProductTypeModel(in.GetProductType())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	updatedType, err := s.repo.UpdateType(ctx, productType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	protoResponse, err := s.mapper.ProductTypeModelToProductTypeProtobuf(updatedType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return protoResponse, nil
+}
+
+// DeleteProductType deletes a product type from the catalog.
+// It delegates the actual deletion logic to the repository's DeleteType method.
+func (s *server) DeleteProductType(ctx context.Context, in *productcatalog.DeleteProductTypeRequest) (*productcatalog.ProductType, error) {
+	deletedType, err := s.repo.DeleteType(ctx, in.GetName())
 	if err != nil {
-		return nil, errors.Wrap(err, "listing products")
+		return nil, toStatus(err)
 	}
-	protoResponse, err := mapper.ProductModelListToListProductsResponse(products)
+	protoResponse, err := s.mapper.ProductTypeModelToProductTypeProtobuf(deletedType)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return protoResponse, nil
 }
@@ -5,30 +5,64 @@ package server
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
 	"net"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/auth"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/config"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/mapper"
 	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/tlsconfig"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/genproto/googleapis/type/money"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
 	ctx context.Context
 	db  *store.MongoDb
+	// repo is the repository the test server reads and writes.
+	repo product.ProductRepository
+	// clientCreds are the mutual TLS credentials of the test clients.
+	clientCreds credentials.TransportCredentials
 )
 
 const host = "localhost:4444"
 
+// The integration tests run against MongoDB, unless the TEST_STORAGE
+// environment variable is set to "bolt" or "memory".
+const (
+	boltStorage   = "bolt"
+	memoryStorage = "memory"
+)
+
 func TestMain(m *testing.M) {
 	ctx = context.Background()
 	const envFilePath = "../.env"
@@ -37,9 +71,59 @@ func TestMain(m *testing.M) {
 		fmt.Println("error when reading config for integration tests:", err)
 		os.Exit(1)
 	}
-	db, err = store.Connect(ctx, cfg.MongodbTestHostName, cfg.MongodbTestDatabase, cfg.MongodbTestPort)
+	cleanup := func() {}
+	switch os.Getenv("TEST_STORAGE") {
+	case memoryStorage:
+		repo = product.NewMemoryRepository()
+	case boltStorage:
+		dir, err := os.MkdirTemp("", "productcatalog")
+		if err != nil {
+			fmt.Println("error when creating bbolt database directory:", err)
+			os.Exit(1)
+		}
+		boltRepo, err := product.NewBoltRepository(filepath.Join(dir, "products.db"))
+		if err != nil {
+			fmt.Println("error when opening bbolt database:", err)
+			os.Exit(1)
+		}
+		cleanup = func() {
+			boltRepo.Close()
+			os.RemoveAll(dir)
+		}
+		repo = boltRepo
+	default:
+		db, err = store.Connect(ctx, cfg.MongodbTestHostName, cfg.MongodbTestDatabase, cfg.MongodbTestPort)
+		if err != nil {
+			fmt.Println("error when connecting to MongoDB:", err)
+			os.Exit(1)
+		}
+		mongoRepo := product.NewMongoRepository(db)
+		if _, err := store.NewMigrator(db, mongoRepo.Migrations()).Migrate(ctx); err != nil {
+			fmt.Println("error when migrating MongoDB:", err)
+			os.Exit(1)
+		}
+		repo = mongoRepo
+	}
+	certDir, err := os.MkdirTemp("", "productcatalog-tls")
+	if err != nil {
+		fmt.Println("error when creating certificate directory:", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(certDir)
+	var tlsCfg tlsconfig.Config
+	tlsCfg, clientCreds, err = writeCertificates(certDir)
+	if err != nil {
+		fmt.Println("error when writing certificates:", err)
+		os.Exit(1)
+	}
+	reloader, err := tlsconfig.New(tlsCfg)
+	if err != nil {
+		fmt.Println("error when loading certificates:", err)
+		os.Exit(1)
+	}
+	authenticator, err := auth.New(auth.Config{ClientCertificates: true})
 	if err != nil {
-		fmt.Println("error when connecting to MongoDB:", err)
+		fmt.Println("error when configuring authentication:", err)
 		os.Exit(1)
 	}
 	lis, err := net.Listen("tcp", host)
@@ -48,9 +132,13 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 	defer lis.Close()
-	srv := New(db)
+	srv := New(repo, mapper.Mapper{})
 	go func() {
-		grpcServer := grpc.NewServer()
+		grpcServer := grpc.NewServer(
+			grpc.Creds(credentials.NewTLS(reloader.TLSConfig())),
+			grpc.ChainUnaryInterceptor(authenticator.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor()),
+		)
 		productcatalog.RegisterProductCatalogServiceServer(grpcServer, srv)
 		reflection.Register(grpcServer)
 		log.Println("Server started")
@@ -59,6 +147,10 @@ func TestMain(m *testing.M) {
 		}
 	}()
 	exitVal := m.Run()
+	cleanup()
+	if db == nil {
+		os.Exit(exitVal)
+	}
 	if err := db.Database(cfg.MongodbTestDatabase).Drop(ctx); err != nil {
 		fmt.Println("error when dropping test MongoDB:", err)
 		os.Exit(1)
@@ -67,7 +159,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestProduct(t *testing.T) {
-	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
 	if err != nil {
 		t.Fatalf("Failed to dial server: %v", err)
 	}
@@ -79,7 +171,7 @@ func TestProduct(t *testing.T) {
 
 	// Create two products.
 	t.Run("Create", func(t *testing.T) {
-		response, err := client.CreateProduct(ctx, _newProduct)
+		response, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: _newProduct})
 		require.Nil(t, err)
 		require.NotNil(t, response)
 		require.Equal(t, _newProduct.Name, response.Name)
@@ -89,7 +181,7 @@ func TestProduct(t *testing.T) {
 			require.Equal(t, _newProduct.Attributes[k].AsInterface(), v.AsInterface())
 		}
 
-		response2, err2 := client.CreateProduct(ctx, _newProduct2)
+		response2, err2 := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: _newProduct2})
 		require.Nil(t, err2)
 		require.NotNil(t, response2)
 		require.Equal(t, _newProduct2.Name, response2.Name)
@@ -99,8 +191,13 @@ func TestProduct(t *testing.T) {
 			require.Equal(t, _newProduct2.Attributes[k].AsInterface(), v.AsInterface())
 		}
 
+		require.Equal(t, int64(1), response.Revision)
+		require.Equal(t, int64(1), response2.Revision)
+
 		_newProduct.Uuid = response.Uuid
+		_newProduct.Revision = response.Revision
 		_newProduct2.Uuid = response2.Uuid
+		_newProduct2.Revision = response2.Revision
 	})
 
 	// Get the first product.
@@ -108,7 +205,21 @@ func TestProduct(t *testing.T) {
 		response, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: _newProduct.Uuid})
 		require.Nil(t, err)
 		require.NotNil(t, response)
-		require.True(t, proto.Equal(_newProduct, response))
+		require.True(t, proto.Equal(withTypedAttributes(_newProduct), response))
+	})
+
+	// Get a product that does not exist.
+	t.Run("Get not found", func(t *testing.T) {
+		response, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: "non-existent"})
+		require.Nil(t, response)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	// Get a product without informing its uuid.
+	t.Run("Get invalid argument", func(t *testing.T) {
+		response, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{})
+		require.Nil(t, response)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
 	})
 
 	// List the products.
@@ -119,38 +230,762 @@ func TestProduct(t *testing.T) {
 		require.True(t, proto.Equal(products(_newProduct.Uuid, _newProduct2.Uuid), response))
 	})
 
+	// List the products one at a time.
+	t.Run("List paginated", func(t *testing.T) {
+		expected := products(_newProduct.Uuid, _newProduct2.Uuid)
+		response, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{PageSize: 1})
+		require.Nil(t, err)
+		require.Len(t, response.Products, 1)
+		require.True(t, proto.Equal(expected.Products[0], response.Products[0]))
+		require.NotEmpty(t, response.NextPageToken)
+
+		response, err = client.ListProducts(ctx, &productcatalog.ListProductsRequest{PageSize: 1, PageToken: response.NextPageToken})
+		require.Nil(t, err)
+		require.Len(t, response.Products, 1)
+		require.True(t, proto.Equal(expected.Products[1], response.Products[0]))
+		require.Empty(t, response.NextPageToken)
+
+		_, err = client.ListProducts(ctx, &productcatalog.ListProductsRequest{PageToken: "invalid"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	// List the products matching a filter.
+	t.Run("List filtered", func(t *testing.T) {
+		response, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.color = "blue" AND attributes.size >= 12 AND price < 10`})
+		require.Nil(t, err)
+		require.True(t, proto.Equal(products(_newProduct.Uuid, _newProduct2.Uuid), response))
+
+		response, err = client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.color = "green"`})
+		require.Nil(t, err)
+		require.Empty(t, response.Products)
+
+		_, err = client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.color = `})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	// List the products ordered by a non-unique key, one at a time.
+	t.Run("List ordered", func(t *testing.T) {
+		expected := products(_newProduct.Uuid, _newProduct2.Uuid)
+		var got []*productcatalog.Product
+		req := &productcatalog.ListProductsRequest{PageSize: 1, OrderBy: "name desc, attributes.size"}
+		for {
+			response, err := client.ListProducts(ctx, req)
+			require.Nil(t, err)
+			got = append(got, response.Products...)
+			if response.NextPageToken == "" {
+				break
+			}
+			req.PageToken = response.NextPageToken
+		}
+		require.True(t, proto.Equal(expected, &productcatalog.ListProductsResponse{Products: got}))
+
+		_, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{OrderBy: "size"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	// Stream the products matching a filter.
+	t.Run("Stream", func(t *testing.T) {
+		expected := products(_newProduct.Uuid, _newProduct2.Uuid)
+		stream, err := client.StreamProducts(ctx, &productcatalog.StreamProductsRequest{Filter: `attributes.color = "blue"`, OrderBy: "name desc"})
+		require.Nil(t, err)
+		var got []*productcatalog.Product
+		for {
+			p, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err)
+			got = append(got, p)
+		}
+		require.True(t, proto.Equal(expected, &productcatalog.ListProductsResponse{Products: got}))
+
+		stream, err = client.StreamProducts(ctx, &productcatalog.StreamProductsRequest{Filter: `attributes.color = `})
+		require.Nil(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
 	// Update the second product.
 	t.Run("Update", func(t *testing.T) {
-		_updatedProduct := updatedProduct(_newProduct2.Uuid)
-		response, err := client.UpdateProduct(ctx, _updatedProduct)
+		_updatedProduct := updatedProduct(_newProduct2.Uuid, 2)
+		response, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+			Product:          _updatedProduct,
+			ExpectedRevision: _newProduct2.Revision,
+		})
 		require.Nil(t, err)
 		require.NotNil(t, response)
-		require.True(t, proto.Equal(_updatedProduct, response))
+		require.True(t, proto.Equal(withTypedAttributes(_updatedProduct), response))
+
+		// The product is no longer at the revision it was created with.
+		response, err = client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+			Product:          _updatedProduct,
+			ExpectedRevision: _newProduct2.Revision,
+		})
+		require.Nil(t, response)
+		require.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	// Update a single attribute of the second product.
+	// Fields outside of the update mask are left untouched.
+	t.Run("Update with mask", func(t *testing.T) {
+		response, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+			Product: &productcatalog.Product{
+				Uuid: _newProduct2.Uuid,
+				Name: "ignored",
+				Attributes: map[string]*structpb.Value{
+					"color": structpb.NewStringValue("red"),
+				},
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.color"}},
+		})
+		require.Nil(t, err)
+		require.NotNil(t, response)
+		require.True(t, proto.Equal(withTypedAttributes(updatedProduct(_newProduct2.Uuid, 3)), response))
+
+		_, err = client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+			Product:    &productcatalog.Product{Uuid: _newProduct2.Uuid},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"uuid"}},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
 	})
 
 	// Delete the first product.
 	t.Run("Delete", func(t *testing.T) {
-		response, err := client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: _newProduct.Uuid})
+		response, err := client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: _newProduct.Uuid, ExpectedRevision: 2})
+		require.Nil(t, response)
+		require.Equal(t, codes.Aborted, status.Code(err))
+
+		response, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: _newProduct.Uuid, ExpectedRevision: 1})
 		require.Nil(t, err)
 		require.NotNil(t, response)
-		require.True(t, proto.Equal(deletedProductResponse(), response))
+		require.True(t, proto.Equal(deletedProductResponse(withTypedAttributes(_newProduct)), response))
+	})
+
+	// Update and delete products that do not exist.
+	t.Run("Update and Delete not found", func(t *testing.T) {
+		response, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{Product: updatedProduct(_newProduct.Uuid, 0)})
+		require.Nil(t, response)
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		deleteResponse, err := client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: _newProduct.Uuid})
+		require.Nil(t, deleteResponse)
+		require.Equal(t, codes.NotFound, status.Code(err))
 	})
 
 	// List the products again. There should be only the updated product.
 	t.Run("List", func(t *testing.T) {
-		_updatedProduct := updatedProduct(_newProduct2.Uuid)
+		_updatedProduct := updatedProduct(_newProduct2.Uuid, 3)
 		response, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{})
 		require.Nil(t, err)
 		require.NotNil(t, response)
-		require.True(t, proto.Equal(_updatedProduct, response.Products[0]))
+		require.True(t, proto.Equal(withTypedAttributes(_updatedProduct), response.Products[0]))
 	})
 }
 
+func TestCreateIdempotency(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	productId := "product-" + uuid.NewString()
+	req := &productcatalog.CreateProductRequest{
+		Product:   newProduct(),
+		ProductId: productId,
+		RequestId: uuid.NewString(),
+	}
+	created, err := client.CreateProduct(ctx, req)
+	require.Nil(t, err)
+	require.Equal(t, productId, created.Uuid)
+
+	// A retry returns the same product.
+	retried, err := client.CreateProduct(ctx, req)
+	require.Nil(t, err)
+	require.True(t, proto.Equal(created, retried))
+
+	// Another request with the same request id fails.
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: updatedProduct("", 0), RequestId: req.RequestId})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Another request for the same product id too.
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: newProduct(), ProductId: productId})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: productId})
+	require.Nil(t, err)
+}
+
+func TestNaturalKeys(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	suffix := uuid.NewString()
+	withKeys := newProduct()
+	withKeys.Sku = "SKU-" + suffix
+	withKeys.Slug = "product-" + suffix
+	created, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: withKeys})
+	require.Nil(t, err)
+	other, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: newProduct()})
+	require.Nil(t, err)
+
+	bySku, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Sku: withKeys.Sku})
+	require.Nil(t, err)
+	require.True(t, proto.Equal(created, bySku))
+	bySlug, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Slug: withKeys.Slug})
+	require.Nil(t, err)
+	require.True(t, proto.Equal(created, bySlug))
+	_, err = client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: created.Uuid, Sku: withKeys.Sku})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Keys in use cannot be taken by another product.
+	duplicate := newProduct()
+	duplicate.Sku = withKeys.Sku
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: duplicate})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product: &productcatalog.Product{Uuid: other.Uuid, Slug: withKeys.Slug},
+	})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	for _, p := range []*productcatalog.Product{created, other} {
+		_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: p.Uuid})
+		require.Nil(t, err)
+	}
+	_, err = client.GetProduct(ctx, &productcatalog.GetProductRequest{Sku: withKeys.Sku})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	info := details[0].(*errdetails.ResourceInfo)
+	require.Equal(t, "sku/"+withKeys.Sku, info.GetResourceName())
+	require.Equal(t, fmt.Sprintf(`product with sku "%s" does not exist`, withKeys.Sku), info.GetDescription())
+}
+
+func TestProductTypes(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	shirt := &productcatalog.ProductType{
+		Name:        "shirt-" + uuid.NewString(),
+		Description: "shirts",
+		Attributes: map[string]*productcatalog.AttributeSchema{
+			"size": {
+				Type:          productcatalog.AttributeSchema_STRING,
+				Required:      true,
+				AllowedValues: []*structpb.Value{structpb.NewStringValue("S"), structpb.NewStringValue("M")},
+			},
+			"chest": {
+				Type:    productcatalog.AttributeSchema_INTEGER,
+				Minimum: proto.Float64(60),
+			},
+		},
+	}
+	created, err := client.CreateProductType(ctx, &productcatalog.CreateProductTypeRequest{ProductType: shirt})
+	require.Nil(t, err)
+	require.True(t, proto.Equal(shirt, created))
+	got, err := client.GetProductType(ctx, &productcatalog.GetProductTypeRequest{Name: shirt.Name})
+	require.Nil(t, err)
+	require.True(t, proto.Equal(shirt, got))
+	list, err := client.ListProductTypes(ctx, &productcatalog.ListProductTypesRequest{})
+	require.Nil(t, err)
+	found := false
+	for _, pt := range list.ProductTypes {
+		found = found || pt.Name == shirt.Name
+	}
+	require.True(t, found)
+	_, err = client.CreateProductType(ctx, &productcatalog.CreateProductTypeRequest{ProductType: shirt})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Attributes that do not follow the schema are reported as field violations.
+	invalid := newProduct()
+	invalid.ProductType = shirt.Name
+	invalid.Attributes = map[string]*structpb.Value{"size": structpb.NewStringValue("XL"), "chest": structpb.NewNumberValue(59)}
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: invalid})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	var violations []string
+	for _, d := range status.Convert(err).Details() {
+		for _, v := range d.(*errdetails.BadRequest).GetFieldViolations() {
+			violations = append(violations, v.GetField())
+		}
+	}
+	require.Equal(t, []string{"attributes.chest", "attributes.size"}, violations)
+	valid := newProduct()
+	valid.ProductType = shirt.Name
+	valid.Attributes = map[string]*structpb.Value{"size": structpb.NewStringValue("M")}
+	p, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: valid})
+	require.Nil(t, err)
+	_, err = client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product:    &productcatalog.Product{Uuid: p.Uuid},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.size"}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Types used by products cannot be deleted.
+	shirt.Description = "t-shirts"
+	updated, err := client.UpdateProductType(ctx, &productcatalog.UpdateProductTypeRequest{ProductType: shirt})
+	require.Nil(t, err)
+	require.Equal(t, "t-shirts", updated.Description)
+	_, err = client.DeleteProductType(ctx, &productcatalog.DeleteProductTypeRequest{Name: shirt.Name})
+	require.Equal(t, codes.Aborted, status.Code(err))
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: p.Uuid})
+	require.Nil(t, err)
+	_, err = client.DeleteProductType(ctx, &productcatalog.DeleteProductTypeRequest{Name: shirt.Name})
+	require.Nil(t, err)
+	_, err = client.GetProductType(ctx, &productcatalog.GetProductTypeRequest{Name: shirt.Name})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Types may give a JSON Schema document instead, whose violations are
+	// reported by JSON pointer.
+	laptop := &productcatalog.ProductType{
+		Name: "laptop-" + uuid.NewString(),
+		JsonSchema: `{
+			"type": "object",
+			"properties": {"ports": {"type": "array", "items": {"enum": ["usb-c", "hdmi"]}}},
+			"required": ["ports"]
+		}`,
+	}
+	_, err = client.CreateProductType(ctx, &productcatalog.CreateProductTypeRequest{ProductType: laptop})
+	require.Nil(t, err)
+	invalid = newProduct()
+	invalid.ProductType = laptop.Name
+	invalid.Attributes = map[string]*structpb.Value{"ports": structpb.NewListValue(&structpb.ListValue{
+		Values: []*structpb.Value{structpb.NewStringValue("usb-c"), structpb.NewStringValue("vga")},
+	})}
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: invalid})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	violations = nil
+	for _, d := range status.Convert(err).Details() {
+		for _, v := range d.(*errdetails.BadRequest).GetFieldViolations() {
+			violations = append(violations, v.GetField())
+		}
+	}
+	require.Equal(t, []string{"attributes/ports/1"}, violations)
+	_, err = client.DeleteProductType(ctx, &productcatalog.DeleteProductTypeRequest{Name: laptop.Name})
+	require.Nil(t, err)
+}
+
+func TestTypedAttributes(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	// Typed attributes come back with their type, and as google.protobuf.Values.
+	typed := map[string]*productcatalog.AttributeValue{
+		"stock":    {Kind: &productcatalog.AttributeValue_IntValue{IntValue: 3}},
+		"cost":     {Kind: &productcatalog.AttributeValue_DecimalValue{DecimalValue: "19.99"}},
+		"released": {Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(time.UnixMilli(1690000000123))}},
+		"checksum": {Kind: &productcatalog.AttributeValue_BytesValue{BytesValue: []byte("abc")}},
+		"supplier": {Kind: &productcatalog.AttributeValue_ObjectIdValue{ObjectIdValue: "64b7f0c2a1b2c3d4e5f60718"}},
+	}
+	p := newProduct()
+	p.TypedAttributes = typed
+	created, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Nil(t, err)
+	got, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+	for k, v := range typed {
+		require.True(t, proto.Equal(v, got.TypedAttributes[k]), "%s: got %v", k, got.TypedAttributes[k])
+	}
+	require.True(t, proto.Equal(&productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_StringValue{StringValue: "blue"}}, got.TypedAttributes["color"]))
+	require.Equal(t, 3.0, got.Attributes["stock"].GetNumberValue())
+	require.Equal(t, "19.99", got.Attributes["cost"].GetStringValue())
+	require.Equal(t, "2023-07-22T04:26:40.123Z", got.Attributes["released"].GetStringValue())
+
+	// Typed attributes can be filtered on, and updated through attributes paths.
+	list, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.stock = 3 AND attributes.cost > 19`})
+	require.Nil(t, err)
+	require.Len(t, list.Products, 1)
+	updated, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product: &productcatalog.Product{
+			Uuid:            created.Uuid,
+			TypedAttributes: map[string]*productcatalog.AttributeValue{"stock": {Kind: &productcatalog.AttributeValue_IntValue{IntValue: 4}}},
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.stock"}},
+	})
+	require.Nil(t, err)
+	require.Equal(t, int64(4), updated.TypedAttributes["stock"].GetIntValue())
+
+	// Products are ordered by timestamp attributes.
+	var shipped []string
+	for _, ms := range []int64{1690000000000, 1680000000000, 1700000000000} {
+		p := newProduct()
+		p.Attributes["batch"] = structpb.NewStringValue("shipped")
+		p.TypedAttributes = map[string]*productcatalog.AttributeValue{
+			"shipped": {Kind: &productcatalog.AttributeValue_TimestampValue{TimestampValue: timestamppb.New(time.UnixMilli(ms))}},
+		}
+		shippedProduct, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+		require.Nil(t, err)
+		shipped = append(shipped, shippedProduct.Uuid)
+	}
+	list, err = client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.batch = "shipped"`, OrderBy: "attributes.shipped desc"})
+	require.Nil(t, err)
+	var order []string
+	for _, p := range list.Products {
+		order = append(order, p.Uuid)
+	}
+	require.Equal(t, []string{shipped[2], shipped[0], shipped[1]}, order)
+	for _, uuid := range shipped {
+		_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: uuid})
+		require.Nil(t, err)
+	}
+
+	// Invalid typed attributes are reported as field violations.
+	p = newProduct()
+	p.TypedAttributes = map[string]*productcatalog.AttributeValue{"color": {Kind: &productcatalog.AttributeValue_StringValue{StringValue: "red"}}}
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	br := status.Convert(err).Details()[0].(*errdetails.BadRequest)
+	require.Equal(t, "typed_attributes.color", br.GetFieldViolations()[0].GetField())
+
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+}
+
+func TestPrices(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	// Prices come back exactly as they were sent, and price holds the first of them.
+	// The price sent is ignored.
+	prices := []*money.Money{
+		{CurrencyCode: "EUR", Units: 19, Nanos: 990000000},
+		{CurrencyCode: "USD", Units: 21, Nanos: 500000000},
+		{CurrencyCode: "JPY", Units: 3200},
+	}
+	p := newProduct()
+	p.Price = 1000
+	p.Prices = prices
+	created, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Nil(t, err)
+	require.Equal(t, float32(19.99), created.Price)
+	require.Len(t, created.Prices, 3)
+	for i := range prices {
+		require.True(t, proto.Equal(prices[i], created.Prices[i]), "got %v", created.Prices[i])
+	}
+
+	// The price can still be filtered on, but prices are updated as a whole.
+	list, err := client.ListProducts(ctx, &productcatalog.ListProductsRequest{Filter: `price > 19.98 AND price < 20`})
+	require.Nil(t, err)
+	require.Len(t, list.Products, 1)
+	updated, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product:    &productcatalog.Product{Uuid: created.Uuid, Prices: []*money.Money{{CurrencyCode: "USD", Units: 5}}},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"prices"}},
+	})
+	require.Nil(t, err)
+	require.Equal(t, float32(5), updated.Price)
+	require.Len(t, updated.Prices, 1)
+	_, err = client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product:    &productcatalog.Product{Uuid: created.Uuid, Price: 6},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price"}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Invalid prices are reported as field violations.
+	p = newProduct()
+	p.Prices = []*money.Money{
+		{CurrencyCode: "USD", Units: 1},
+		{CurrencyCode: "USD", Units: -1},
+	}
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	br := status.Convert(err).Details()[0].(*errdetails.BadRequest)
+	require.Len(t, br.GetFieldViolations(), 2)
+	require.Equal(t, "prices[1].currency_code", br.GetFieldViolations()[0].GetField())
+	require.Equal(t, "prices[1].amount", br.GetFieldViolations()[1].GetField())
+
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+}
+
+func TestAttributeKeys(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	// Keys MongoDB reserves are rejected.
+	p := newProduct()
+	p.Attributes["$price"] = structpb.NewNumberValue(1)
+	_, err = client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	br := status.Convert(err).Details()[0].(*errdetails.BadRequest)
+	require.Equal(t, "attributes", br.GetFieldViolations()[0].GetField())
+
+	// Unless they are escaped, in which case they come back as they were sent.
+	escaping := New(repo, mapper.Mapper{EscapeAttributeKeys: true})
+	p.Attributes["dimensions"] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"w.cm": structpb.NewNumberValue(2)}})
+	created, err := escaping.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: p})
+	require.Nil(t, err)
+	require.Equal(t, 1.0, created.Attributes["$price"].GetNumberValue())
+	require.Equal(t, 2.0, created.Attributes["dimensions"].GetStructValue().GetFields()["w.cm"].GetNumberValue())
+	updated, err := escaping.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+		Product:    &productcatalog.Product{Uuid: created.Uuid, Attributes: map[string]*structpb.Value{"$price": structpb.NewNumberValue(2)}},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"attributes.$price"}},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 2.0, updated.Attributes["$price"].GetNumberValue())
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+
+	// JSON Schemas naming keys that escaping changes are rejected, as they would not match.
+	_, err = escaping.CreateProductType(ctx, &productcatalog.CreateProductTypeRequest{ProductType: &productcatalog.ProductType{
+		Name:       "furniture",
+		JsonSchema: `{"properties": {"dimensions": {"required": ["w.cm"]}}}`,
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, `invalid argument: json_schema: must not name key "w.cm" while attribute keys are escaped`, status.Convert(err).Message())
+}
+
+func TestBatch(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	var created []*productcatalog.Product
+
+	// Create products, one of them invalid.
+	t.Run("BatchCreate", func(t *testing.T) {
+		invalidProduct := newProduct()
+		invalidProduct.Name = ""
+		response, err := client.BatchCreateProducts(ctx, &productcatalog.BatchCreateProductsRequest{
+			Products: []*productcatalog.Product{newProduct(), invalidProduct, newProduct()},
+		})
+		require.Nil(t, err)
+		require.Len(t, response.Results, 3)
+		require.Equal(t, int32(codes.OK), response.Results[0].Status.Code)
+		require.Equal(t, int32(codes.InvalidArgument), response.Results[1].Status.Code)
+		require.Nil(t, response.Results[1].Product)
+		require.Equal(t, int32(codes.OK), response.Results[2].Status.Code)
+		created = []*productcatalog.Product{response.Results[0].Product, response.Results[2].Product}
+		expected := newProduct()
+		expected.Uuid = created[0].Uuid
+		expected.Revision = 1
+		require.True(t, proto.Equal(withTypedAttributes(expected), created[0]))
+
+		// All or nothing batches fail as a whole.
+		_, err = client.BatchCreateProducts(ctx, &productcatalog.BatchCreateProductsRequest{
+			Products:     []*productcatalog.Product{newProduct(), invalidProduct},
+			AllOrNothing: true,
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "invalid argument: products[1].name: must not be empty", status.Convert(err).Message())
+	})
+
+	// Update them, with a revision conflict.
+	t.Run("BatchUpdate", func(t *testing.T) {
+		response, err := client.BatchUpdateProducts(ctx, &productcatalog.BatchUpdateProductsRequest{
+			Requests: []*productcatalog.UpdateProductRequest{
+				{Product: updatedProduct(created[0].Uuid, 0), ExpectedRevision: 1},
+				{Product: updatedProduct(created[1].Uuid, 0), ExpectedRevision: 2},
+			},
+		})
+		require.Nil(t, err)
+		require.Equal(t, int32(codes.OK), response.Results[0].Status.Code)
+		require.True(t, proto.Equal(withTypedAttributes(updatedProduct(created[0].Uuid, 2)), response.Results[0].Product))
+		require.Equal(t, int32(codes.Aborted), response.Results[1].Status.Code)
+	})
+
+	// Delete them, one of them twice.
+	t.Run("BatchDelete", func(t *testing.T) {
+		_, err := client.BatchDeleteProducts(ctx, &productcatalog.BatchDeleteProductsRequest{
+			Requests:     []*productcatalog.DeleteProductRequest{{Uuid: created[0].Uuid}, {Uuid: created[0].Uuid}},
+			AllOrNothing: true,
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		response, err := client.BatchDeleteProducts(ctx, &productcatalog.BatchDeleteProductsRequest{
+			Requests: []*productcatalog.DeleteProductRequest{{Uuid: created[0].Uuid}, {Uuid: created[1].Uuid}, {Uuid: created[1].Uuid}},
+		})
+		require.Nil(t, err)
+		require.Equal(t, int32(codes.OK), response.Results[0].Status.Code)
+		require.True(t, proto.Equal(withTypedAttributes(updatedProduct(created[0].Uuid, 2)), response.Results[0].Product))
+		require.Equal(t, int32(codes.OK), response.Results[1].Status.Code)
+		require.Equal(t, int32(codes.InvalidArgument), response.Results[2].Status.Code)
+
+		for _, p := range created {
+			_, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: p.Uuid})
+			require.Equal(t, codes.NotFound, status.Code(err))
+		}
+	})
+}
+
+func TestImport(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	existing, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: newProduct()})
+	require.Nil(t, err)
+
+	// Stream more than a chunk of products, replacing the existing one
+	// and with invalid products in both chunks.
+	stream, err := client.ImportProducts(ctx)
+	require.Nil(t, err)
+	uuids := []string{existing.Uuid}
+	require.Nil(t, stream.Send(updatedProduct(existing.Uuid, 0)))
+	for i := 1; i < importChunkSize+10; i++ {
+		p := newProduct()
+		p.Uuid = uuid.NewString()
+		if i == 5 || i == importChunkSize+5 {
+			p.Name = ""
+		} else {
+			uuids = append(uuids, p.Uuid)
+		}
+		require.Nil(t, stream.Send(p))
+	}
+	response, err := stream.CloseAndRecv()
+	require.Nil(t, err)
+	require.Equal(t, int64(importChunkSize+7), response.InsertedCount)
+	require.Equal(t, int64(1), response.UpdatedCount)
+	require.Equal(t, int64(2), response.FailedCount)
+	require.Len(t, response.Failures, 2)
+	require.Equal(t, int64(5), response.Failures[0].Index)
+	require.Equal(t, int64(importChunkSize+5), response.Failures[1].Index)
+	require.Equal(t, int32(codes.InvalidArgument), response.Failures[1].Status.Code)
+	require.Equal(t, "invalid argument: name: must not be empty", response.Failures[1].Status.Message)
+
+	replaced, err := client.GetProduct(ctx, &productcatalog.GetProductRequest{Uuid: existing.Uuid})
+	require.Nil(t, err)
+	require.True(t, proto.Equal(withTypedAttributes(updatedProduct(existing.Uuid, 2)), replaced))
+
+	// An empty import writes nothing.
+	stream, err = client.ImportProducts(ctx)
+	require.Nil(t, err)
+	response, err = stream.CloseAndRecv()
+	require.Nil(t, err)
+	require.True(t, proto.Equal(&productcatalog.ImportProductsResponse{}, response))
+
+	reqs := make([]*productcatalog.DeleteProductRequest, len(uuids))
+	for i, id := range uuids {
+		reqs[i] = &productcatalog.DeleteProductRequest{Uuid: id}
+	}
+	_, err = client.BatchDeleteProducts(ctx, &productcatalog.BatchDeleteProductsRequest{Requests: reqs, AllOrNothing: true})
+	require.Nil(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(clientCreds))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	client := productcatalog.NewProductCatalogServiceClient(conn)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchProducts(watchCtx, &productcatalog.WatchProductsRequest{})
+	require.Nil(t, err)
+	changes := make(chan *productcatalog.ProductChange)
+	go func() {
+		defer close(changes)
+		for {
+			change, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case changes <- change:
+			case <-watchCtx.Done():
+				return
+			}
+		}
+	}()
+	// nextChange returns the next change made to the given product.
+	nextChange := func(uuid string, timeout time.Duration) *productcatalog.ProductChange {
+		for {
+			select {
+			case change := <-changes:
+				require.NotNil(t, change, "watch ended")
+				if change.Uuid == uuid {
+					return change
+				}
+			case <-time.After(timeout):
+				return nil
+			}
+		}
+	}
+
+	// Changes made before the watch starts are not streamed,
+	// so the product is updated until a change is seen.
+	created, err := client.CreateProduct(ctx, &productcatalog.CreateProductRequest{Product: newProduct()})
+	require.Nil(t, err)
+	var change *productcatalog.ProductChange
+	for revision := int64(1); change == nil; revision++ {
+		_, err := client.UpdateProduct(ctx, &productcatalog.UpdateProductRequest{
+			Product:    &productcatalog.Product{Uuid: created.Uuid, Prices: []*money.Money{{CurrencyCode: "USD", Units: revision}}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"prices"}},
+		})
+		require.Nil(t, err)
+		change = nextChange(created.Uuid, 100*time.Millisecond)
+	}
+	require.Contains(t, []productcatalog.ProductChange_Type{productcatalog.ProductChange_CREATED, productcatalog.ProductChange_UPDATED}, change.Type)
+	require.Equal(t, created.Name, change.After.Name)
+
+	_, err = client.DeleteProduct(ctx, &productcatalog.DeleteProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+	for change.Type != productcatalog.ProductChange_DELETED {
+		change = nextChange(created.Uuid, 10*time.Second)
+		require.NotNil(t, change, "deletion not seen")
+	}
+	require.Nil(t, change.After)
+
+	// Resume tokens are rejected when invalid, or when the backend polls for changes.
+	resumed, err := client.WatchProducts(ctx, &productcatalog.WatchProductsRequest{ResumeToken: "token"})
+	require.Nil(t, err)
+	_, err = resumed.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTransportSecurity(t *testing.T) {
+	listTypes := func(creds credentials.TransportCredentials) error {
+		conn, err := grpc.Dial(host, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatalf("Failed to dial server: %v", err)
+		}
+		defer conn.Close()
+		_, err = productcatalog.NewProductCatalogServiceClient(conn).ListProductTypes(ctx, &productcatalog.ListProductTypesRequest{})
+		return err
+	}
+	require.Nil(t, listTypes(clientCreds))
+
+	// Clients must use TLS, and present a certificate.
+	err := listTypes(insecure.NewCredentials())
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	// The server is not verified, so that the handshake only fails for lack of a client certificate.
+	err = listTypes(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
 func newProduct() *productcatalog.Product {
 	return &productcatalog.Product{
 		Name:        "Test Product Name",
 		Description: "Test Product Description",
 		Price:       9.99,
+		Prices:      []*money.Money{{CurrencyCode: "USD", Units: 9, Nanos: 990000000}},
 		Attributes: map[string]*structpb.Value{
 			"color": structpb.NewStringValue("blue"),
 			"size":  structpb.NewNumberValue(12),
@@ -158,12 +993,14 @@ func newProduct() *productcatalog.Product {
 	}
 }
 
-func updatedProduct(id string) *productcatalog.Product {
+func updatedProduct(id string, revision int64) *productcatalog.Product {
 	return &productcatalog.Product{
 		Uuid:        id,
+		Revision:    revision,
 		Name:        "Test Product Name updated",
 		Description: "Test Product Description",
 		Price:       9.99,
+		Prices:      []*money.Money{{CurrencyCode: "USD", Units: 9, Nanos: 990000000}},
 		Attributes: map[string]*structpb.Value{
 			"color": structpb.NewStringValue("red"),
 			"size":  structpb.NewNumberValue(15),
@@ -171,35 +1008,144 @@ func updatedProduct(id string) *productcatalog.Product {
 	}
 }
 
+// products returns the expected list of products, ordered by uuid
+// the same way ListProducts does.
 func products(productId1, productId2 string) *productcatalog.ListProductsResponse {
+	if productId2 < productId1 {
+		productId1, productId2 = productId2, productId1
+	}
 	return &productcatalog.ListProductsResponse{
 		Products: []*productcatalog.Product{
-			{
+			withTypedAttributes(&productcatalog.Product{
 				Uuid:        productId1,
 				Name:        "Test Product Name",
 				Description: "Test Product Description",
 				Price:       9.99,
+				Prices:      []*money.Money{{CurrencyCode: "USD", Units: 9, Nanos: 990000000}},
 				Attributes: map[string]*structpb.Value{
 					"color": structpb.NewStringValue("blue"),
 					"size":  structpb.NewNumberValue(12),
 				},
-			},
-			{
+				Revision: 1,
+			}),
+			withTypedAttributes(&productcatalog.Product{
 				Uuid:        productId2,
 				Name:        "Test Product Name",
 				Description: "Test Product Description",
 				Price:       9.99,
+				Prices:      []*money.Money{{CurrencyCode: "USD", Units: 9, Nanos: 990000000}},
 				Attributes: map[string]*structpb.Value{
 					"color": structpb.NewStringValue("blue"),
 					"size":  structpb.NewNumberValue(12),
 				},
-			},
+				Revision: 1,
+			}),
 		},
 	}
 }
 
-func deletedProductResponse() *productcatalog.DeleteProductResponse {
+// withTypedAttributes returns a copy of a product whose typed attributes hold
+// its string and number attributes, as the server returns them.
+func withTypedAttributes(p *productcatalog.Product) *productcatalog.Product {
+	c := proto.Clone(p).(*productcatalog.Product)
+	c.TypedAttributes = make(map[string]*productcatalog.AttributeValue)
+	for k, v := range p.GetAttributes() {
+		switch v := v.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			c.TypedAttributes[k] = &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_StringValue{StringValue: v.StringValue}}
+		case *structpb.Value_NumberValue:
+			c.TypedAttributes[k] = &productcatalog.AttributeValue{Kind: &productcatalog.AttributeValue_DoubleValue{DoubleValue: v.NumberValue}}
+		}
+	}
+	return c
+}
+
+func deletedProductResponse(deletedProduct *productcatalog.Product) *productcatalog.DeleteProductResponse {
 	return &productcatalog.DeleteProductResponse{
-		Result: "success",
+		DeletedCount: 1,
+		Product:      deletedProduct,
+	}
+}
+
+// writeCertificates writes to dir the certificate authority, server certificate
+// and key of a mutual TLS configuration, and returns it with the credentials of
+// a client presenting a certificate issued by that authority.
+func writeCertificates(dir string) (tlsconfig.Config, credentials.TransportCredentials, error) {
+	cfg := tlsconfig.Config{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   tlsconfig.ClientAuthRequire,
+	}
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return cfg, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return cfg, nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return cfg, nil, err
+	}
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte, err error) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			DNSNames:     []string{"localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+	}
+	serverCert, serverKey, err := issue(2, "localhost", x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return cfg, nil, err
+	}
+	clientCertPEM, clientKeyPEM, err := issue(3, "integration-tests", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return cfg, nil, err
+	}
+	files := map[string][]byte{
+		cfg.CertFile:     serverCert,
+		cfg.KeyFile:      serverKey,
+		cfg.ClientCAFile: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return cfg, nil, err
+		}
+	}
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		return cfg, nil, err
 	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return cfg, credentials.NewTLS(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}}), nil
 }
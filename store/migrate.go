@@ -0,0 +1,302 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations are applied in the order of their versions, each at most once.
+// Applied migrations are recorded in the migrations collection, keyed by version.
+// While migrating, a lock document is held in the migrations_lock collection,
+// so that replicas starting at the same time do not run migrations concurrently:
+// the others wait for the lock and then find the migrations already applied.
+// The lock expires after a lease, so that a process dying while migrating does not
+// block the others forever, and is extended on a heartbeat while migrations run,
+// and after each of them. A migration losing the lock has its context canceled.
+
+const (
+	migrationsCollectionName     = "migrations"
+	migrationsLockCollectionName = "migrations_lock"
+	// migrationLockId is the id of the lock document.
+	migrationLockId = "lock"
+)
+
+// For ease of unit testing.
+var (
+	// migrationLockLease is how long the lock is held without being extended.
+	migrationLockLease = 10 * time.Minute
+	// migrationLockHeartbeat is how often the lock is extended while a migration runs.
+	migrationLockHeartbeat = time.Minute
+	// migrationLockRetryInterval is how long to wait before trying again to acquire the lock.
+	migrationLockRetryInterval = time.Second
+	timeNow                    = time.Now
+)
+
+// Migration is a versioned change to the database, such as creating indexes,
+// backfilling fields or renaming attribute keys.
+// Migrations must be safe to run again if interrupted before being recorded.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+}
+
+// appliedMigration is the record of an applied migration.
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// migrationLock is the document held while migrating.
+type migrationLock struct {
+	Id        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// migrationStore is the storage of the migration records and lock.
+// It allows unit tests to inject a fake one.
+type migrationStore interface {
+	appliedMigrations(ctx context.Context) ([]appliedMigration, error)
+	recordMigration(ctx context.Context, m appliedMigration) error
+	insertLock(ctx context.Context, lock migrationLock) error
+	// extendLock updates the expiry of the lock held by owner, reporting whether it is still held.
+	extendLock(ctx context.Context, owner string, expiresAt time.Time) (bool, error)
+	// deleteLock deletes the lock held by owner, or any lock expired by before when owner is empty.
+	deleteLock(ctx context.Context, owner string, before time.Time) error
+}
+
+// mongoMigrationStore implements migrationStore on top of a MongoDB database.
+type mongoMigrationStore struct {
+	*mongo.Database
+}
+
+func (s *mongoMigrationStore) appliedMigrations(ctx context.Context) ([]appliedMigration, error) {
+	cur, err := s.Collection(migrationsCollectionName).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var applied []appliedMigration
+	if err := cur.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (s *mongoMigrationStore) recordMigration(ctx context.Context, m appliedMigration) error {
+	_, err := s.Collection(migrationsCollectionName).InsertOne(ctx, m)
+	return err
+}
+
+func (s *mongoMigrationStore) insertLock(ctx context.Context, lock migrationLock) error {
+	_, err := s.Collection(migrationsLockCollectionName).InsertOne(ctx, lock)
+	return err
+}
+
+func (s *mongoMigrationStore) extendLock(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+	res, err := s.Collection(migrationsLockCollectionName).UpdateOne(ctx,
+		bson.M{"_id": migrationLockId, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (s *mongoMigrationStore) deleteLock(ctx context.Context, owner string, before time.Time) error {
+	filter := bson.M{"_id": migrationLockId, "owner": owner}
+	if owner == "" {
+		filter = bson.M{"_id": migrationLockId, "expires_at": bson.M{"$lte": before}}
+	}
+	_, err := s.Collection(migrationsLockCollectionName).DeleteOne(ctx, filter)
+	return err
+}
+
+// Migrator applies the pending migrations of a database.
+type Migrator struct {
+	store      migrationStore
+	migrations []Migration
+	owner      string
+}
+
+// NewMigrator returns a Migrator applying the given migrations to the database.
+func NewMigrator(db *MongoDb, migrations []Migration) *Migrator {
+	return &Migrator{
+		store:      &mongoMigrationStore{db.Database(db.DatabaseName)},
+		migrations: migrations,
+		owner:      lockOwner(),
+	}
+}
+
+// lockOwner returns an identifier of the process, unique among replicas.
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.NewString())
+}
+
+// Migrate applies, in order, the migrations that were not applied yet,
+// waiting for the lock while other processes migrate the database.
+// It returns the applied migrations.
+func (m *Migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	migrations, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.store.deleteLock(context.Background(), m.owner, time.Time{})
+	pending, err := m.pending(ctx, migrations)
+	if err != nil {
+		return nil, err
+	}
+	for i, migration := range pending {
+		if err := m.apply(ctx, migration); err != nil {
+			return pending[:i], errors.Wrapf(err, "applying migration %d (%s)", migration.Version, migration.Description)
+		}
+		record := appliedMigration{Version: migration.Version, Description: migration.Description, AppliedAt: timeNow()}
+		if err := m.store.recordMigration(ctx, record); err != nil {
+			return pending[:i], errors.Wrapf(err, "recording migration %d", migration.Version)
+		}
+		if err := m.extendLock(ctx); err != nil {
+			return pending[:i+1], err
+		}
+	}
+	return pending, nil
+}
+
+// apply runs a migration, extending the lock every migrationLockHeartbeat until it
+// returns. When the lock cannot be extended, the context of the migration is canceled,
+// and the reason is returned instead of the error of the migration.
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	migrationCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	lost := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(migrationLockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				close(lost)
+				return
+			case <-ticker.C:
+			}
+			if err := m.extendLock(ctx); err != nil {
+				lost <- err
+				cancel()
+				return
+			}
+		}
+	}()
+	err := migration.Up(migrationCtx)
+	close(done)
+	if lockErr, ok := <-lost; ok {
+		return lockErr
+	}
+	return err
+}
+
+// extendLock extends the lease of the migration lock, failing if it is no longer held.
+func (m *Migrator) extendLock(ctx context.Context) error {
+	held, err := m.store.extendLock(ctx, m.owner, timeNow().Add(migrationLockLease))
+	if err != nil {
+		return errors.Wrap(err, "extending migration lock")
+	}
+	if !held {
+		return errors.New("migration lock expired while migrating")
+	}
+	return nil
+}
+
+// Pending returns the migrations that were not applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	migrations, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(ctx, migrations)
+}
+
+// pending returns the sorted migrations that were not applied yet,
+// failing if the database has migrations unknown to this version.
+func (m *Migrator) pending(ctx context.Context, migrations []Migration) ([]Migration, error) {
+	applied, err := m.store.appliedMigrations(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading applied migrations")
+	}
+	known := make(map[int]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		if !known[a.Version] {
+			return nil, fmt.Errorf("database has migration %d (%s), unknown to this version", a.Version, a.Description)
+		}
+		done[a.Version] = true
+	}
+	var pending []Migration
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// lock acquires the migration lock, waiting while another process holds it.
+func (m *Migrator) lock(ctx context.Context) error {
+	for {
+		err := m.store.insertLock(ctx, migrationLock{Id: migrationLockId, Owner: m.owner, ExpiresAt: timeNow().Add(migrationLockLease)})
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return errors.Wrap(err, "acquiring migration lock")
+		}
+		// The lock of a process that died while migrating is taken over once expired.
+		if err := m.store.deleteLock(ctx, "", timeNow()); err != nil {
+			return errors.Wrap(err, "deleting expired migration lock")
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "waiting for migration lock")
+		case <-time.After(migrationLockRetryInterval):
+		}
+	}
+}
+
+// sortMigrations returns the migrations sorted by version,
+// failing if a version is not positive or is repeated.
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has version %d, must be positive", migration.Description, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migration version %d is repeated", migration.Version)
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMigrate(t *testing.T) {
+	defer func(now func() time.Time, interval time.Duration) {
+		timeNow, migrationLockRetryInterval = now, interval
+	}(timeNow, migrationLockRetryInterval)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	migrationLockRetryInterval = time.Millisecond
+
+	duplicateKeyError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+	testCases := []struct {
+		name                  string
+		migrations            []Migration
+		failing               int
+		mockAppliedMigrations func(ctx context.Context) ([]appliedMigration, error)
+		mockRecordMigration   func(ctx context.Context, m appliedMigration) error
+		mockInsertLock        func(ctx context.Context, lock migrationLock) error
+		mockExtendLock        func(ctx context.Context, owner string, expiresAt time.Time) (bool, error)
+		expectedApplied       []int
+		expectedRecorded      []int
+		expectedLockDeletes   int
+		expectedError         error
+	}{
+		{
+			name: "happy path",
+			mockAppliedMigrations: func(ctx context.Context) ([]appliedMigration, error) {
+				return []appliedMigration{{Version: 1, Description: "one"}}, nil
+			},
+			expectedApplied:     []int{2, 3},
+			expectedRecorded:    []int{2, 3},
+			expectedLockDeletes: 1,
+		},
+		{
+			name: "nothing to apply",
+			mockAppliedMigrations: func(ctx context.Context) ([]appliedMigration, error) {
+				return []appliedMigration{{Version: 1}, {Version: 2}, {Version: 3}}, nil
+			},
+			expectedLockDeletes: 1,
+		},
+		{
+			name: "waiting for the lock",
+			mockInsertLock: func() func(ctx context.Context, lock migrationLock) error {
+				calls := 0
+				return func(ctx context.Context, lock migrationLock) error {
+					calls++
+					if calls < 3 {
+						return duplicateKeyError
+					}
+					return nil
+				}
+			}(),
+			expectedApplied:  []int{1, 2, 3},
+			expectedRecorded: []int{1, 2, 3},
+			// Two attempts to take over an expired lock, and the release.
+			expectedLockDeletes: 3,
+		},
+		{
+			name: "error when acquiring the lock",
+			mockInsertLock: func(ctx context.Context, lock migrationLock) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("acquiring migration lock: random error"),
+		},
+		{
+			name: "database with an unknown migration",
+			mockAppliedMigrations: func(ctx context.Context) ([]appliedMigration, error) {
+				return []appliedMigration{{Version: 1}, {Version: 4, Description: "four"}}, nil
+			},
+			expectedLockDeletes: 1,
+			expectedError:       errors.New("database has migration 4 (four), unknown to this version"),
+		},
+		{
+			name: "error when reading applied migrations",
+			mockAppliedMigrations: func(ctx context.Context) ([]appliedMigration, error) {
+				return nil, errors.New("random error")
+			},
+			expectedLockDeletes: 1,
+			expectedError:       errors.New("reading applied migrations: random error"),
+		},
+		{
+			name:                "error when applying a migration",
+			failing:             2,
+			expectedApplied:     []int{1, 2},
+			expectedRecorded:    []int{1},
+			expectedLockDeletes: 1,
+			expectedError:       errors.New("applying migration 2 (two): random error"),
+		},
+		{
+			name: "error when recording a migration",
+			mockRecordMigration: func(ctx context.Context, m appliedMigration) error {
+				return errors.New("random error")
+			},
+			expectedApplied:     []int{1},
+			expectedLockDeletes: 1,
+			expectedError:       errors.New("recording migration 1: random error"),
+		},
+		{
+			name: "lock expired while migrating",
+			mockExtendLock: func(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+				return false, nil
+			},
+			expectedApplied:     []int{1},
+			expectedRecorded:    []int{1},
+			expectedLockDeletes: 1,
+			expectedError:       errors.New("migration lock expired while migrating"),
+		},
+		{
+			name:          "repeated version",
+			migrations:    []Migration{{Version: 1}, {Version: 1}},
+			expectedError: errors.New("migration version 1 is repeated"),
+		},
+		{
+			name:          "invalid version",
+			migrations:    []Migration{{Version: 0, Description: "zero"}},
+			expectedError: errors.New(`migration "zero" has version 0, must be positive`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var applied, recorded []int
+			migrations := tc.migrations
+			if migrations == nil {
+				// Out of order, as migrations are sorted by version.
+				for _, m := range []Migration{{Version: 3, Description: "three"}, {Version: 1, Description: "one"}, {Version: 2, Description: "two"}} {
+					m := m
+					m.Up = func(ctx context.Context) error {
+						applied = append(applied, m.Version)
+						if m.Version == tc.failing {
+							return errors.New("random error")
+						}
+						return nil
+					}
+					migrations = append(migrations, m)
+				}
+			}
+			lockDeletes := 0
+			store := &mockMigrationStore{
+				mockAppliedMigrations: tc.mockAppliedMigrations,
+				mockRecordMigration: func(ctx context.Context, m appliedMigration) error {
+					if tc.mockRecordMigration != nil {
+						return tc.mockRecordMigration(ctx, m)
+					}
+					require.Equal(t, now, m.AppliedAt)
+					recorded = append(recorded, m.Version)
+					return nil
+				},
+				mockInsertLock: func(ctx context.Context, lock migrationLock) error {
+					require.Equal(t, migrationLock{Id: "lock", Owner: "owner", ExpiresAt: now.Add(migrationLockLease)}, lock)
+					if tc.mockInsertLock != nil {
+						return tc.mockInsertLock(ctx, lock)
+					}
+					return nil
+				},
+				mockExtendLock: tc.mockExtendLock,
+				mockDeleteLock: func(ctx context.Context, owner string, before time.Time) error {
+					lockDeletes++
+					return nil
+				},
+			}
+			m := &Migrator{store: store, migrations: migrations, owner: "owner"}
+			output, err := m.Migrate(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				var versions []int
+				for _, m := range output {
+					versions = append(versions, m.Version)
+				}
+				require.Equal(t, tc.expectedRecorded, versions)
+			}
+			require.Equal(t, tc.expectedApplied, applied)
+			require.Equal(t, tc.expectedRecorded, recorded)
+			require.Equal(t, tc.expectedLockDeletes, lockDeletes)
+		})
+	}
+}
+
+func TestMigrateCanceledWhileWaitingForTheLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	store := &mockMigrationStore{
+		mockInsertLock: func(ctx context.Context, lock migrationLock) error {
+			cancel()
+			return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+		},
+		mockDeleteLock: func(ctx context.Context, owner string, before time.Time) error {
+			require.Empty(t, owner)
+			return nil
+		},
+	}
+	m := &Migrator{store: store, migrations: []Migration{{Version: 1}}, owner: "owner"}
+	_, err := m.Migrate(ctx)
+	require.Equal(t, "waiting for migration lock: context canceled", err.Error())
+}
+
+func TestMigrateHeartbeat(t *testing.T) {
+	defer func(heartbeat time.Duration) { migrationLockHeartbeat = heartbeat }(migrationLockHeartbeat)
+	migrationLockHeartbeat = time.Millisecond
+
+	testCases := []struct {
+		name           string
+		mockExtendLock func(ctx context.Context, owner string, expiresAt time.Time) (bool, error)
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+		},
+		{
+			name: "lock expired while migrating",
+			mockExtendLock: func(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+				return false, nil
+			},
+			expectedError: errors.New("applying migration 1 (one): migration lock expired while migrating"),
+		},
+		{
+			name: "error when extending the lock",
+			mockExtendLock: func(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+				return false, errors.New("random error")
+			},
+			expectedError: errors.New("applying migration 1 (one): extending migration lock: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			extended := make(chan struct{}, 100)
+			store := &mockMigrationStore{
+				mockRecordMigration: func(ctx context.Context, m appliedMigration) error { return nil },
+				mockInsertLock:      func(ctx context.Context, lock migrationLock) error { return nil },
+				mockExtendLock: func(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+					extended <- struct{}{}
+					if tc.mockExtendLock != nil {
+						return tc.mockExtendLock(ctx, owner, expiresAt)
+					}
+					return true, nil
+				},
+				mockDeleteLock: func(ctx context.Context, owner string, before time.Time) error { return nil },
+			}
+			// The migration runs until the lock was extended twice, or its context is canceled.
+			up := func(ctx context.Context) error {
+				for i := 0; i < 2; i++ {
+					select {
+					case <-extended:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				return nil
+			}
+			m := &Migrator{store: store, migrations: []Migration{{Version: 1, Description: "one", Up: up}}, owner: "owner"}
+			_, err := m.Migrate(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	store := &mockMigrationStore{
+		mockAppliedMigrations: func(ctx context.Context) ([]appliedMigration, error) {
+			return []appliedMigration{{Version: 2}}, nil
+		},
+	}
+	m := &Migrator{store: store, migrations: []Migration{{Version: 3}, {Version: 2}, {Version: 1}}}
+	pending, err := m.Pending(context.TODO())
+	require.Nil(t, err)
+	require.Equal(t, []Migration{{Version: 1}, {Version: 3}}, pending)
+}
+
+type mockMigrationStore struct {
+	mockAppliedMigrations func(ctx context.Context) ([]appliedMigration, error)
+	mockRecordMigration   func(ctx context.Context, m appliedMigration) error
+	mockInsertLock        func(ctx context.Context, lock migrationLock) error
+	mockExtendLock        func(ctx context.Context, owner string, expiresAt time.Time) (bool, error)
+	mockDeleteLock        func(ctx context.Context, owner string, before time.Time) error
+}
+
+func (m *mockMigrationStore) appliedMigrations(ctx context.Context) ([]appliedMigration, error) {
+	if m.mockAppliedMigrations == nil {
+		return nil, nil
+	}
+	return m.mockAppliedMigrations(ctx)
+}
+
+func (m *mockMigrationStore) recordMigration(ctx context.Context, a appliedMigration) error {
+	return m.mockRecordMigration(ctx, a)
+}
+
+func (m *mockMigrationStore) insertLock(ctx context.Context, lock migrationLock) error {
+	return m.mockInsertLock(ctx, lock)
+}
+
+func (m *mockMigrationStore) extendLock(ctx context.Context, owner string, expiresAt time.Time) (bool, error) {
+	if m.mockExtendLock == nil {
+		return true, nil
+	}
+	return m.mockExtendLock(ctx, owner, expiresAt)
+}
+
+func (m *mockMigrationStore) deleteLock(ctx context.Context, owner string, before time.Time) error {
+	return m.mockDeleteLock(ctx, owner, before)
+}
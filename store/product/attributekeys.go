@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Attribute keys become the field names of a BSON subdocument, so every backend
+// rejects the keys MongoDB would refuse or read as paths: empty keys, keys starting
+// with $, and keys containing dots or NUL characters, at any nesting level.
+// Callers that must accept such keys escape them with EscapeAttributeKey before
+// writing, and unescape them with UnescapeAttributeKey after reading.
+// Attributes are also limited in number, nesting depth and size, well below the
+// 16MB MongoDB allows for a whole document.
+
+const (
+	// MaxAttributeKeys is the maximum number of attribute keys of a product,
+	// counting those of nested documents.
+	MaxAttributeKeys = 1000
+
+	// MaxAttributeDepth is the maximum nesting depth of attribute values: the values
+	// of the attributes are at depth 1, and the elements of a value at one level more.
+	MaxAttributeDepth = 16
+
+	// MaxAttributesSize is the maximum size of the attributes of a product, encoded in BSON.
+	MaxAttributesSize = 1 << 20
+)
+
+var (
+	keyEscaper   = strings.NewReplacer("%", "%25", ".", "%2E", "$", "%24", "\x00", "%00")
+	keyUnescaper = strings.NewReplacer("%25", "%", "%2E", ".", "%24", "$", "%00", "\x00")
+)
+
+// EscapeAttributeKey escapes the characters of an attribute key that MongoDB reserves,
+// percent-encoding dots, dollar signs and NUL characters, as well as percent signs,
+// so that UnescapeAttributeKey can reverse it. Empty keys stay invalid.
+func EscapeAttributeKey(key string) string {
+	return keyEscaper.Replace(key)
+}
+
+// UnescapeAttributeKey reverses EscapeAttributeKey.
+func UnescapeAttributeKey(key string) string {
+	return keyUnescaper.Replace(key)
+}
+
+// validAttributeKey reports whether an attribute key can be written as is.
+func validAttributeKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "$") && !strings.ContainsAny(key, ".\x00")
+}
+
+// validateAttributeKeys checks the keys of attributes that are about to be written,
+// and their number, nesting depth and size.
+func validateAttributeKeys(attributes map[string]interface{}) []FieldViolation {
+	var violations []FieldViolation
+	keys := 0
+	var walk func(field string, v interface{}, depth int)
+	walk = func(field string, v interface{}, depth int) {
+		if depth > MaxAttributeDepth {
+			violations = append(violations, FieldViolation{
+				Field:       field,
+				Description: fmt.Sprintf("must not be nested more than %d levels deep", MaxAttributeDepth),
+			})
+			return
+		}
+		doc, _ := asDocument(v)
+		list, _ := asList(v)
+		for i, e := range list {
+			walk(fmt.Sprintf("%s[%d]", field, i), e, depth+1)
+		}
+		names := make([]string, 0, len(doc))
+		for k := range doc {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			keys++
+			if !validAttributeKey(k) {
+				violations = append(violations, FieldViolation{
+					Field:       field,
+					Description: fmt.Sprintf(`key %q must not be empty, start with "$", or contain "." or NUL characters`, k),
+				})
+				continue
+			}
+			walk(field+"."+k, doc[k], depth+1)
+		}
+	}
+	walk(attributesField, attributes, 0)
+	if keys > MaxAttributeKeys {
+		violations = append(violations, FieldViolation{
+			Field:       attributesField,
+			Description: fmt.Sprintf("must not have more than %d keys, counting those of nested documents", MaxAttributeKeys),
+		})
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	// Values that cannot be encoded are left for the backend to report.
+	if data, err := bson.Marshal(attributes); err == nil && len(data) > MaxAttributesSize {
+		violations = append(violations, FieldViolation{
+			Field:       attributesField,
+			Description: fmt.Sprintf("must not take more than %d bytes once encoded", MaxAttributesSize),
+		})
+	}
+	return violations
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEscapeAttributeKey(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{input: "color", expectedOutput: "color"},
+		{input: "$price", expectedOutput: "%24price"},
+		{input: "dimensions.width", expectedOutput: "dimensions%2Ewidth"},
+		{input: "a\x00b", expectedOutput: "a%00b"},
+		{input: "100%", expectedOutput: "100%25"},
+		{input: "%2E", expectedOutput: "%252E"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			output := EscapeAttributeKey(tc.input)
+			require.Equal(t, tc.expectedOutput, output)
+			require.True(t, validAttributeKey(output))
+			require.Equal(t, tc.input, UnescapeAttributeKey(output))
+		})
+	}
+}
+
+func TestValidateAttributeKeys(t *testing.T) {
+	nested := func(depth int) interface{} {
+		var v interface{} = "leaf"
+		for i := 0; i < depth; i++ {
+			v = []interface{}{v}
+		}
+		return v
+	}
+	manyKeys := make(map[string]interface{})
+	for i := 0; i <= MaxAttributeKeys; i++ {
+		manyKeys[fmt.Sprintf("k%d", i)] = true
+	}
+	testCases := []struct {
+		name               string
+		input              map[string]interface{}
+		expectedViolations []FieldViolation
+	}{
+		{
+			name: "happy path",
+			input: map[string]interface{}{
+				"color":    "blue",
+				"variants": primitive.A{primitive.D{{Key: "size", Value: "M"}}},
+				"deep":     nested(MaxAttributeDepth - 1),
+			},
+		},
+		{
+			name:  "no attributes",
+			input: nil,
+		},
+		{
+			name: "reserved keys",
+			input: map[string]interface{}{
+				"":         1.0,
+				"$where":   1.0,
+				"a.b":      1.0,
+				"variants": []interface{}{map[string]interface{}{"a\x00b": 1.0}},
+			},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes", Description: `key "" must not be empty, start with "$", or contain "." or NUL characters`},
+				{Field: "attributes", Description: `key "$where" must not be empty, start with "$", or contain "." or NUL characters`},
+				{Field: "attributes", Description: `key "a.b" must not be empty, start with "$", or contain "." or NUL characters`},
+				{Field: "attributes.variants[0]", Description: `key "a\x00b" must not be empty, start with "$", or contain "." or NUL characters`},
+			},
+		},
+		{
+			name:  "too deep",
+			input: map[string]interface{}{"deep": nested(MaxAttributeDepth)},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes.deep" + strings.Repeat("[0]", MaxAttributeDepth), Description: "must not be nested more than 16 levels deep"},
+			},
+		},
+		{
+			name:  "too many keys",
+			input: manyKeys,
+			expectedViolations: []FieldViolation{
+				{Field: "attributes", Description: "must not have more than 1000 keys, counting those of nested documents"},
+			},
+		},
+		{
+			name:  "too large",
+			input: map[string]interface{}{"blob": strings.Repeat("a", MaxAttributesSize)},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes", Description: "must not take more than 1048576 bytes once encoded"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedViolations, validateAttributeKeys(tc.input))
+		})
+	}
+}
+
+// testRepositoryAttributeLimits checks that updates of single attributes cannot grow
+// the attributes of a product past their limits, one key at a time.
+func testRepositoryAttributeLimits(t *testing.T, repo ProductRepository) {
+	ctx := context.TODO()
+	attributes := make(map[string]interface{})
+	for i := 0; i < MaxAttributeKeys-2; i++ {
+		attributes[fmt.Sprintf("k%d", i)] = true
+	}
+	p, err := repo.Create(ctx, &models.Product{Name: "many keys", Attributes: attributes}, "", "")
+	require.Nil(t, err)
+	for i := MaxAttributeKeys - 2; i < MaxAttributeKeys; i++ {
+		key := fmt.Sprintf("k%d", i)
+		_, err := repo.Update(ctx, &models.Product{Uuid: p.Uuid, Attributes: map[string]interface{}{key: true}}, []string{"attributes." + key}, 0)
+		require.Nil(t, err)
+	}
+	_, err = repo.Update(ctx, &models.Product{Uuid: p.Uuid, Attributes: map[string]interface{}{"one-too-many": true}}, nil, 0)
+	require.Equal(t, "invalid argument: attributes: must not have more than 1000 keys, counting those of nested documents", err.Error())
+	results, err := repo.BatchUpdate(ctx, []ProductUpdate{
+		{Product: &models.Product{Uuid: p.Uuid, Attributes: map[string]interface{}{"one-too-many": true}}, Paths: []string{"attributes.one-too-many"}},
+	}, false)
+	require.Nil(t, err)
+	require.Equal(t, "invalid argument: attributes: must not have more than 1000 keys, counting those of nested documents", results[0].Err.Error())
+	stored, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: p.Uuid})
+	require.Nil(t, err)
+	require.Len(t, stored.Attributes, MaxAttributeKeys)
+
+	// Their size is limited the same way.
+	p, err = repo.Create(ctx, &models.Product{Name: "large", Attributes: map[string]interface{}{"blob": strings.Repeat("a", MaxAttributesSize-100)}}, "", "")
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: p.Uuid, Attributes: map[string]interface{}{"blob2": strings.Repeat("a", 100)}}, []string{"attributes.blob2"}, 0)
+	require.Equal(t, "invalid argument: attributes: must not take more than 1048576 bytes once encoded", err.Error())
+}
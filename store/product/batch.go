@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// MaxBatchSize is the maximum number of items of a batch operation.
+const MaxBatchSize = 1000
+
+// BatchResult is the outcome of one item of a batch operation.
+type BatchResult struct {
+	// Product is the product as written, or as it was right before being deleted.
+	// It is nil when the item failed.
+	Product *models.Product
+	// Err is the error the item failed with, as the single item operation would have returned it.
+	Err error
+	// Created reports whether the item created the product,
+	// for operations that either create or replace products.
+	Created bool
+}
+
+// ProductUpdate is one item of a batch update,
+// holding the arguments of ProductRepository.Update.
+type ProductUpdate struct {
+	Product          *models.Product
+	Paths            []string
+	ExpectedRevision int64
+}
+
+// validateBatchSize checks the number of items of a batch,
+// held by the request field with the given name.
+func validateBatchSize(field string, size int) error {
+	switch {
+	case size == 0:
+		return invalidArgumentError(FieldViolation{Field: field, Description: "must not be empty"})
+	case size > MaxBatchSize:
+		return invalidArgumentError(FieldViolation{Field: field, Description: fmt.Sprintf("must not have more than %d items", MaxBatchSize)})
+	}
+	return nil
+}
+
+// batchError returns the error of the first failed item of a batch,
+// which fails the whole batch when it runs all or nothing.
+// It returns nil when every item succeeded.
+func batchError(field string, results []BatchResult) error {
+	for i, r := range results {
+		if r.Err != nil {
+			return batchItemError(field, i, r.Err)
+		}
+	}
+	return nil
+}
+
+// rejectRepeatedUuids fails the items of a batch that refer to a product
+// already referred to by a previous item, as their outcome would depend
+// on the order in which the items are applied.
+// Items that already failed are ignored.
+func rejectRepeatedUuids(results []BatchResult, uuids []string) {
+	seen := make(map[string]bool, len(uuids))
+	for i, uuid := range uuids {
+		if results[i].Err != nil {
+			continue
+		}
+		if seen[uuid] {
+			results[i].Err = invalidArgumentError(FieldViolation{Field: "uuid", Description: "is repeated in the batch"})
+		}
+		seen[uuid] = true
+	}
+}
+
+// prepareUpsert validates the products of an upsert, assigning a new uuid
+// to those without one, and returns the results of the items that failed.
+// Whether an item creates a product is only known when it is applied,
+// which must then call checkCreatedUuid.
+func prepareUpsert(products []*models.Product, uuidProvider func() string) []BatchResult {
+	results := make([]BatchResult, len(products))
+	uuids := make([]string, len(products))
+	for i, p := range products {
+		if err := validateProduct(p); err != nil {
+			results[i].Err = err
+			continue
+		}
+		p.Price = legacyPrice(p.Prices)
+		if p.Uuid == "" {
+			p.Uuid = uuidProvider()
+		}
+		uuids[i] = p.Uuid
+	}
+	rejectRepeatedUuids(results, uuids)
+	return results
+}
+
+// checkCreatedUuid checks the uuid of a product an upsert creates, as prepareCreate
+// checks the product ids clients choose. Uuids assigned by prepareUpsert pass.
+// Products that already exist keep being replaced whatever their uuid.
+func checkCreatedUuid(uuid string) error {
+	if violations := productIdViolations("uuid", uuid); len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return nil
+}
+
+// markCreated sets Created for the succeeded items of an upsert that are at
+// their first revision, as replacing a product always increments its revision.
+func markCreated(results []BatchResult) {
+	for i, r := range results {
+		results[i].Created = r.Err == nil && r.Product.Revision == 1
+	}
+}
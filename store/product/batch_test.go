@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+func TestValidateBatchSize(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         int
+		expectedError error
+	}{
+		{
+			name:  "happy path",
+			input: MaxBatchSize,
+		},
+		{
+			name:          "empty",
+			input:         0,
+			expectedError: errors.New("invalid argument: requests: must not be empty"),
+		},
+		{
+			name:          "too many items",
+			input:         MaxBatchSize + 1,
+			expectedError: errors.New("invalid argument: requests: must not have more than 1000 items"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBatchSize("requests", tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	results := []BatchResult{{}, {}}
+	require.Nil(t, batchError("requests", results))
+	results[1].Err = notFoundError("uuid")
+	require.Equal(t, `requests[1]: product with uuid "uuid" does not exist`, batchError("requests", results).Error())
+}
+
+func TestRejectRepeatedUuids(t *testing.T) {
+	failed := errors.New("random error")
+	results := []BatchResult{{}, {Err: failed}, {}, {}, {}}
+	rejectRepeatedUuids(results, []string{"a", "b", "b", "a", "c"})
+	require.Nil(t, results[0].Err)
+	require.Equal(t, failed, results[1].Err)
+	require.Nil(t, results[2].Err)
+	require.Equal(t, "invalid argument: uuid: is repeated in the batch", results[3].Err.Error())
+	require.Nil(t, results[4].Err)
+}
+
+func TestMarkCreated(t *testing.T) {
+	results := []BatchResult{
+		{Product: &models.Product{Revision: 1}},
+		{Product: &models.Product{Revision: 2}},
+		{Err: errors.New("random error")},
+	}
+	markCreated(results)
+	require.True(t, results[0].Created)
+	require.False(t, results[1].Created)
+	require.False(t, results[2].Created)
+}
+
+func TestPrepareUpsert(t *testing.T) {
+	products := []*models.Product{{Name: "a"}, {Uuid: "b", Name: "b"}, {Name: ""}, {Uuid: "b", Name: "c"}}
+	results := prepareUpsert(products, sequentialUuids())
+	require.Nil(t, results[0].Err)
+	require.Equal(t, "uuid1", products[0].Uuid)
+	require.Nil(t, results[1].Err)
+	require.Equal(t, "b", products[1].Uuid)
+	require.Equal(t, "invalid argument: name: must not be empty", results[2].Err.Error())
+	require.Equal(t, "", products[2].Uuid)
+	require.Equal(t, "invalid argument: uuid: is repeated in the batch", results[3].Err.Error())
+}
+
+// testRepositoryBatches checks the batch operations of a repository
+// that applies all or nothing batches atomically.
+func testRepositoryBatches(t *testing.T, repo ProductRepository) {
+	ctx := context.TODO()
+
+	// Invalid items fail on their own.
+	results, err := repo.BatchCreate(ctx, []*models.Product{{Name: "a", Prices: usd("1")}, {Name: ""}, {Name: "b", Prices: usd("2")}}, false)
+	require.Nil(t, err)
+	require.Len(t, results, 3)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, int64(1), results[0].Product.Revision)
+	require.Equal(t, "invalid argument: name: must not be empty", results[1].Err.Error())
+	require.Nil(t, results[1].Product)
+	require.Nil(t, results[2].Err)
+	a, b := results[0].Product, results[2].Product
+
+	// Or fail the whole batch, which then creates nothing.
+	_, err = repo.BatchCreate(ctx, []*models.Product{{Name: "c"}, {Name: "d", Prices: usd("-1")}}, true)
+	require.Equal(t, "invalid argument: products[1].prices[0].amount: must be a number that is not negative", err.Error())
+	products, _, err := repo.List(ctx, &productcatalog.ListProductsRequest{})
+	require.Nil(t, err)
+	require.Len(t, products, 2)
+
+	_, err = repo.BatchCreate(ctx, nil, false)
+	require.Equal(t, "invalid argument: products: must not be empty", err.Error())
+
+	// Updates that conflict fail, and the others are applied.
+	results, err = repo.BatchUpdate(ctx, []ProductUpdate{
+		{Product: &models.Product{Uuid: a.Uuid, Name: "a2"}, ExpectedRevision: 1},
+		{Product: &models.Product{Uuid: b.Uuid, Name: "b2"}, ExpectedRevision: 2},
+		{Product: &models.Product{Uuid: "missing", Name: "c2"}},
+		{Product: &models.Product{Uuid: a.Uuid, Name: "a3"}},
+	}, false)
+	require.Nil(t, err)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, "a2", results[0].Product.Name)
+	require.Equal(t, int64(2), results[0].Product.Revision)
+	require.Equal(t, KindConflict, KindOf(results[1].Err))
+	require.Equal(t, KindNotFound, KindOf(results[2].Err))
+	require.Equal(t, "invalid argument: uuid: is repeated in the batch", results[3].Err.Error())
+
+	// An all or nothing batch with a conflict updates nothing.
+	_, err = repo.BatchUpdate(ctx, []ProductUpdate{
+		{Product: &models.Product{Uuid: a.Uuid, Prices: usd("10")}, Paths: []string{"prices"}},
+		{Product: &models.Product{Uuid: b.Uuid, Prices: usd("10")}, Paths: []string{"prices"}, ExpectedRevision: 2},
+	}, true)
+	require.Equal(t, KindConflict, KindOf(err))
+	require.Equal(t, `requests[1]: product with uuid "`+b.Uuid+`" is at revision 1, expected 2`, err.Error())
+	got, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: a.Uuid})
+	require.Nil(t, err)
+	require.Equal(t, float32(1), got.Price)
+	require.Equal(t, int64(2), got.Revision)
+
+	results, err = repo.BatchUpdate(ctx, []ProductUpdate{
+		{Product: &models.Product{Uuid: a.Uuid, Prices: usd("10")}, Paths: []string{"prices"}},
+		{Product: &models.Product{Uuid: b.Uuid, Prices: usd("10")}, Paths: []string{"prices"}, ExpectedRevision: 1},
+	}, true)
+	require.Nil(t, err)
+	require.Equal(t, float32(10), results[0].Product.Price)
+	require.Equal(t, float32(10), results[1].Product.Price)
+
+	// Deletions too.
+	_, err = repo.BatchDelete(ctx, []*productcatalog.DeleteProductRequest{{Uuid: a.Uuid}, {Uuid: "missing"}}, true)
+	require.Equal(t, `requests[1]: product with uuid "missing" does not exist`, err.Error())
+	results, err = repo.BatchDelete(ctx, []*productcatalog.DeleteProductRequest{{Uuid: a.Uuid}, {Uuid: b.Uuid, ExpectedRevision: 1}, {Uuid: ""}}, false)
+	require.Nil(t, err)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, "a2", results[0].Product.Name)
+	require.Equal(t, KindConflict, KindOf(results[1].Err))
+	require.Equal(t, "invalid argument: uuid: must not be empty", results[2].Err.Error())
+	products, _, err = repo.List(ctx, &productcatalog.ListProductsRequest{})
+	require.Nil(t, err)
+	require.Len(t, products, 1)
+	require.Equal(t, b.Uuid, products[0].Uuid)
+
+	// Upserts replace existing products and create the others.
+	results, err = repo.BatchUpsert(ctx, []*models.Product{
+		{Uuid: b.Uuid, Name: "b3", Prices: usd("3")},
+		{Uuid: "imported", Name: "e", Prices: usd("5"), Attributes: map[string]interface{}{"color": "red"}},
+		{Name: "f", Prices: usd("6")},
+		{Name: "g", Prices: usd("-1")},
+		{Uuid: b.Uuid, Name: "b4"},
+		{Uuid: "Imported/2", Name: "h"},
+	})
+	require.Nil(t, err)
+	require.Len(t, results, 6)
+	require.Nil(t, results[0].Err)
+	require.False(t, results[0].Created)
+	require.Equal(t, "b3", results[0].Product.Name)
+	require.Equal(t, "", results[0].Product.Description)
+	require.Empty(t, results[0].Product.Attributes)
+	require.Equal(t, int64(3), results[0].Product.Revision)
+	require.Nil(t, results[1].Err)
+	require.True(t, results[1].Created)
+	require.Equal(t, "imported", results[1].Product.Uuid)
+	require.Equal(t, int64(1), results[1].Product.Revision)
+	require.Nil(t, results[2].Err)
+	require.True(t, results[2].Created)
+	require.NotEmpty(t, results[2].Product.Uuid)
+	require.Equal(t, "invalid argument: prices[0].amount: must be a number that is not negative", results[3].Err.Error())
+	require.Equal(t, "invalid argument: uuid: is repeated in the batch", results[4].Err.Error())
+	require.Equal(t, "invalid argument: uuid: must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit", results[5].Err.Error())
+	got, err = repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "imported"})
+	require.Nil(t, err)
+	require.Equal(t, "red", got.Attributes["color"])
+
+	results, err = repo.BatchUpsert(ctx, []*models.Product{{Uuid: "imported", Name: "e2", Prices: usd("5")}})
+	require.Nil(t, err)
+	require.Nil(t, results[0].Err)
+	require.False(t, results[0].Created)
+	require.Equal(t, int64(2), results[0].Product.Revision)
+
+	_, err = repo.BatchUpsert(ctx, nil)
+	require.Equal(t, "invalid argument: products: must not be empty", err.Error())
+}
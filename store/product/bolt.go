@@ -0,0 +1,905 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The bbolt database holds the products, encoded as BSON and keyed by uuid,
+// in the products bucket. Secondary indexes on name and price have a bucket each,
+// with keys made of the encoded field value followed by the product uuid, and the
+// uuid as value, so that iterating a bucket visits products in the order of the field.
+// Listings ordered by uuid, name or price only read the products
+// needed for the requested page; other orderings scan every product.
+// Unique indexes on sku and slug map the non-empty values to the product uuid.
+// Product types are kept, encoded as BSON and keyed by name, in their own bucket,
+// and the products having a type are indexed by type name followed by 0x00 and uuid,
+// so that deleting a type finds whether products use it.
+// The idempotency keys of creates are kept, encoded as BSON and keyed by request id,
+// in their own bucket, and indexed by expiry so that expired keys are found quickly.
+
+var (
+	productsBucket = []byte("products")
+	// boltIndexes maps the indexed fields to their buckets.
+	boltIndexes = map[string][]byte{
+		"name":  []byte("products_by_name"),
+		"price": []byte("products_by_price"),
+	}
+	// boltUniqueIndexes maps the unique fields to their buckets.
+	boltUniqueIndexes = map[string][]byte{
+		"sku":  []byte("products_by_sku"),
+		"slug": []byte("products_by_slug"),
+	}
+	productTypesBucket    = []byte("product_types")
+	productsByTypeBucket  = []byte("products_by_type")
+	idempotencyKeysBucket = []byte("idempotency_keys")
+	// idempotencyExpiryBucket has keys made of the expiry time of an idempotency key,
+	// in nanoseconds encoded as big endian, followed by its request id.
+	idempotencyExpiryBucket = []byte("idempotency_keys_by_expiry")
+)
+
+// boltStreamBatchSize is the number of products read by each transaction
+// of Stream, so that no read transaction is held open while products are sent.
+// It is a variable for ease of unit testing.
+var boltStreamBatchSize = 100
+
+// boltOpenTimeout is how long opening a database waits for the file lock
+// held by another process.
+const boltOpenTimeout = 5 * time.Second
+
+// BoltRepository is a ProductRepository that stores products
+// in a local bbolt database file, for single node deployments.
+// It supports the same filters, orderings and pagination as MongoRepository.
+type BoltRepository struct {
+	db           *bbolt.DB
+	uuidProvider func() string
+	changes      poller
+}
+
+var _ ProductRepository = (*BoltRepository)(nil)
+
+// NewBoltRepository opens, creating it if needed, the bbolt database at the given path.
+// The repository must be closed when no longer used.
+func NewBoltRepository(path string) (*BoltRepository, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, `opening database "%s"`, path)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(productsBucket); err != nil {
+			return err
+		}
+		for _, bucket := range boltIndexes {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		for _, bucket := range boltUniqueIndexes {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		for _, bucket := range [][]byte{productTypesBucket, productsByTypeBucket, idempotencyKeysBucket, idempotencyExpiryBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "creating buckets")
+	}
+	return &BoltRepository{db: db, uuidProvider: uuid.NewString}, nil
+}
+
+// Close closes the database file.
+func (r *BoltRepository) Close() error {
+	return r.db.Close()
+}
+
+// Create stores a new product.
+// It behaves like MongoRepository.Create.
+func (r *BoltRepository) Create(ctx context.Context, newProduct *models.Product, productId, requestId string) (*models.Product, error) {
+	key, err := prepareCreate(newProduct, productId, requestId, r.uuidProvider)
+	if err != nil {
+		return nil, err
+	}
+	var created *models.Product
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		if err := removeExpiredKeys(tx); err != nil {
+			return err
+		}
+		if key != nil {
+			stored, err := getIdempotencyKey(tx, key.RequestId)
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				created, err = stored.replay(key)
+				return err
+			}
+		}
+		if err := insertProduct(tx, newProduct); err != nil {
+			return err
+		}
+		created = newProduct
+		if key != nil {
+			return putIdempotencyKey(tx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, "inserting product")
+	}
+	return created, nil
+}
+
+// Get retrieves a product by uuid, sku or slug.
+func (r *BoltRepository) Get(ctx context.Context, req *productcatalog.GetProductRequest) (*models.Product, error) {
+	field, value, err := productKey(req)
+	if err != nil {
+		return nil, err
+	}
+	var product *models.Product
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		uuid := value
+		if field != uuidField {
+			v := tx.Bucket(boltUniqueIndexes[field]).Get([]byte(value))
+			if v == nil {
+				return keyNotFoundError(field, value)
+			}
+			uuid = string(v)
+		}
+		var err error
+		product, err = getProduct(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `getting product with %s "%s"`, field, value)
+	}
+	return product, nil
+}
+
+// Update writes the fields of a product named by the update mask paths.
+// It behaves like MongoRepository.Update.
+func (r *BoltRepository) Update(ctx context.Context, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	paths, err := prepareUpdate(productToUpdate, paths)
+	if err != nil {
+		return nil, err
+	}
+	var updated *models.Product
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		updated, err = updateProduct(tx, productToUpdate, paths, expectedRevision)
+		return err
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `updating product with uuid "%s"`, productToUpdate.Uuid)
+	}
+	return updated, nil
+}
+
+// Delete deletes a product by uuid.
+// It behaves like MongoRepository.Delete.
+func (r *BoltRepository) Delete(ctx context.Context, req *productcatalog.DeleteProductRequest) (*models.Product, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+	var deleted *models.Product
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		deleted, err = removeProduct(tx, req.GetUuid(), req.GetExpectedRevision())
+		return err
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `deleting product with uuid "%s"`, req.GetUuid())
+	}
+	return deleted, nil
+}
+
+// BatchCreate stores new products.
+// It behaves like MongoRepository.BatchCreate. Every batch is written in
+// a single transaction, which all or nothing batches roll back on failure.
+func (r *BoltRepository) BatchCreate(ctx context.Context, newProducts []*models.Product, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(newProducts)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(newProducts))
+	for i, p := range newProducts {
+		if err := validateProduct(p); err != nil {
+			results[i].Err = err
+			continue
+		}
+		p.Price = legacyPrice(p.Prices)
+		p.Uuid = r.uuidProvider()
+		p.Revision = 1
+	}
+	return r.runBatch("products", results, allOrNothing, func(tx *bbolt.Tx, i int) (*models.Product, error) {
+		if err := insertProduct(tx, newProducts[i]); err != nil {
+			return nil, err
+		}
+		return newProducts[i], nil
+	})
+}
+
+// BatchUpdate updates products.
+// It behaves like MongoRepository.BatchUpdate.
+func (r *BoltRepository) BatchUpdate(ctx context.Context, updates []ProductUpdate, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(updates)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(updates))
+	paths := make([][]string, len(updates))
+	uuids := make([]string, len(updates))
+	for i, u := range updates {
+		paths[i], results[i].Err = prepareUpdate(u.Product, u.Paths)
+		uuids[i] = u.Product.Uuid
+	}
+	rejectRepeatedUuids(results, uuids)
+	return r.runBatch("requests", results, allOrNothing, func(tx *bbolt.Tx, i int) (*models.Product, error) {
+		return updateProduct(tx, updates[i].Product, paths[i], updates[i].ExpectedRevision)
+	})
+}
+
+// BatchDelete deletes products.
+// It behaves like MongoRepository.BatchDelete.
+func (r *BoltRepository) BatchDelete(ctx context.Context, reqs []*productcatalog.DeleteProductRequest, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(reqs))
+	uuids := make([]string, len(reqs))
+	for i, req := range reqs {
+		results[i].Err = validateUuid(req.GetUuid())
+		uuids[i] = req.GetUuid()
+	}
+	rejectRepeatedUuids(results, uuids)
+	return r.runBatch("requests", results, allOrNothing, func(tx *bbolt.Tx, i int) (*models.Product, error) {
+		return removeProduct(tx, reqs[i].GetUuid(), reqs[i].GetExpectedRevision())
+	})
+}
+
+// BatchUpsert creates or replaces products.
+// It behaves like MongoRepository.BatchUpsert.
+func (r *BoltRepository) BatchUpsert(ctx context.Context, products []*models.Product) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(products)); err != nil {
+		return nil, err
+	}
+	results, err := r.runBatch("products", prepareUpsert(products, r.uuidProvider), false, func(tx *bbolt.Tx, i int) (*models.Product, error) {
+		p := products[i]
+		if tx.Bucket(productsBucket).Get([]byte(p.Uuid)) != nil {
+			return updateProduct(tx, p, updatableFields, 0)
+		}
+		if err := checkCreatedUuid(p.Uuid); err != nil {
+			return nil, err
+		}
+		p.Revision = 1
+		if err := insertProduct(tx, p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	markCreated(results)
+	return results, nil
+}
+
+// CreateType stores a new product type.
+// It behaves like MongoRepository.CreateType.
+func (r *BoltRepository) CreateType(ctx context.Context, newType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(newType); err != nil {
+		return nil, err
+	}
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(productTypesBucket).Get([]byte(newType.Name)) != nil {
+			return productTypeExistsError(newType.Name)
+		}
+		return putProductType(tx, newType)
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, "inserting product type")
+	}
+	return newType, nil
+}
+
+// GetType retrieves a product type by name.
+// It behaves like MongoRepository.GetType.
+func (r *BoltRepository) GetType(ctx context.Context, name string) (*models.ProductType, error) {
+	var t *models.ProductType
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		t, err = getProductType(tx, name)
+		return err
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `getting product type "%s"`, name)
+	}
+	if t == nil {
+		return nil, productTypeNotFoundError(name)
+	}
+	return t, nil
+}
+
+// ListTypes lists every product type, ordered by name.
+func (r *BoltRepository) ListTypes(ctx context.Context) ([]*models.ProductType, error) {
+	types := []*models.ProductType{}
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(productTypesBucket).ForEach(func(k, v []byte) error {
+			t := new(models.ProductType)
+			if err := bson.Unmarshal(v, t); err != nil {
+				return errors.Wrap(err, "decoding product type")
+			}
+			types = append(types, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, "listing product types")
+	}
+	return types, nil
+}
+
+// UpdateType replaces the product type with the same name.
+// It behaves like MongoRepository.UpdateType.
+func (r *BoltRepository) UpdateType(ctx context.Context, productType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(productType); err != nil {
+		return nil, err
+	}
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(productTypesBucket).Get([]byte(productType.Name)) == nil {
+			return productTypeNotFoundError(productType.Name)
+		}
+		return putProductType(tx, productType)
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `updating product type "%s"`, productType.Name)
+	}
+	return productType, nil
+}
+
+// DeleteType deletes a product type by name.
+// It behaves like MongoRepository.DeleteType.
+func (r *BoltRepository) DeleteType(ctx context.Context, name string) (*models.ProductType, error) {
+	var deleted *models.ProductType
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		deleted, err = getProductType(tx, name)
+		if err != nil {
+			return err
+		}
+		if deleted == nil {
+			return productTypeNotFoundError(name)
+		}
+		prefix := typeIndexKey(name, "")
+		if k, _ := tx.Bucket(productsByTypeBucket).Cursor().Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) {
+			return productTypeInUseError(name)
+		}
+		return tx.Bucket(productTypesBucket).Delete([]byte(name))
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, `deleting product type "%s"`, name)
+	}
+	return deleted, nil
+}
+
+// runBatch applies, in a single transaction, the items of a batch that passed validation.
+// Items fail before writing anything when they conflict with the stored products, so that
+// the others can still be committed. Any other error rolls back the whole batch.
+func (r *BoltRepository) runBatch(field string, results []BatchResult, allOrNothing bool, apply func(tx *bbolt.Tx, i int) (*models.Product, error)) ([]BatchResult, error) {
+	if allOrNothing {
+		if err := batchError(field, results); err != nil {
+			return nil, err
+		}
+	}
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		for i := range results {
+			if results[i].Err != nil {
+				continue
+			}
+			p, err := apply(tx, i)
+			var perr *Error
+			if err != nil && (allOrNothing || !errors.As(err, &perr)) {
+				return batchItemError(field, i, err)
+			}
+			results[i].Product, results[i].Err = p, err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapBoltError(err, "writing products")
+	}
+	return results, nil
+}
+
+// List lists one page of the products matching the request filter.
+// It behaves like MongoRepository.List.
+func (r *BoltRepository) List(ctx context.Context, req *productcatalog.ListProductsRequest) ([]*models.Product, string, error) {
+	q, err := parseListRequest(req)
+	if err != nil {
+		return nil, "", err
+	}
+	var candidates []*models.Product
+	err = r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		candidates, err = scanProducts(ctx, tx, q)
+		return err
+	})
+	if err != nil {
+		return nil, "", wrapBoltError(err, "finding products")
+	}
+	return q.page(candidates)
+}
+
+// Stream calls send with every product matching the request filter.
+// It behaves like MongoRepository.Stream. Products are read in batches,
+// each in its own transaction, so products written while streaming
+// may or may not be sent. Products ordered by a field without an index
+// are all read and sorted at once instead, as each batch would read them all.
+func (r *BoltRepository) Stream(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+	q, err := parseStreamRequest(req)
+	if err != nil {
+		return err
+	}
+	if !readsInOrder(q.keys[0].field) {
+		var candidates []*models.Product
+		err := r.db.View(func(tx *bbolt.Tx) error {
+			var err error
+			candidates, err = scanProducts(ctx, tx, q)
+			return err
+		})
+		if err != nil {
+			return wrapBoltError(err, "finding products")
+		}
+		q.size = len(candidates)
+		sorted, _, err := q.page(candidates)
+		if err != nil {
+			return err
+		}
+		for _, p := range sorted {
+			if err := send(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	q.size = boltStreamBatchSize
+	for {
+		var candidates []*models.Product
+		err := r.db.View(func(tx *bbolt.Tx) error {
+			var err error
+			candidates, err = scanProducts(ctx, tx, q)
+			return err
+		})
+		if err != nil {
+			return wrapBoltError(err, "finding products")
+		}
+		batch, nextPageToken, err := q.page(candidates)
+		if err != nil {
+			return err
+		}
+		for _, p := range batch {
+			if err := send(p); err != nil {
+				return err
+			}
+		}
+		if nextPageToken == "" {
+			return nil
+		}
+		q.after = sortValues(q.keys, batch[len(batch)-1])
+	}
+}
+
+// Watch calls send with the changes made to products, polling for them
+// every second, until ctx is done or send fails. Watches share the reads of
+// the products, which fail past the number of products set with SetMaxWatchedProducts.
+// Watches cannot be resumed.
+func (r *BoltRepository) Watch(ctx context.Context, resumeToken string, send func(*Change) error) error {
+	if resumeToken != "" {
+		return errResumeNotSupported
+	}
+	return r.changes.watch(ctx, r.Stream, send)
+}
+
+// SetMaxWatchedProducts sets the largest number of products Watch reads, 100000 by default,
+// as each read holds every product in memory. Watches already running keep the previous one.
+func (r *BoltRepository) SetMaxWatchedProducts(max int) {
+	r.changes.setMaxProducts(max)
+}
+
+// readsInOrder reports whether scanProducts reads products in the order of the given field.
+func readsInOrder(field string) bool {
+	_, indexed := boltIndexes[field]
+	return field == uuidField || indexed
+}
+
+// scanProducts returns the products that match a list query and can be part of the requested page.
+// When the first sort key is uuid or an indexed field, products are read in that order,
+// stopping once the page is complete; ties on the first key are read whole,
+// since they are ordered by the remaining keys.
+func scanProducts(ctx context.Context, tx *bbolt.Tx, q *listQuery) ([]*models.Product, error) {
+	first := q.keys[0]
+	products := tx.Bucket(productsBucket)
+	var (
+		cursor *bbolt.Cursor
+		start  []byte
+	)
+	switch bucket, indexed := boltIndexes[first.field]; {
+	case first.field == uuidField:
+		cursor = products.Cursor()
+		if q.after != nil {
+			if id, ok := q.after[0].(string); ok {
+				start = []byte(id)
+			}
+		}
+	case indexed:
+		cursor = tx.Bucket(bucket).Cursor()
+		if q.after != nil {
+			start = indexValue(q.after[0])
+		}
+	default:
+		var matched []*models.Product
+		err := products.ForEach(func(k, v []byte) error {
+			p, err := decodeProduct(v)
+			if err != nil {
+				return err
+			}
+			if (q.filter == nil || q.filter.matches(p)) && q.isAfter(p) {
+				matched = append(matched, p)
+			}
+			return ctx.Err()
+		})
+		return matched, err
+	}
+	var (
+		matched   []*models.Product
+		lastValue interface{}
+	)
+	k, v := seek(cursor, start, first.descending)
+	for ; k != nil; k, v = step(cursor, first.descending) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if first.field != uuidField {
+			v = products.Get(v)
+		}
+		p, err := decodeProduct(v)
+		if err != nil {
+			return nil, err
+		}
+		value := fieldValue(p, first.field)
+		if len(matched) > q.size && compareValues(value, lastValue) != 0 {
+			break
+		}
+		if (q.filter == nil || q.filter.matches(p)) && q.isAfter(p) {
+			matched = append(matched, p)
+			lastValue = value
+		}
+	}
+	return matched, nil
+}
+
+// seek positions the cursor at the first key to visit, in the given direction,
+// among the keys that start with the given prefix or come after it.
+func seek(c *bbolt.Cursor, prefix []byte, descending bool) ([]byte, []byte) {
+	if !descending {
+		if prefix == nil {
+			return c.First()
+		}
+		return c.Seek(prefix)
+	}
+	if prefix == nil {
+		return c.Last()
+	}
+	end := prefixEnd(prefix)
+	if end == nil {
+		return c.Last()
+	}
+	if k, _ := c.Seek(end); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// step moves the cursor to the next key in the given direction.
+func step(c *bbolt.Cursor, descending bool) ([]byte, []byte) {
+	if descending {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// prefixEnd returns the smallest key greater than every key starting with prefix,
+// or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// getProduct reads a product, failing with an error of kind KindNotFound if it does not exist.
+func getProduct(tx *bbolt.Tx, uuid string) (*models.Product, error) {
+	v := tx.Bucket(productsBucket).Get([]byte(uuid))
+	if v == nil {
+		return nil, notFoundError(uuid)
+	}
+	return decodeProduct(v)
+}
+
+// insertProduct stores a new product.
+func insertProduct(tx *bbolt.Tx, newProduct *models.Product) error {
+	if tx.Bucket(productsBucket).Get([]byte(newProduct.Uuid)) != nil {
+		return alreadyExistsError(newProduct.Uuid)
+	}
+	if err := checkUnique(tx, newProduct); err != nil {
+		return err
+	}
+	if err := checkProductType(newProduct, productTypeGetter(tx)); err != nil {
+		return err
+	}
+	return putProduct(tx, newProduct)
+}
+
+// checkUnique fails if another product has the sku or slug of the given one.
+// It must be called before writing anything, as batches keep the writes
+// of the items that succeeded.
+func checkUnique(tx *bbolt.Tx, p *models.Product) error {
+	for _, field := range uniqueFields {
+		value := uniqueValue(p, field)
+		if value == "" {
+			continue
+		}
+		if uuid := tx.Bucket(boltUniqueIndexes[field]).Get([]byte(value)); uuid != nil && string(uuid) != p.Uuid {
+			return uniqueValueTakenError(p.Uuid, field, value)
+		}
+	}
+	return nil
+}
+
+// updateProduct writes the fields of a product named by the normalized update mask paths.
+func updateProduct(tx *bbolt.Tx, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	current, err := getProduct(tx, productToUpdate.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	if expectedRevision != 0 && current.Revision != expectedRevision {
+		return nil, conflictError(current.Uuid, expectedRevision, current.Revision)
+	}
+	if len(paths) == 0 {
+		return current, nil
+	}
+	updated := cloneProduct(current)
+	applyUpdate(updated, productToUpdate, paths)
+	if err := checkUnique(tx, updated); err != nil {
+		return nil, err
+	}
+	if err := checkUpdatedAttributes(updated, paths, productTypeGetter(tx)); err != nil {
+		return nil, err
+	}
+	if err := deleteIndexEntries(tx, current); err != nil {
+		return nil, err
+	}
+	if err := putProduct(tx, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// removeProduct deletes a product and its index entries, returning it.
+func removeProduct(tx *bbolt.Tx, uuid string, expectedRevision int64) (*models.Product, error) {
+	current, err := getProduct(tx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	if expectedRevision != 0 && current.Revision != expectedRevision {
+		return nil, conflictError(current.Uuid, expectedRevision, current.Revision)
+	}
+	if err := deleteIndexEntries(tx, current); err != nil {
+		return nil, err
+	}
+	if err := tx.Bucket(productsBucket).Delete([]byte(uuid)); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// putProduct writes a product and its index entries.
+func putProduct(tx *bbolt.Tx, p *models.Product) error {
+	v, err := bson.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, "encoding product")
+	}
+	if err := tx.Bucket(productsBucket).Put([]byte(p.Uuid), v); err != nil {
+		return err
+	}
+	for field, bucket := range boltIndexes {
+		if err := tx.Bucket(bucket).Put(indexKey(field, p), []byte(p.Uuid)); err != nil {
+			return err
+		}
+	}
+	for field, bucket := range boltUniqueIndexes {
+		if value := uniqueValue(p, field); value != "" {
+			if err := tx.Bucket(bucket).Put([]byte(value), []byte(p.Uuid)); err != nil {
+				return err
+			}
+		}
+	}
+	if p.ProductType != "" {
+		return tx.Bucket(productsByTypeBucket).Put(typeIndexKey(p.ProductType, p.Uuid), nil)
+	}
+	return nil
+}
+
+// deleteIndexEntries deletes the index entries of a product.
+func deleteIndexEntries(tx *bbolt.Tx, p *models.Product) error {
+	for field, bucket := range boltIndexes {
+		if err := tx.Bucket(bucket).Delete(indexKey(field, p)); err != nil {
+			return err
+		}
+	}
+	for field, bucket := range boltUniqueIndexes {
+		if value := uniqueValue(p, field); value != "" {
+			if err := tx.Bucket(bucket).Delete([]byte(value)); err != nil {
+				return err
+			}
+		}
+	}
+	if p.ProductType != "" {
+		return tx.Bucket(productsByTypeBucket).Delete(typeIndexKey(p.ProductType, p.Uuid))
+	}
+	return nil
+}
+
+// typeIndexKey returns the key of the entry of a product in the index by type.
+func typeIndexKey(name, uuid string) []byte {
+	return []byte(name + "\x00" + uuid)
+}
+
+// getProductType reads a product type, returning nil if it does not exist.
+func getProductType(tx *bbolt.Tx, name string) (*models.ProductType, error) {
+	v := tx.Bucket(productTypesBucket).Get([]byte(name))
+	if v == nil {
+		return nil, nil
+	}
+	t := new(models.ProductType)
+	if err := bson.Unmarshal(v, t); err != nil {
+		return nil, errors.Wrap(err, "decoding product type")
+	}
+	return t, nil
+}
+
+// productTypeGetter returns a function reading the product types of a transaction,
+// as checkProductType expects.
+func productTypeGetter(tx *bbolt.Tx) func(name string) (*models.ProductType, error) {
+	return func(name string) (*models.ProductType, error) {
+		return getProductType(tx, name)
+	}
+}
+
+// putProductType writes a product type.
+func putProductType(tx *bbolt.Tx, t *models.ProductType) error {
+	v, err := bson.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "encoding product type")
+	}
+	return tx.Bucket(productTypesBucket).Put([]byte(t.Name), v)
+}
+
+// decodeProduct decodes a product stored as BSON.
+func decodeProduct(v []byte) (*models.Product, error) {
+	p := new(models.Product)
+	if err := bson.Unmarshal(v, p); err != nil {
+		return nil, errors.Wrap(err, "decoding product")
+	}
+	return p, nil
+}
+
+// getIdempotencyKey returns the idempotency key with the given request id, or nil if there is none.
+func getIdempotencyKey(tx *bbolt.Tx, requestId string) (*idempotencyKey, error) {
+	v := tx.Bucket(idempotencyKeysBucket).Get([]byte(requestId))
+	if v == nil {
+		return nil, nil
+	}
+	key := new(idempotencyKey)
+	if err := bson.Unmarshal(v, key); err != nil {
+		return nil, errors.Wrap(err, "decoding idempotency key")
+	}
+	return key, nil
+}
+
+// putIdempotencyKey stores an idempotency key.
+func putIdempotencyKey(tx *bbolt.Tx, key *idempotencyKey) error {
+	v, err := bson.Marshal(key)
+	if err != nil {
+		return errors.Wrap(err, "encoding idempotency key")
+	}
+	if err := tx.Bucket(idempotencyKeysBucket).Put([]byte(key.RequestId), v); err != nil {
+		return err
+	}
+	return tx.Bucket(idempotencyExpiryBucket).Put(expiryKey(key.ExpiresAt, key.RequestId), nil)
+}
+
+// removeExpiredKeys deletes the idempotency keys that expired.
+func removeExpiredKeys(tx *bbolt.Tx) error {
+	now := expiryKey(timeNow(), "")
+	var expired [][]byte
+	c := tx.Bucket(idempotencyExpiryBucket).Cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k[:8], now) <= 0; k, _ = c.Next() {
+		expired = append(expired, append([]byte(nil), k...))
+	}
+	for _, k := range expired {
+		if err := tx.Bucket(idempotencyExpiryBucket).Delete(k); err != nil {
+			return err
+		}
+		if err := tx.Bucket(idempotencyKeysBucket).Delete(k[8:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expiryKey returns the key of the expiry index entry of an idempotency key.
+func expiryKey(expiresAt time.Time, requestId string) []byte {
+	k := make([]byte, 8, 8+len(requestId))
+	binary.BigEndian.PutUint64(k, uint64(expiresAt.UnixNano()))
+	return append(k, requestId...)
+}
+
+// indexKey returns the key of the index entry of a product for the given field.
+func indexKey(field string, p *models.Product) []byte {
+	return append(indexValue(fieldValue(p, field)), p.Uuid...)
+}
+
+// indexValue encodes the value of an indexed field so that
+// encoded values sort in the same order as the values.
+// Strings are terminated by 0x00 0x01, with 0x00 escaped as 0x00 0xff, so that no
+// string is a prefix of another. Numbers are encoded as 8 bytes, flipping the sign
+// bit of positive numbers and every bit of negative ones.
+func indexValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		b := bytes.ReplaceAll([]byte(v), []byte{0x00}, []byte{0x00, 0xff})
+		return append(b, 0x00, 0x01)
+	default:
+		n, _ := asNumber(v)
+		bits := math.Float64bits(n)
+		if bits&(1<<63) != 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, bits)
+		return b
+	}
+}
+
+// wrapBoltError annotates an error returned by a transaction with the given message,
+// passing through the errors of this package.
+func wrapBoltError(err error, format string, args ...interface{}) error {
+	var perr *Error
+	if errors.As(err, &perr) {
+		return err
+	}
+	return errors.Wrapf(err, format, args...)
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.etcd.io/bbolt"
+)
+
+func newTestBoltRepository(t *testing.T) (*BoltRepository, string) {
+	path := filepath.Join(t.TempDir(), "products.db")
+	repo, err := NewBoltRepository(path)
+	require.Nil(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo, path
+}
+
+func TestBoltRepository(t *testing.T) {
+	ctx := context.TODO()
+	repo, path := newTestBoltRepository(t)
+	repo.uuidProvider = func() string { return "uuid1" }
+
+	created, err := repo.Create(ctx, &models.Product{
+		Name:   "name",
+		Prices: usd("1"),
+		Attributes: map[string]interface{}{
+			"color":      "blue",
+			"dimensions": map[string]interface{}{"height": 2.0},
+		},
+	}, "", "")
+	require.Nil(t, err)
+	require.Equal(t, int64(1), created.Revision)
+
+	_, err = repo.Create(ctx, &models.Product{Name: "other"}, "", "")
+	require.Equal(t, `product with uuid "uuid1" already exists`, err.Error())
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+
+	got, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "uuid1"})
+	require.Nil(t, err)
+	require.Equal(t, created, got)
+
+	updated, err := repo.Update(ctx, &models.Product{Uuid: "uuid1", Name: "new name", Prices: usd("3")}, []string{"name", "prices", "attributes.color"}, 1)
+	require.Nil(t, err)
+	require.Equal(t, &models.Product{
+		Uuid:       "uuid1",
+		Name:       "new name",
+		Price:      3,
+		Prices:     usd("3"),
+		Attributes: map[string]interface{}{"dimensions": map[string]interface{}{"height": 2.0}},
+		Revision:   2,
+	}, updated)
+
+	_, err = repo.Update(ctx, &models.Product{Uuid: "uuid1", Name: "newer name"}, nil, 1)
+	require.Equal(t, `product with uuid "uuid1" is at revision 2, expected 1`, err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: "uuid2", Name: "newer name"}, nil, 0)
+	require.Equal(t, KindNotFound, KindOf(err))
+
+	// The index entries of the previous name and price are gone.
+	products, _, err := repo.List(ctx, &productcatalog.ListProductsRequest{OrderBy: "name"})
+	require.Nil(t, err)
+	require.Equal(t, []*models.Product{updated}, products)
+	products, _, err = repo.List(ctx, &productcatalog.ListProductsRequest{OrderBy: "price desc"})
+	require.Nil(t, err)
+	require.Equal(t, []*models.Product{updated}, products)
+
+	// Products survive reopening the database.
+	require.Nil(t, repo.Close())
+	repo, err = NewBoltRepository(path)
+	require.Nil(t, err)
+	got, err = repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "uuid1"})
+	require.Nil(t, err)
+	require.Equal(t, updated, got)
+
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: "uuid1", ExpectedRevision: 1})
+	require.Equal(t, KindConflict, KindOf(err))
+	deleted, err := repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: "uuid1"})
+	require.Nil(t, err)
+	require.Equal(t, updated, deleted)
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "uuid1"})
+	require.Equal(t, `product with uuid "uuid1" does not exist`, err.Error())
+	err = repo.db.View(func(tx *bbolt.Tx) error {
+		for _, bucket := range boltIndexes {
+			require.Equal(t, 0, tx.Bucket(bucket).Stats().KeyN)
+		}
+		return nil
+	})
+	require.Nil(t, err)
+	require.Nil(t, repo.Close())
+}
+
+func TestBoltRepositoryList(t *testing.T) {
+	ctx := context.TODO()
+	repo, _ := newTestBoltRepository(t)
+	products := []*models.Product{
+		{Name: "a", Prices: usd("3"), Attributes: map[string]interface{}{"weight": 2.0}},
+		{Name: "b", Prices: usd("1"), Attributes: map[string]interface{}{"weight": 1.0}},
+		{Name: "c", Prices: usd("0.5")},
+		{Name: "d", Prices: usd("1"), Attributes: map[string]interface{}{"weight": 3.0}},
+		{Name: "e", Prices: usd("5"), Attributes: map[string]interface{}{"weight": 1.0}},
+		{Name: "a", Prices: usd("1")},
+		{Name: "b\x00"},
+	}
+	for i, p := range products {
+		id := fmt.Sprintf("uuid%d", i)
+		repo.uuidProvider = func() string { return id }
+		_, err := repo.Create(ctx, p, "", "")
+		require.Nil(t, err)
+	}
+	testCases := []struct {
+		name           string
+		input          *productcatalog.ListProductsRequest
+		expectedOutput []string
+	}{
+		{
+			name:           "ordered by uuid",
+			input:          &productcatalog.ListProductsRequest{},
+			expectedOutput: []string{"uuid0", "uuid1", "uuid2", "uuid3", "uuid4", "uuid5", "uuid6"},
+		},
+		{
+			name:           "ordered by uuid descending",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "uuid desc"},
+			expectedOutput: []string{"uuid6", "uuid5", "uuid4", "uuid3", "uuid2", "uuid1", "uuid0"},
+		},
+		{
+			name:           "ordered by name",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "name"},
+			expectedOutput: []string{"uuid0", "uuid5", "uuid1", "uuid6", "uuid2", "uuid3", "uuid4"},
+		},
+		{
+			name:           "ordered by name descending",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "name desc"},
+			expectedOutput: []string{"uuid4", "uuid3", "uuid2", "uuid6", "uuid1", "uuid0", "uuid5"},
+		},
+		{
+			name:           "ordered by price",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "price"},
+			expectedOutput: []string{"uuid6", "uuid2", "uuid1", "uuid3", "uuid5", "uuid0", "uuid4"},
+		},
+		{
+			name:           "ordered by price descending and name",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "price desc, name desc"},
+			expectedOutput: []string{"uuid4", "uuid0", "uuid3", "uuid1", "uuid5", "uuid2", "uuid6"},
+		},
+		{
+			name:           "ordered by attribute",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "attributes.weight desc"},
+			expectedOutput: []string{"uuid3", "uuid0", "uuid1", "uuid4", "uuid2", "uuid5", "uuid6"},
+		},
+		{
+			name:           "filtered and ordered by price",
+			input:          &productcatalog.ListProductsRequest{Filter: "price >= 1 AND NOT name = \"d\"", OrderBy: "price"},
+			expectedOutput: []string{"uuid1", "uuid5", "uuid0", "uuid4"},
+		},
+	}
+	for _, tc := range testCases {
+		for _, size := range []int32{1, 2, 3, 100} {
+			t.Run(fmt.Sprintf("%s in pages of %d", tc.name, size), func(t *testing.T) {
+				req := &productcatalog.ListProductsRequest{Filter: tc.input.Filter, OrderBy: tc.input.OrderBy, PageSize: size}
+				var ids []string
+				for {
+					page, nextPageToken, err := repo.List(ctx, req)
+					require.Nil(t, err)
+					require.LessOrEqual(t, len(page), int(size))
+					for _, p := range page {
+						ids = append(ids, p.Uuid)
+					}
+					if nextPageToken == "" {
+						break
+					}
+					req.PageToken = nextPageToken
+				}
+				require.Equal(t, tc.expectedOutput, ids)
+			})
+		}
+		t.Run(tc.name+" streamed", func(t *testing.T) {
+			// Streams read batches of products like pages.
+			defer func(size int) { boltStreamBatchSize = size }(boltStreamBatchSize)
+			boltStreamBatchSize = 2
+			var ids []string
+			err := repo.Stream(ctx, &productcatalog.StreamProductsRequest{Filter: tc.input.Filter, OrderBy: tc.input.OrderBy}, func(p *models.Product) error {
+				ids = append(ids, p.Uuid)
+				return nil
+			})
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedOutput, ids)
+		})
+	}
+}
+
+func TestBoltRepositoryStream(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := repo.Create(context.TODO(), &models.Product{Name: name}, "", "")
+		require.Nil(t, err)
+	}
+
+	// Streaming stops at the first error returned by send.
+	sent := 0
+	err := repo.Stream(context.TODO(), &productcatalog.StreamProductsRequest{}, func(p *models.Product) error {
+		sent++
+		return errors.New("random error")
+	})
+	require.Equal(t, "random error", err.Error())
+	require.Equal(t, 1, sent)
+
+	// And when the context is canceled.
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err = repo.Stream(ctx, &productcatalog.StreamProductsRequest{}, func(p *models.Product) error {
+		return nil
+	})
+	require.True(t, errors.Is(err, context.Canceled))
+
+	// Products ordered by a field without an index are read once, so that the ones
+	// created while streaming are not sent.
+	defer func(size int) { boltStreamBatchSize = size }(boltStreamBatchSize)
+	boltStreamBatchSize = 1
+	var names []string
+	err = repo.Stream(context.TODO(), &productcatalog.StreamProductsRequest{OrderBy: "attributes.rank, name"}, func(p *models.Product) error {
+		names = append(names, p.Name)
+		_, err := repo.Create(context.TODO(), &models.Product{Name: "z"}, "", "")
+		return err
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, names)
+
+	err = repo.Stream(context.TODO(), &productcatalog.StreamProductsRequest{OrderBy: "size"}, nil)
+	require.Equal(t, `invalid argument: order_by: unknown field "size"`, err.Error())
+}
+
+func TestIndexValue(t *testing.T) {
+	values := []interface{}{math.Inf(-1), -10.5, -1.0, -0.25, 0.0, 0.25, 1.0, 10.5, math.Inf(1)}
+	for i := 1; i < len(values); i++ {
+		require.Equal(t, -1, bytes.Compare(indexValue(values[i-1]), indexValue(values[i])), "%v < %v", values[i-1], values[i])
+	}
+	names := []string{"", "\x00", "\x00a", "a", "a\x00", "a\x00\x00", "a\x01", "ab", "b"}
+	keys := make([][]byte, len(names))
+	for i, name := range names {
+		keys[i] = append(indexValue(name), "uuid"...)
+	}
+	require.True(t, sort.SliceIsSorted(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 }))
+}
+
+func TestPrefixEnd(t *testing.T) {
+	require.Equal(t, []byte{0x01, 0x03}, prefixEnd([]byte{0x01, 0x02}))
+	require.Equal(t, []byte{0x02}, prefixEnd([]byte{0x01, 0xff}))
+	require.Nil(t, prefixEnd([]byte{0xff, 0xff}))
+}
+
+func TestBoltRepositoryBatches(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryBatches(t, repo)
+}
+
+func TestBoltRepositoryWatch(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryWatch(t, repo)
+}
+
+func TestBoltRepositoryIdempotency(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryIdempotency(t, repo)
+
+	// Expired keys are removed by later creates.
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { return time.Now().Add(3 * IdempotencyKeyTTL) }
+	_, err := repo.Create(context.TODO(), &models.Product{Name: "d"}, "", "")
+	require.Nil(t, err)
+	err = repo.db.View(func(tx *bbolt.Tx) error {
+		require.Equal(t, 0, tx.Bucket(idempotencyKeysBucket).Stats().KeyN)
+		require.Equal(t, 0, tx.Bucket(idempotencyExpiryBucket).Stats().KeyN)
+		return nil
+	})
+	require.Nil(t, err)
+}
+
+func TestBoltRepositoryUniqueness(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryUniqueness(t, repo)
+
+	// Only the keys of the stored products are left in the indexes.
+	err := repo.db.View(func(tx *bbolt.Tx) error {
+		require.Equal(t, 1, tx.Bucket(boltUniqueIndexes["sku"]).Stats().KeyN)
+		require.Equal(t, 2, tx.Bucket(boltUniqueIndexes["slug"]).Stats().KeyN)
+		return nil
+	})
+	require.Nil(t, err)
+}
+
+func TestBoltRepositoryProductTypes(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryProductTypes(t, repo)
+
+	// Only the product still having a type is left in the index by type.
+	err := repo.db.View(func(tx *bbolt.Tx) error {
+		require.Equal(t, 1, tx.Bucket(productsByTypeBucket).Stats().KeyN)
+		return nil
+	})
+	require.Nil(t, err)
+}
+
+func TestBoltRepositoryAttributeLimits(t *testing.T) {
+	repo, _ := newTestBoltRepository(t)
+	testRepositoryAttributeLimits(t, repo)
+}
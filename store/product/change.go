@@ -0,0 +1,281 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// ChangeType is the type of a change made to a product.
+type ChangeType int
+
+const (
+	// ChangeCreated means that the product was created.
+	ChangeCreated ChangeType = iota + 1
+	// ChangeUpdated means that the product was updated.
+	ChangeUpdated
+	// ChangeDeleted means that the product was deleted.
+	ChangeDeleted
+)
+
+// String returns the name of the change type.
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeCreated:
+		return "created"
+	case ChangeUpdated:
+		return "updated"
+	case ChangeDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a change made to a product, as reported by Watch.
+type Change struct {
+	Type ChangeType
+	Uuid string
+	// Before is the product before the change, nil for created products
+	// and when the backend does not know it.
+	Before *models.Product
+	// After is the product after the change, nil for deleted products
+	// and when the backend does not know it.
+	After *models.Product
+	// ResumeToken is the token to resume watching after the change,
+	// empty when the backend does not support resuming.
+	ResumeToken string
+}
+
+// pollInterval is how often the products are read by a poller.
+// It is a variable for ease of unit testing.
+var pollInterval = time.Second
+
+// maxPolledProducts is the largest number of products a poller reads, as each read
+// holds every product in memory, unless set otherwise. It is a variable for ease of unit testing.
+var maxPolledProducts = 100000
+
+// watcherBuffer is the number of reads whose changes a watcher may fall behind by.
+const watcherBuffer = 16
+
+// errResumeNotSupported is returned when resuming a watch that polls for changes.
+var errResumeNotSupported = invalidArgumentError(FieldViolation{
+	Field:       "resume_token",
+	Description: "is not supported by this storage backend",
+})
+
+// errWatcherBehind is returned to a watcher that does not keep up with the changes.
+var errWatcherBehind = &Error{
+	Kind: KindUnavailable,
+	msg:  "watcher fell behind the changes",
+}
+
+// streamFunc is the Stream method of a repository.
+type streamFunc func(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error
+
+// poller implements Watch for the backends that cannot be notified of changes.
+// While it has watchers, it reads every product every pollInterval, and hands the
+// differences between consecutive reads to every watcher, so that the reads are
+// shared. Changes made to a product between two reads are reported as a single
+// change, and none is resumable. The zero value is ready to use.
+type poller struct {
+	mu          sync.Mutex
+	loop        *pollLoop // The running loop, nil when there are no watchers.
+	maxProducts int       // Largest number of products read, maxPolledProducts when zero.
+}
+
+// pollLoop is a loop reading the products for the watchers of a poller.
+type pollLoop struct {
+	interval    time.Duration
+	maxProducts int
+	cancel      context.CancelFunc
+	watchers    map[*watcher]bool
+}
+
+// watcher receives the changes read by a poll loop.
+type watcher struct {
+	loop    *pollLoop
+	changes chan []*Change
+	err     error // Why changes was closed.
+}
+
+// watch calls send with the changes made to the products read with stream, from
+// the next read of the products on. It returns when ctx is done, when send fails,
+// or when reading fails.
+func (p *poller) watch(ctx context.Context, stream streamFunc, send func(*Change) error) error {
+	w := p.subscribe(stream)
+	defer p.unsubscribe(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case changes, ok := <-w.changes:
+			if !ok {
+				return w.err
+			}
+			for _, c := range changes {
+				if err := send(c); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// setMaxProducts sets the largest number of products read by the loops started from now on.
+func (p *poller) setMaxProducts(max int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxProducts = max
+}
+
+// subscribe adds a watcher, starting a loop when there is none.
+func (p *poller) subscribe(stream streamFunc) *watcher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.loop == nil {
+		maxProducts := p.maxProducts
+		if maxProducts == 0 {
+			maxProducts = maxPolledProducts
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		p.loop = &pollLoop{
+			interval:    pollInterval,
+			maxProducts: maxProducts,
+			cancel:      cancel,
+			watchers:    make(map[*watcher]bool),
+		}
+		go p.run(ctx, p.loop, stream)
+	}
+	w := &watcher{loop: p.loop, changes: make(chan []*Change, watcherBuffer)}
+	p.loop.watchers[w] = true
+	return w
+}
+
+// unsubscribe removes a watcher, stopping its loop when it was the last one.
+func (p *poller) unsubscribe(w *watcher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(w.loop.watchers, w)
+	if len(w.loop.watchers) == 0 && p.loop == w.loop {
+		w.loop.cancel()
+		p.loop = nil
+	}
+}
+
+// run reads the products every interval of loop until ctx is done,
+// publishing the changes to its watchers.
+func (p *poller) run(ctx context.Context, loop *pollLoop, stream streamFunc) {
+	previous, err := readSnapshot(ctx, stream, loop.maxProducts)
+	if err != nil {
+		p.fail(loop, err)
+		return
+	}
+	ticker := time.NewTicker(loop.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current, err := readSnapshot(ctx, stream, loop.maxProducts)
+		if err != nil {
+			p.fail(loop, err)
+			return
+		}
+		if changes := diffSnapshots(previous, current); len(changes) > 0 {
+			p.publish(loop, changes)
+		}
+		previous = current
+	}
+}
+
+// publish hands changes to the watchers of loop,
+// ending those that fell too far behind.
+func (p *poller) publish(loop *pollLoop, changes []*Change) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for w := range loop.watchers {
+		select {
+		case w.changes <- changes:
+		default:
+			delete(loop.watchers, w)
+			w.end(errWatcherBehind)
+		}
+	}
+}
+
+// fail ends the watchers of loop with err. Later watchers start another loop.
+func (p *poller) fail(loop *pollLoop, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for w := range loop.watchers {
+		delete(loop.watchers, w)
+		w.end(err)
+	}
+	if p.loop == loop {
+		loop.cancel()
+		p.loop = nil
+	}
+}
+
+// end stops handing changes to the watcher, which then returns err.
+func (w *watcher) end(err error) {
+	w.err = err
+	close(w.changes)
+}
+
+// readSnapshot reads every product with stream, by uuid,
+// failing when there are more than maxProducts.
+func readSnapshot(ctx context.Context, stream streamFunc, maxProducts int) (map[string]*models.Product, error) {
+	snapshot := make(map[string]*models.Product)
+	tooMany := &Error{
+		Kind: KindFailedPrecondition,
+		msg:  fmt.Sprintf("watching more than %d products needs change streams", maxProducts),
+	}
+	err := stream(ctx, &productcatalog.StreamProductsRequest{}, func(p *models.Product) error {
+		if len(snapshot) == maxProducts {
+			return tooMany
+		}
+		snapshot[p.Uuid] = p
+		return nil
+	})
+	if errors.Is(err, tooMany) {
+		return nil, tooMany
+	}
+	return snapshot, err
+}
+
+// diffSnapshots returns the changes that turn one read of the products into another,
+// ordered by uuid. A product is taken as updated when its revision or fields changed.
+func diffSnapshots(previous, current map[string]*models.Product) []*Change {
+	var changes []*Change
+	for uuid, after := range current {
+		before, ok := previous[uuid]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Type: ChangeCreated, Uuid: uuid, After: after})
+		case before.Revision != after.Revision || !sameFields(before, after):
+			changes = append(changes, &Change{Type: ChangeUpdated, Uuid: uuid, Before: before, After: after})
+		}
+	}
+	for uuid, before := range previous {
+		if _, ok := current[uuid]; !ok {
+			changes = append(changes, &Change{Type: ChangeDeleted, Uuid: uuid, Before: before})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Uuid < changes[j].Uuid
+	})
+	return changes
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	previous := map[string]*models.Product{
+		"a": {Uuid: "a", Name: "a", Revision: 1},
+		"b": {Uuid: "b", Name: "b", Revision: 1},
+		"c": {Uuid: "c", Name: "c", Revision: 3},
+		"d": {Uuid: "d", Name: "d", Revision: 1},
+	}
+	current := map[string]*models.Product{
+		"a": {Uuid: "a", Name: "a", Revision: 1},
+		"c": {Uuid: "c", Name: "c2", Revision: 4},
+		"d": {Uuid: "d", Name: "d2", Revision: 1},
+		"e": {Uuid: "e", Name: "e", Revision: 1},
+	}
+	expected := []*Change{
+		{Type: ChangeDeleted, Uuid: "b", Before: previous["b"]},
+		{Type: ChangeUpdated, Uuid: "c", Before: previous["c"], After: current["c"]},
+		{Type: ChangeUpdated, Uuid: "d", Before: previous["d"], After: current["d"]},
+		{Type: ChangeCreated, Uuid: "e", After: current["e"]},
+	}
+	require.Equal(t, expected, diffSnapshots(previous, current))
+	require.Empty(t, diffSnapshots(current, current))
+}
+
+func TestPollerWatch(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	snapshots := [][]*models.Product{
+		{{Uuid: "a", Revision: 1}},
+		{{Uuid: "a", Revision: 2}, {Uuid: "b", Revision: 1}},
+		{{Uuid: "a", Revision: 2}, {Uuid: "b", Revision: 1}},
+		{{Uuid: "b", Revision: 1}},
+	}
+	testCases := []struct {
+		name           string
+		mockStreamErr  func(call int) error
+		mockSend       func(c *Change) error
+		expectedOutput []*Change
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			expectedOutput: []*Change{
+				{Type: ChangeUpdated, Uuid: "a", Before: snapshots[0][0], After: snapshots[1][0]},
+				{Type: ChangeCreated, Uuid: "b", After: snapshots[1][1]},
+				{Type: ChangeDeleted, Uuid: "a", Before: snapshots[2][0]},
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name: "error when reading products",
+			mockStreamErr: func(call int) error {
+				if call == 2 {
+					return errors.New("random error")
+				}
+				return nil
+			},
+			expectedError: errors.New("random error"),
+		},
+		{
+			name: "error when sending change",
+			mockSend: func(c *Change) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			calls := 0
+			stream := func(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+				calls++
+				if tc.mockStreamErr != nil {
+					if err := tc.mockStreamErr(calls); err != nil {
+						return err
+					}
+				}
+				// Wait for the watch to end once every snapshot was read.
+				if calls > len(snapshots) {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				for _, p := range snapshots[calls-1] {
+					if err := send(p); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			var output []*Change
+			var p poller
+			err := p.watch(ctx, stream, func(c *Change) error {
+				if tc.mockSend != nil {
+					return tc.mockSend(c)
+				}
+				output = append(output, c)
+				if len(output) == len(tc.expectedOutput) {
+					cancel()
+				}
+				return nil
+			})
+			require.NotNil(t, err)
+			require.Equal(t, tc.expectedError.Error(), err.Error())
+			require.Equal(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func TestPollerSharesReads(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	var mu sync.Mutex
+	calls := 0
+	stream := func(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+		mu.Lock()
+		calls++
+		revision := int64(calls)
+		mu.Unlock()
+		return send(&models.Product{Uuid: "a", Revision: revision})
+	}
+	var p poller
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	revisions := make([]chan int64, 2)
+	done := make(chan error, len(revisions))
+	for i := range revisions {
+		revisions[i] = make(chan int64, 100)
+		go func(revisions chan int64) {
+			done <- p.watch(ctx, stream, func(c *Change) error {
+				select {
+				case revisions <- c.After.Revision:
+				default:
+				}
+				return nil
+			})
+		}(revisions[i])
+	}
+
+	// Each read is made once for both watchers, which then see every revision
+	// in turn, instead of every other one.
+	for _, r := range revisions {
+		previous := <-r
+		for i := 0; i < 5; i++ {
+			revision := <-r
+			require.Equal(t, previous+1, revision)
+			previous = revision
+		}
+	}
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+	require.Equal(t, context.Canceled, <-done)
+
+	// The loop stops with its last watcher.
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.loop == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestPollerMaxProducts(t *testing.T) {
+	defer func(max int) { maxPolledProducts = max }(maxPolledProducts)
+	maxPolledProducts = 2
+
+	stream := func(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+		for _, uuid := range []string{"a", "b", "c"} {
+			if err := send(&models.Product{Uuid: uuid}); err != nil {
+				return errors.Wrap(err, "streaming products")
+			}
+		}
+		return nil
+	}
+	var p poller
+	err := p.watch(context.TODO(), stream, func(c *Change) error { return nil })
+	require.Equal(t, KindFailedPrecondition, KindOf(err))
+	require.Equal(t, "watching more than 2 products needs change streams", err.Error())
+}
+
+func TestMemoryRepositoryMaxWatchedProducts(t *testing.T) {
+	repo := NewMemoryRepository()
+	for _, name := range []string{"a", "b"} {
+		_, err := repo.Create(context.TODO(), &models.Product{Name: name}, "", "")
+		require.Nil(t, err)
+	}
+	repo.SetMaxWatchedProducts(1)
+	err := repo.Watch(context.TODO(), "", func(c *Change) error { return nil })
+	require.Equal(t, KindFailedPrecondition, KindOf(err))
+	require.Equal(t, "watching more than 1 products needs change streams", err.Error())
+}
+
+func TestPollerWatcherBehind(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	var p poller
+	w := p.subscribe(func(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+		return nil
+	})
+	defer p.unsubscribe(w)
+	changes := []*Change{{Type: ChangeCreated, Uuid: "a"}}
+	for i := 0; i <= watcherBuffer; i++ {
+		p.publish(w.loop, changes)
+	}
+	for i := 0; i < watcherBuffer; i++ {
+		require.Equal(t, changes, <-w.changes)
+	}
+	_, ok := <-w.changes
+	require.False(t, ok)
+	require.Equal(t, errWatcherBehind, w.err)
+}
+
+// testRepositoryWatch checks the Watch method of a repository that polls for changes.
+func testRepositoryWatch(t *testing.T, repo ProductRepository) {
+	err := repo.Watch(context.TODO(), "token", func(c *Change) error { return nil })
+	require.Equal(t, "invalid argument: resume_token: is not supported by this storage backend", err.Error())
+
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	changes := make(chan *Change)
+	done := make(chan error)
+	go func() {
+		done <- repo.Watch(ctx, "", func(c *Change) error {
+			select {
+			case changes <- c:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	// The first read of the watch may happen after the product is created,
+	// so it is updated until the update is seen.
+	p, err := repo.Create(ctx, &models.Product{Name: "name"}, "", "")
+	require.Nil(t, err)
+	var seen *Change
+	for seen == nil {
+		_, err := repo.Update(ctx, &models.Product{Uuid: p.Uuid, Prices: usd(fmt.Sprint(p.Revision))}, []string{"prices"}, 0)
+		require.Nil(t, err)
+		p.Revision++
+		select {
+		case seen = <-changes:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	require.Equal(t, p.Uuid, seen.Uuid)
+	require.Contains(t, []ChangeType{ChangeCreated, ChangeUpdated}, seen.Type)
+
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: p.Uuid})
+	require.Nil(t, err)
+	for seen.Type != ChangeDeleted {
+		seen = <-changes
+	}
+	require.Equal(t, p.Uuid, seen.Uuid)
+	require.Equal(t, "name", seen.Before.Name)
+	require.Nil(t, seen.After)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+}
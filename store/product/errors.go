@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kind identifies the category of an error returned by this package.
+type Kind int
+
+const (
+	// KindUnknown is used for errors that could not be classified.
+	KindUnknown Kind = iota
+	// KindNotFound means that the requested product does not exist.
+	KindNotFound
+	// KindAlreadyExists means that the product being written clashes with an existing one.
+	KindAlreadyExists
+	// KindInvalidArgument means that the request itself is invalid.
+	KindInvalidArgument
+	// KindConflict means that the operation conflicts with the current state of the product.
+	KindConflict
+	// KindUnavailable means that the database could not be reached in time.
+	KindUnavailable
+	// KindFailedPrecondition means that the operation is not supported in the current state of the catalog.
+	KindFailedPrecondition
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindAlreadyExists:
+		return "already exists"
+	case KindInvalidArgument:
+		return "invalid argument"
+	case KindConflict:
+		return "conflict"
+	case KindUnavailable:
+		return "unavailable"
+	case KindFailedPrecondition:
+		return "failed precondition"
+	default:
+		return "unknown"
+	}
+}
+
+// FieldViolation describes a single invalid field of a request.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error is the error type returned by this package.
+// It carries a Kind, so callers can branch on the category of the failure
+// instead of parsing error messages.
+type Error struct {
+	Kind       Kind
+	Uuid       string           // Unique identifier of the product the error refers to, if any.
+	KeyField   string           // Natural key, sku or slug, the error refers to, if any.
+	Key        string           // Value of KeyField.
+	Violations []FieldViolation // Invalid fields, for errors of kind KindInvalidArgument.
+	msg        string
+	err        error
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.msg + ": " + e.err.Error()
+	}
+	return e.msg
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// KindOf returns the kind of the given error,
+// or KindUnknown if it was not returned by this package.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindUnknown
+}
+
+// notFoundError returns an error stating that the product with the given uuid does not exist.
+func notFoundError(uuid string) error {
+	return &Error{
+		Kind: KindNotFound,
+		Uuid: uuid,
+		msg:  fmt.Sprintf(`product with uuid "%s" does not exist`, uuid),
+	}
+}
+
+// alreadyExistsError returns an error stating that a product with the given uuid already exists.
+func alreadyExistsError(uuid string) error {
+	return &Error{
+		Kind: KindAlreadyExists,
+		Uuid: uuid,
+		msg:  fmt.Sprintf(`product with uuid "%s" already exists`, uuid),
+	}
+}
+
+// conflictError returns an error stating that the product with the given uuid
+// is not at the revision the caller expected.
+func conflictError(uuid string, expected, actual int64) error {
+	return &Error{
+		Kind: KindConflict,
+		Uuid: uuid,
+		msg:  fmt.Sprintf(`product with uuid "%s" is at revision %d, expected %d`, uuid, actual, expected),
+	}
+}
+
+// invalidArgumentError returns an error listing the given field violations.
+func invalidArgumentError(violations ...FieldViolation) error {
+	msg := "invalid argument"
+	for i, v := range violations {
+		if i == 0 {
+			msg += ": "
+		} else {
+			msg += "; "
+		}
+		msg += fmt.Sprintf("%s: %s", v.Field, v.Description)
+	}
+	return &Error{
+		Kind:       KindInvalidArgument,
+		Violations: violations,
+		msg:        msg,
+	}
+}
+
+// NewInvalidArgumentError returns an error listing the given field violations,
+// for callers that check requests before they reach a repository.
+func NewInvalidArgumentError(violations ...FieldViolation) error {
+	return invalidArgumentError(violations...)
+}
+
+// batchItemError returns the error of the item at the given index of a batch,
+// reported as the failure of the whole batch. The kind of the error is kept,
+// and its field violations are prefixed with the position of the item,
+// as in products[3].name.
+func batchItemError(field string, index int, err error) error {
+	prefix := fmt.Sprintf("%s[%d]", field, index)
+	var e *Error
+	if !errors.As(err, &e) {
+		return errors.Wrap(err, prefix)
+	}
+	if e.Kind == KindInvalidArgument {
+		violations := make([]FieldViolation, len(e.Violations))
+		for i, v := range e.Violations {
+			violations[i] = FieldViolation{Field: prefix + "." + v.Field, Description: v.Description}
+		}
+		return invalidArgumentError(violations...)
+	}
+	return &Error{Kind: e.Kind, Uuid: e.Uuid, KeyField: e.KeyField, Key: e.Key, msg: prefix + ": " + e.msg, err: e.err}
+}
+
+// wrapDbError annotates an error returned by MongoDB with the given message,
+// classifying the failures callers can act upon.
+func wrapDbError(err error, uuid, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Kind: KindAlreadyExists, Uuid: uuid, msg: msg, err: err}
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err):
+		return &Error{Kind: KindUnavailable, Uuid: uuid, msg: msg, err: err}
+	}
+	return errors.Wrap(err, msg)
+}
+
+// wrapWriteError annotates an error returned by MongoDB when writing the given product
+// as wrapDbError does, except for the duplicate key errors of the sku and slug indexes,
+// which are reported as the other repositories report them.
+func wrapWriteError(err error, p *models.Product, format string, args ...interface{}) error {
+	if mongo.IsDuplicateKeyError(err) {
+		for _, field := range uniqueFields {
+			if strings.Contains(err.Error(), " index: "+uniqueIndexName(field)+" ") {
+				return uniqueValueTakenError(p.Uuid, field, uniqueValue(p, field))
+			}
+		}
+	}
+	return wrapDbError(err, p.Uuid, format, args...)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWrapDbError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         error
+		expectedKind  Kind
+		expectedError error
+	}{
+		{
+			name:          "duplicate key",
+			input:         mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}},
+			expectedKind:  KindAlreadyExists,
+			expectedError: errors.New("inserting product: write exception: write errors: [duplicate key]"),
+		},
+		{
+			name:          "timeout",
+			input:         context.DeadlineExceeded,
+			expectedKind:  KindUnavailable,
+			expectedError: errors.New("inserting product: context deadline exceeded"),
+		},
+		{
+			name:          "unknown error",
+			input:         errors.New("random error"),
+			expectedKind:  KindUnknown,
+			expectedError: errors.New("inserting product: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := wrapDbError(tc.input, "uuid", "inserting product")
+			require.Equal(t, tc.expectedError.Error(), err.Error())
+			require.Equal(t, tc.expectedKind, KindOf(err))
+		})
+	}
+}
+
+func TestWrapWriteError(t *testing.T) {
+	p := &models.Product{Uuid: "uuid", Sku: "SKU-1", Slug: "a"}
+	testCases := []struct {
+		name             string
+		input            error
+		expectedKind     Kind
+		expectedKeyField string
+		expectedKey      string
+		expectedError    error
+	}{
+		{
+			name: "duplicate sku",
+			input: mongo.WriteException{WriteErrors: []mongo.WriteError{{
+				Code:    11000,
+				Message: `E11000 duplicate key error collection: db.products index: sku_1 dup key: { sku: "SKU-1" }`,
+			}}},
+			expectedKind:     KindAlreadyExists,
+			expectedKeyField: "sku",
+			expectedKey:      "SKU-1",
+			expectedError:    errors.New(`product with sku "SKU-1" already exists`),
+		},
+		{
+			name: "duplicate slug in a bulk write",
+			input: mongo.WriteError{
+				Code:    11000,
+				Message: `E11000 duplicate key error collection: db.products index: slug_1 dup key: { slug: "a" }`,
+			},
+			expectedKind:     KindAlreadyExists,
+			expectedKeyField: "slug",
+			expectedKey:      "a",
+			expectedError:    errors.New(`product with slug "a" already exists`),
+		},
+		{
+			name: "duplicate uuid",
+			input: mongo.WriteException{WriteErrors: []mongo.WriteError{{
+				Code:    11000,
+				Message: `E11000 duplicate key error collection: db.products index: uuid_1 dup key: { uuid: "uuid" }`,
+			}}},
+			expectedKind:  KindAlreadyExists,
+			expectedError: errors.New(`inserting product: write exception: write errors: [E11000 duplicate key error collection: db.products index: uuid_1 dup key: { uuid: "uuid" }]`),
+		},
+		{
+			name:          "unknown error",
+			input:         errors.New("random error"),
+			expectedKind:  KindUnknown,
+			expectedError: errors.New("inserting product: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := wrapWriteError(tc.input, p, "inserting product")
+			require.Equal(t, tc.expectedError.Error(), err.Error())
+			require.Equal(t, tc.expectedKind, KindOf(err))
+			var perr *Error
+			if errors.As(err, &perr) {
+				require.Equal(t, "uuid", perr.Uuid)
+				require.Equal(t, tc.expectedKeyField, perr.KeyField)
+				require.Equal(t, tc.expectedKey, perr.Key)
+			}
+		})
+	}
+}
+
+func TestInvalidArgumentError(t *testing.T) {
+	err := invalidArgumentError(
+		FieldViolation{Field: "name", Description: "must not be empty"},
+		FieldViolation{Field: "price", Description: "must not be negative"},
+	)
+	require.Equal(t, "invalid argument: name: must not be empty; price: must not be negative", err.Error())
+	var perr *Error
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, KindInvalidArgument, perr.Kind)
+	require.Len(t, perr.Violations, 2)
+}
+
+func TestNotFoundError(t *testing.T) {
+	err := notFoundError("uuid")
+	require.Equal(t, `product with uuid "uuid" does not exist`, err.Error())
+	require.Equal(t, KindNotFound, KindOf(err))
+}
+
+func TestConflictError(t *testing.T) {
+	err := conflictError("uuid", 2, 3)
+	require.Equal(t, `product with uuid "uuid" is at revision 3, expected 2`, err.Error())
+	require.Equal(t, KindConflict, KindOf(err))
+}
+
+func TestBatchItemError(t *testing.T) {
+	testCases := []struct {
+		name               string
+		input              error
+		expectedKind       Kind
+		expectedViolations []FieldViolation
+		expectedError      error
+	}{
+		{
+			name:               "invalid argument",
+			input:              invalidArgumentError(FieldViolation{Field: "name", Description: "must not be empty"}),
+			expectedKind:       KindInvalidArgument,
+			expectedViolations: []FieldViolation{{Field: "products[2].name", Description: "must not be empty"}},
+			expectedError:      errors.New("invalid argument: products[2].name: must not be empty"),
+		},
+		{
+			name:          "conflict",
+			input:         conflictError("uuid", 2, 3),
+			expectedKind:  KindConflict,
+			expectedError: errors.New(`products[2]: product with uuid "uuid" is at revision 3, expected 2`),
+		},
+		{
+			name:          "unknown error",
+			input:         errors.New("random error"),
+			expectedKind:  KindUnknown,
+			expectedError: errors.New("products[2]: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := batchItemError("products", 2, tc.input)
+			require.Equal(t, tc.expectedError.Error(), err.Error())
+			require.Equal(t, tc.expectedKind, KindOf(err))
+			var perr *Error
+			if errors.As(err, &perr) {
+				require.Equal(t, tc.expectedViolations, perr.Violations)
+			}
+		})
+	}
+}
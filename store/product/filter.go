@@ -0,0 +1,488 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Filter expressions follow a subset of AIP-160:
+//
+//	attributes.color = "red" AND price < 50 AND attributes.sizes:"M"
+//
+// Restrictions compare a field with a literal using one of
+// =, !=, <, <=, >, >= or the "has" operator ":". A restriction
+// like attributes.sizes:"M" matches when the attribute is equal to,
+// or is a list containing, the value; attributes.color:* matches
+// when the attribute is present. Literals are double or single quoted
+// strings, numbers, true, false and null. Restrictions are combined
+// with AND, OR and NOT, and grouped with parentheses. As in AIP-160,
+// OR binds tighter than AND.
+//
+// Fields are either one of the top level fields (uuid, name, description, price,
+// sku, slug, product_type) or a path into the attributes, such as attributes.dimensions.height.
+// Attribute keys may only contain letters, digits, '_' and '-', so a filter
+// can never inject MongoDB operators or reach outside the attributes subdocument.
+
+const (
+	// maxFilterLength is the maximum length of a filter expression.
+	maxFilterLength = 4096
+	// maxFilterDepth is the maximum nesting of parentheses and negations.
+	maxFilterDepth = 32
+	// maxAttributePathDepth is the maximum number of keys in an attribute path.
+	maxAttributePathDepth = 8
+	// attributesField is the name of the field holding the product attributes.
+	attributesField = "attributes"
+)
+
+// attributeKeyRegexp matches the attribute keys that can be used in filters and orderings.
+var attributeKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// fieldKind tells the type of the values a top level field holds.
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	numberField
+	anyField
+)
+
+// topLevelFields are the product fields that can be filtered and sorted by, besides the attributes.
+var topLevelFields = map[string]fieldKind{
+	"uuid":         stringField,
+	"name":         stringField,
+	"description":  stringField,
+	"price":        numberField,
+	"sku":          stringField,
+	"slug":         stringField,
+	"product_type": stringField,
+}
+
+// filterNode is a node of a parsed filter expression.
+type filterNode interface {
+	// toBSON translates the node into a MongoDB query.
+	toBSON() bson.M
+	// matches reports whether a product satisfies the node.
+	matches(p *models.Product) bool
+}
+
+// comparison is a restriction over a single field.
+type comparison struct {
+	field    string
+	operator string
+	value    interface{}
+	presence bool // Set for the "field:*" restriction.
+}
+
+// conjunction matches when all its children match.
+type conjunction struct {
+	children []filterNode
+}
+
+// disjunction matches when any of its children match.
+type disjunction struct {
+	children []filterNode
+}
+
+// negation matches when its child does not match.
+type negation struct {
+	child filterNode
+}
+
+// comparisonOperators maps filter operators to MongoDB query operators.
+var comparisonOperators = map[string]string{
+	"=":  "$eq",
+	"!=": "$ne",
+	"<":  "$lt",
+	"<=": "$lte",
+	">":  "$gt",
+	">=": "$gte",
+	":":  "$eq",
+}
+
+func (c *comparison) toBSON() bson.M {
+	if c.presence {
+		return bson.M{c.field: bson.M{"$exists": true}}
+	}
+	return bson.M{c.field: bson.M{comparisonOperators[c.operator]: c.value}}
+}
+
+func (c *conjunction) toBSON() bson.M {
+	children := make(bson.A, len(c.children))
+	for i, child := range c.children {
+		children[i] = child.toBSON()
+	}
+	return bson.M{"$and": children}
+}
+
+func (d *disjunction) toBSON() bson.M {
+	children := make(bson.A, len(d.children))
+	for i, child := range d.children {
+		children[i] = child.toBSON()
+	}
+	return bson.M{"$or": children}
+}
+
+func (n *negation) toBSON() bson.M {
+	return bson.M{"$nor": bson.A{n.child.toBSON()}}
+}
+
+// parseFilter parses a filter expression.
+// It returns a nil node for an empty expression.
+func parseFilter(filter string) (filterNode, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	if len(filter) > maxFilterLength {
+		return nil, filterError("must not be longer than %d characters", maxFilterLength)
+	}
+	tokens, err := lexFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	node, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, filterError("unexpected %s at position %d", tok, tok.pos)
+	}
+	return node, nil
+}
+
+// filterError returns an invalid argument error for the filter field.
+func filterError(format string, args ...interface{}) error {
+	return invalidArgumentError(FieldViolation{Field: "filter", Description: fmt.Sprintf(format, args...)})
+}
+
+// tokenKind identifies the kind of a filter token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenStar
+	tokenLParen
+	tokenRParen
+)
+
+// filterToken is a lexical token of a filter expression.
+type filterToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+func (t filterToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of filter"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexFilter splits a filter expression into tokens.
+func lexFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '*':
+			tokens = append(tokens, filterToken{kind: tokenStar, text: "*", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			s, n, err := lexString(runes[i:], i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: string(runes[i : i+n]), value: s, pos: i})
+			i += n
+		case strings.ContainsRune("=!<>:", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != ':' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, filterError("unexpected %q at position %d", "!", i)
+			}
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.')) || r == '.':
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".eE+-", runes[j])) {
+				j++
+			}
+			text := string(runes[i:j])
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, filterError("invalid number %q at position %d", text, i)
+			}
+			tokens = append(tokens, filterToken{kind: tokenNumber, text: text, value: v, pos: i})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_-.", runes[j])) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenIdent, text: string(runes[i:j]), pos: i})
+			i = j
+		default:
+			return nil, filterError("unexpected %q at position %d", string(r), i)
+		}
+	}
+	return append(tokens, filterToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// lexString reads a quoted string at the beginning of runes,
+// returning its unescaped value and the number of runes consumed.
+func lexString(runes []rune, pos int) (string, int, error) {
+	quote := runes[0]
+	var sb strings.Builder
+	for i := 1; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\\':
+			if i+1 == len(runes) {
+				return "", 0, filterError("unterminated string at position %d", pos)
+			}
+			i++
+			switch e := runes[i]; e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '\\', '"', '\'':
+				sb.WriteRune(e)
+			default:
+				return "", 0, filterError("invalid escape sequence %q at position %d", `\`+string(e), pos+i-1)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", 0, filterError("unterminated string at position %d", pos)
+}
+
+// filterParser is a recursive descent parser for filter expressions.
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	depth  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword reports whether the token is the given keyword.
+func isKeyword(tok filterToken, keyword string) bool {
+	return tok.kind == tokenIdent && tok.text == keyword
+}
+
+// parseExpression parses: sequence { "AND" sequence }.
+func (p *filterParser) parseExpression() (filterNode, error) {
+	node, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	children := []filterNode{node}
+	for isKeyword(p.peek(), "AND") {
+		p.next()
+		node, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &conjunction{children: children}, nil
+}
+
+// parseSequence parses: factor { "OR" factor }.
+func (p *filterParser) parseSequence() (filterNode, error) {
+	node, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	children := []filterNode{node}
+	for isKeyword(p.peek(), "OR") {
+		p.next()
+		node, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &disjunction{children: children}, nil
+}
+
+// parseFactor parses: "NOT" factor | "(" expression ")" | restriction.
+func (p *filterParser) parseFactor() (filterNode, error) {
+	tok := p.peek()
+	switch {
+	case isKeyword(tok, "NOT"):
+		p.next()
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		child, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &negation{child: child}, nil
+	case tok.kind == tokenLParen:
+		p.next()
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		node, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, filterError("expected \")\" at position %d, got %s", closing.pos, closing)
+		}
+		return node, nil
+	}
+	return p.parseRestriction()
+}
+
+func (p *filterParser) enter(tok filterToken) error {
+	p.depth++
+	if p.depth > maxFilterDepth {
+		return filterError("nesting at position %d exceeds the maximum depth of %d", tok.pos, maxFilterDepth)
+	}
+	return nil
+}
+
+func (p *filterParser) leave() {
+	p.depth--
+}
+
+// parseRestriction parses: field operator value.
+func (p *filterParser) parseRestriction() (filterNode, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != tokenIdent || isKeyword(fieldTok, "AND") || isKeyword(fieldTok, "OR") {
+		return nil, filterError("expected a field name at position %d, got %s", fieldTok.pos, fieldTok)
+	}
+	kind, err := filterField(fieldTok)
+	if err != nil {
+		return nil, err
+	}
+	opTok := p.next()
+	if opTok.kind != tokenOperator {
+		return nil, filterError("expected an operator after %q at position %d, got %s", fieldTok.text, opTok.pos, opTok)
+	}
+	valueTok := p.next()
+	c := &comparison{field: fieldTok.text, operator: opTok.text}
+	switch valueTok.kind {
+	case tokenStar:
+		if opTok.text != ":" {
+			return nil, filterError(`"*" at position %d can only be used with the ":" operator`, valueTok.pos)
+		}
+		c.presence = true
+		return c, nil
+	case tokenString, tokenNumber:
+		c.value = valueTok.value
+	case tokenIdent:
+		switch valueTok.text {
+		case "true":
+			c.value = true
+		case "false":
+			c.value = false
+		case "null":
+			c.value = nil
+		default:
+			return nil, filterError("expected a value at position %d, got %s; strings must be quoted", valueTok.pos, valueTok)
+		}
+	default:
+		return nil, filterError("expected a value at position %d, got %s", valueTok.pos, valueTok)
+	}
+	if err := checkValueKind(fieldTok.text, kind, c.value); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// filterField validates a field name used in a filter, returning the kind of values it holds.
+func filterField(tok filterToken) (fieldKind, error) {
+	kind, err := lookupField(tok.text)
+	if err != nil {
+		return 0, filterError("%v at position %d", err, tok.pos)
+	}
+	return kind, nil
+}
+
+// lookupField validates a field path that can be used to query products,
+// returning the kind of values it holds.
+func lookupField(path string) (fieldKind, error) {
+	if kind, ok := topLevelFields[path]; ok {
+		return kind, nil
+	}
+	attributePath := strings.TrimPrefix(path, attributesField+".")
+	if attributePath == path {
+		return 0, fmt.Errorf("unknown field %q", path)
+	}
+	keys := strings.Split(attributePath, ".")
+	if len(keys) > maxAttributePathDepth {
+		return 0, fmt.Errorf("attribute path %q is deeper than %d keys", path, maxAttributePathDepth)
+	}
+	for _, key := range keys {
+		if !attributeKeyRegexp.MatchString(key) {
+			return 0, fmt.Errorf("invalid attribute key %q", key)
+		}
+	}
+	return anyField, nil
+}
+
+// checkValueKind checks that a literal can be compared with the given field.
+func checkValueKind(field string, kind fieldKind, value interface{}) error {
+	switch kind {
+	case stringField:
+		if _, ok := value.(string); !ok {
+			return filterError("field %q can only be compared with strings", field)
+		}
+	case numberField:
+		if _, ok := value.(float64); !ok {
+			return filterError("field %q can only be compared with numbers", field)
+		}
+	}
+	return nil
+}
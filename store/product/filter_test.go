@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		expectedOutput bson.M
+		expectedError  error
+	}{
+		{
+			name:  "empty filter",
+			input: "  ",
+		},
+		{
+			name:           "equality on attribute",
+			input:          `attributes.color = "red"`,
+			expectedOutput: bson.M{"attributes.color": bson.M{"$eq": "red"}},
+		},
+		{
+			name:  "conjunction",
+			input: `attributes.color = "red" AND price < 50 AND attributes.sizes:"M"`,
+			expectedOutput: bson.M{"$and": bson.A{
+				bson.M{"attributes.color": bson.M{"$eq": "red"}},
+				bson.M{"price": bson.M{"$lt": 50.0}},
+				bson.M{"attributes.sizes": bson.M{"$eq": "M"}},
+			}},
+		},
+		{
+			name:  "OR binds tighter than AND",
+			input: `name = 'a' AND price >= 1 OR price <= -1.5`,
+			expectedOutput: bson.M{"$and": bson.A{
+				bson.M{"name": bson.M{"$eq": "a"}},
+				bson.M{"$or": bson.A{
+					bson.M{"price": bson.M{"$gte": 1.0}},
+					bson.M{"price": bson.M{"$lte": -1.5}},
+				}},
+			}},
+		},
+		{
+			name:  "negation and parentheses",
+			input: `NOT (attributes.discontinued = true OR attributes.stock != 0)`,
+			expectedOutput: bson.M{"$nor": bson.A{
+				bson.M{"$or": bson.A{
+					bson.M{"attributes.discontinued": bson.M{"$eq": true}},
+					bson.M{"attributes.stock": bson.M{"$ne": 0.0}},
+				}},
+			}},
+		},
+		{
+			name:           "presence",
+			input:          `attributes.dimensions.height:*`,
+			expectedOutput: bson.M{"attributes.dimensions.height": bson.M{"$exists": true}},
+		},
+		{
+			name:           "null and escaped strings",
+			input:          `attributes.note = null OR attributes.quote > "say \"hi\""`,
+			expectedOutput: bson.M{"$or": bson.A{bson.M{"attributes.note": bson.M{"$eq": nil}}, bson.M{"attributes.quote": bson.M{"$gt": `say "hi"`}}}},
+		},
+		{
+			name:          "unknown field",
+			input:         `color = "red"`,
+			expectedError: errors.New(`invalid argument: filter: unknown field "color" at position 0`),
+		},
+		{
+			name:          "operator injection in attribute key",
+			input:         `attributes.$where = "1"`,
+			expectedError: errors.New(`invalid argument: filter: unexpected "$" at position 11`),
+		},
+		{
+			name:          "empty attribute key",
+			input:         `attributes..color = "1"`,
+			expectedError: errors.New(`invalid argument: filter: invalid attribute key "" at position 0`),
+		},
+		{
+			name:          "unquoted string",
+			input:         `name = red`,
+			expectedError: errors.New(`invalid argument: filter: expected a value at position 7, got "red"; strings must be quoted`),
+		},
+		{
+			name:          "wrong value type",
+			input:         `price = "cheap"`,
+			expectedError: errors.New(`invalid argument: filter: field "price" can only be compared with numbers`),
+		},
+		{
+			name:          "star without has operator",
+			input:         `attributes.color = *`,
+			expectedError: errors.New(`invalid argument: filter: "*" at position 19 can only be used with the ":" operator`),
+		},
+		{
+			name:          "missing operator",
+			input:         `name "a"`,
+			expectedError: errors.New(`invalid argument: filter: expected an operator after "name" at position 5, got "\"a\""`),
+		},
+		{
+			name:          "unbalanced parentheses",
+			input:         `(name = "a"`,
+			expectedError: errors.New(`invalid argument: filter: expected ")" at position 11, got end of filter`),
+		},
+		{
+			name:          "trailing tokens",
+			input:         `name = "a" )`,
+			expectedError: errors.New(`invalid argument: filter: unexpected ")" at position 11`),
+		},
+		{
+			name:          "unterminated string",
+			input:         `name = "a`,
+			expectedError: errors.New(`invalid argument: filter: unterminated string at position 7`),
+		},
+		{
+			name:          "too deep",
+			input:         strings.Repeat("(", maxFilterDepth+1) + `name = "a"` + strings.Repeat(")", maxFilterDepth+1),
+			expectedError: errors.New(`invalid argument: filter: nesting at position 32 exceeds the maximum depth of 32`),
+		},
+		{
+			name:          "too long",
+			input:         strings.Repeat(" ", maxFilterLength) + `name = "a"`,
+			expectedError: errors.New(`invalid argument: filter: must not be longer than 4096 characters`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := parseFilter(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.Equal(t, KindInvalidArgument, KindOf(err))
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				if tc.expectedOutput == nil {
+					require.Nil(t, node)
+					return
+				}
+				require.Equal(t, tc.expectedOutput, node.toBSON())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// IdempotencyKeyTTL is how long the request id of a create is remembered,
+// during which retries of the request return the product it created.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+const (
+	// maxRequestIdLength is the maximum length of the request id of a create.
+	maxRequestIdLength = 128
+)
+
+// productIdPattern is the format of the product ids chosen by clients, following AIP-122.
+var productIdPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// productIdViolations checks a product id chosen by a client, reporting it at the given field.
+func productIdViolations(field, productId string) []FieldViolation {
+	if productIdPattern.MatchString(productId) {
+		return nil
+	}
+	return []FieldViolation{{
+		Field:       field,
+		Description: "must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit",
+	}}
+}
+
+// For ease of unit testing.
+var timeNow = time.Now
+
+// idempotencyKey records the request id of a create, along with the product
+// it created, so that retries of the request return that product.
+type idempotencyKey struct {
+	RequestId string `bson:"_id"`
+	// Checksum of the create request, so that a request id is not reused for another request.
+	Checksum  int64           `bson:"checksum"`
+	Product   *models.Product `bson:"product"`
+	ExpiresAt time.Time       `bson:"expires_at"`
+	// Committed reports whether the product was inserted, for the backends
+	// that do not write the key and the product atomically.
+	Committed bool `bson:"committed"`
+}
+
+// newIdempotencyKey returns the key recording the given create request.
+// The product must not have been assigned its uuid yet.
+func newIdempotencyKey(requestId, productId string, newProduct *models.Product) (*idempotencyKey, error) {
+	// The attributes are encoded as JSON, which sorts map keys.
+	attributes, err := json.Marshal(newProduct.Attributes)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding attributes")
+	}
+	prices, err := json.Marshal(newProduct.Prices)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding prices")
+	}
+	return &idempotencyKey{
+		RequestId: requestId,
+		Checksum:  queryChecksum(productId, newProduct.Name, newProduct.Description, newProduct.Sku, newProduct.Slug, newProduct.ProductType, string(prices), string(attributes)),
+		ExpiresAt: timeNow().Add(IdempotencyKeyTTL),
+	}, nil
+}
+
+// expired reports whether the key is no longer valid.
+func (k *idempotencyKey) expired() bool {
+	return !timeNow().Before(k.ExpiresAt)
+}
+
+// replay returns the product created by the request the key records,
+// failing if the retried request is not the same.
+func (k *idempotencyKey) replay(retried *idempotencyKey) (*models.Product, error) {
+	if k.Checksum != retried.Checksum {
+		return nil, invalidArgumentError(FieldViolation{Field: "request_id", Description: "was used for a different request"})
+	}
+	return cloneProduct(k.Product), nil
+}
+
+// prepareCreate validates a create request and assigns the uuid and first revision
+// of the new product. When a request id is given, it returns the key recording the request.
+func prepareCreate(newProduct *models.Product, productId, requestId string, uuidProvider func() string) (*idempotencyKey, error) {
+	var violations []FieldViolation
+	if productId != "" {
+		violations = append(violations, productIdViolations("product_id", productId)...)
+	}
+	if len(requestId) > maxRequestIdLength {
+		violations = append(violations, FieldViolation{Field: "request_id", Description: "must not be longer than 128 characters"})
+	}
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations...)
+	}
+	if err := validateProduct(newProduct); err != nil {
+		return nil, err
+	}
+	newProduct.Price = legacyPrice(newProduct.Prices)
+	var key *idempotencyKey
+	if requestId != "" {
+		var err error
+		if key, err = newIdempotencyKey(requestId, productId, newProduct); err != nil {
+			return nil, err
+		}
+	}
+	newProduct.Uuid = productId
+	if newProduct.Uuid == "" {
+		newProduct.Uuid = uuidProvider()
+	}
+	newProduct.Revision = 1
+	if key != nil {
+		key.Product = cloneProduct(newProduct)
+	}
+	return key, nil
+}
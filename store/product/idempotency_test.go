@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	newProduct := func() *models.Product {
+		return &models.Product{Name: "name", Prices: usd("1"), Attributes: map[string]interface{}{"a": 1.0, "b": "b", "c": []interface{}{"c"}}}
+	}
+	key, err := newIdempotencyKey("request", "", newProduct())
+	require.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		same, err := newIdempotencyKey("request", "", newProduct())
+		require.Nil(t, err)
+		require.Equal(t, key.Checksum, same.Checksum)
+	}
+	withProductId, err := newIdempotencyKey("request", "id", newProduct())
+	require.Nil(t, err)
+	require.NotEqual(t, key.Checksum, withProductId.Checksum)
+	other := newProduct()
+	other.Prices = usd("2")
+	withOtherPrice, err := newIdempotencyKey("request", "", other)
+	require.Nil(t, err)
+	require.NotEqual(t, key.Checksum, withOtherPrice.Checksum)
+}
+
+func TestProductIdPattern(t *testing.T) {
+	for _, id := range []string{"a", "product-1", "0", "a123456789012345678901234567890123456789012345678901234567890bc"} {
+		require.True(t, productIdPattern.MatchString(id), id)
+	}
+	for _, id := range []string{"", "-a", "a-", "A", "a_b", "a b", "a1234567890123456789012345678901234567890123456789012345678901bcd"} {
+		require.False(t, productIdPattern.MatchString(id), id)
+	}
+}
+
+// testRepositoryIdempotency checks the client chosen uuids and request ids of Create.
+func testRepositoryIdempotency(t *testing.T, repo ProductRepository) {
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	ctx := context.TODO()
+
+	created, err := repo.Create(ctx, &models.Product{Name: "a"}, "product-a", "")
+	require.Nil(t, err)
+	require.Equal(t, "product-a", created.Uuid)
+	_, err = repo.Create(ctx, &models.Product{Name: "a"}, "product-a", "")
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+
+	// Retries return the product first created, even once changed.
+	created, err = repo.Create(ctx, &models.Product{Name: "b"}, "", "request-b")
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: created.Uuid, Name: "b2"}, nil, 0)
+	require.Nil(t, err)
+	retried, err := repo.Create(ctx, &models.Product{Name: "b"}, "", "request-b")
+	require.Nil(t, err)
+	require.Equal(t, created, retried)
+	_, err = repo.Create(ctx, &models.Product{Name: "c"}, "", "request-b")
+	require.Equal(t, "invalid argument: request_id: was used for a different request", err.Error())
+
+	// A failed create does not record its request id.
+	_, err = repo.Create(ctx, &models.Product{Name: "a"}, "product-a", "request-a")
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+	_, err = repo.Create(ctx, &models.Product{Name: "a"}, "product-a2", "request-a")
+	require.Nil(t, err)
+
+	// Expired request ids create new products.
+	now = now.Add(IdempotencyKeyTTL)
+	again, err := repo.Create(ctx, &models.Product{Name: "b"}, "", "request-b")
+	require.Nil(t, err)
+	require.NotEqual(t, created.Uuid, again.Uuid)
+
+	products, _, err := repo.List(ctx, &productcatalog.ListProductsRequest{})
+	require.Nil(t, err)
+	require.Len(t, products, 4)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A product type may give a JSON Schema document instead of attribute schemas,
+// which the attributes of its products, as a JSON object, must then follow.
+// Violations name the offending value by its JSON pointer within the attributes,
+// as in attributes/dimensions/width.
+
+const (
+	// jsonSchemaField is the field of a product type holding its JSON Schema document.
+	jsonSchemaField = "json_schema"
+
+	// jsonSchemaURL is the location compiled documents are given,
+	// which only shows up in the locations of their keywords.
+	jsonSchemaURL = "urn:product-type"
+
+	// maxCompiledSchemas bounds the number of compiled documents kept.
+	maxCompiledSchemas = 256
+)
+
+var (
+	compiledSchemasMu sync.Mutex
+	compiledSchemas   = make(map[string]*jsonschema.Schema)
+)
+
+// compileJSONSchema compiles a JSON Schema document, reusing the result of
+// earlier compilations of the same document. Documents are read as draft 2020-12
+// unless they declare otherwise, and may not load other documents.
+func compileJSONSchema(doc string) (*jsonschema.Schema, error) {
+	compiledSchemasMu.Lock()
+	defer compiledSchemasMu.Unlock()
+	if schema, ok := compiledSchemas[doc]; ok {
+		return schema, nil
+	}
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("loading %s is not allowed", s)
+	}
+	if err := c.AddResource(jsonSchemaURL, strings.NewReader(doc)); err != nil {
+		return nil, errors.Wrap(err, "parsing JSON Schema")
+	}
+	schema, err := c.Compile(jsonSchemaURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling JSON Schema")
+	}
+	if len(compiledSchemas) >= maxCompiledSchemas {
+		compiledSchemas = make(map[string]*jsonschema.Schema)
+	}
+	compiledSchemas[doc] = schema
+	return schema, nil
+}
+
+// validateJSONSchema checks the JSON Schema document of a product type.
+func validateJSONSchema(doc string) []FieldViolation {
+	if _, err := compileJSONSchema(doc); err != nil {
+		return []FieldViolation{{Field: jsonSchemaField, Description: "must be a valid JSON Schema: " + errors.Cause(err).Error()}}
+	}
+	return nil
+}
+
+// validateJSONAttributes checks the attributes of a product against a JSON Schema document,
+// returning a violation for each value that does not follow it, ordered by field.
+func validateJSONAttributes(doc string, attributes map[string]interface{}) []FieldViolation {
+	schema, err := compileJSONSchema(doc)
+	if err != nil {
+		// The document was checked when the type was written.
+		return []FieldViolation{{Field: jsonSchemaField, Description: "must be a valid JSON Schema"}}
+	}
+	err = schema.Validate(toJSONValue(attributes))
+	var ve *jsonschema.ValidationError
+	switch {
+	case err == nil:
+		return nil
+	case !errors.As(err, &ve):
+		return []FieldViolation{{Field: attributesField, Description: "must be JSON values"}}
+	}
+	var violations []FieldViolation
+	var collect func(ve *jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			violations = append(violations, FieldViolation{Field: attributesField + ve.InstanceLocation, Description: ve.Message})
+		}
+		for _, cause := range ve.Causes {
+			collect(cause)
+		}
+	}
+	collect(ve)
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Field != violations[j].Field {
+			return violations[i].Field < violations[j].Field
+		}
+		return violations[i].Description < violations[j].Description
+	})
+	return violations
+}
+
+// toJSONValue converts the values decoded from BSON, which JSON Schema validation
+// does not support, into JSON values: lists and documents become plain slices and
+// maps, decimals become numbers, and dates, ObjectIDs and binary data become strings,
+// as in their canonical extended JSON.
+func toJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case primitive.Decimal128:
+		if n := json.Number(v.String()); isJSONNumber(n) {
+			return n
+		}
+		return v.String()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.Binary:
+		return base64.StdEncoding.EncodeToString(v.Data)
+	case primitive.A:
+		return toJSONValue([]interface{}(v))
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = toJSONValue(e)
+		}
+		return list
+	case primitive.D:
+		return toJSONValue(map[string]interface{}(v.Map()))
+	case primitive.M:
+		return toJSONValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			doc[k] = toJSONValue(e)
+		}
+		return doc
+	}
+	return v
+}
+
+// isJSONNumber reports whether n is a number in JSON syntax,
+// which the decimals NaN and Infinity are not.
+func isJSONNumber(n json.Number) bool {
+	_, err := n.Float64()
+	return err == nil && !strings.ContainsAny(string(n), "nNiI")
+}
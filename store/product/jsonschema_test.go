@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const laptopSchema = `{
+	"type": "object",
+	"properties": {
+		"ram": {"type": "integer", "minimum": 4},
+		"price": {"type": "number", "exclusiveMinimum": 0},
+		"released": {"type": "string", "format": "date-time"},
+		"ports": {"type": "array", "items": {"enum": ["usb-c", "hdmi"]}},
+		"screen": {
+			"type": "object",
+			"properties": {"size": {"type": "number"}},
+			"required": ["size"]
+		}
+	},
+	"required": ["ram"],
+	"additionalProperties": false
+}`
+
+func TestValidateJSONSchema(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         *models.ProductType
+		expectedError error
+	}{
+		{
+			name:  "happy path",
+			input: &models.ProductType{Name: "laptop", JsonSchema: laptopSchema},
+		},
+		{
+			name: "with attributes",
+			input: &models.ProductType{
+				Name:       "laptop",
+				Attributes: map[string]models.AttributeSchema{"ram": {Type: models.AttributeInteger}},
+				JsonSchema: laptopSchema,
+			},
+			expectedError: errors.New("invalid argument: json_schema: must not be set along with attributes"),
+		},
+		{
+			name:          "invalid JSON",
+			input:         &models.ProductType{Name: "laptop", JsonSchema: `{"type": `},
+			expectedError: errors.New("invalid argument: json_schema: must be a valid JSON Schema: jsonschema: invalid json urn:product-type: unexpected EOF"),
+		},
+		{
+			name:  "invalid schema",
+			input: &models.ProductType{Name: "laptop", JsonSchema: `{"type": "thing"}`},
+			expectedError: errors.New("invalid argument: json_schema: must be a valid JSON Schema: " +
+				"jsonschema urn:product-type compilation failed: " +
+				"'/type' does not validate with https://json-schema.org/draft/2020-12/schema#/allOf/3/$ref/properties/type/anyOf/0/$ref/enum: " +
+				`value must be one of "array", "boolean", "integer", "null", "number", "object", "string"`),
+		},
+		{
+			name:  "remote reference",
+			input: &models.ProductType{Name: "laptop", JsonSchema: `{"$ref": "file:///etc/passwd"}`},
+			expectedError: errors.New("invalid argument: json_schema: must be a valid JSON Schema: " +
+				"jsonschema urn:product-type compilation failed: loading file:///etc/passwd is not allowed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProductType(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateJSONAttributes(t *testing.T) {
+	decimal, err := primitive.ParseDecimal128("1999.99")
+	require.Nil(t, err)
+	zero, err := primitive.ParseDecimal128("0")
+	require.Nil(t, err)
+	laptop := &models.ProductType{Name: "laptop", JsonSchema: laptopSchema}
+	testCases := []struct {
+		name               string
+		input              map[string]interface{}
+		expectedViolations []FieldViolation
+	}{
+		{
+			name: "happy path",
+			input: map[string]interface{}{
+				"ram":    16.0,
+				"ports":  []interface{}{"usb-c", "hdmi"},
+				"screen": map[string]interface{}{"size": 13.3},
+			},
+		},
+		{
+			name: "values decoded from BSON",
+			input: map[string]interface{}{
+				"ram":    int64(16),
+				"ports":  primitive.A{"usb-c"},
+				"screen": primitive.D{{Key: "size", Value: 13.3}},
+			},
+		},
+		{
+			name: "values BSON has types for",
+			input: map[string]interface{}{
+				"ram":      int64(16),
+				"price":    decimal,
+				"released": primitive.DateTime(1690000000123),
+			},
+		},
+		{
+			name:  "missing required attribute",
+			input: nil,
+			expectedViolations: []FieldViolation{
+				{Field: "attributes", Description: "missing properties: 'ram'"},
+			},
+		},
+		{
+			name: "invalid values",
+			input: map[string]interface{}{
+				"ram":    2.0,
+				"price":  zero,
+				"ports":  []interface{}{"usb-c", "vga"},
+				"screen": map[string]interface{}{},
+				"color":  "silver",
+			},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes", Description: "additionalProperties 'color' not allowed"},
+				{Field: "attributes/ports/1", Description: `value must be one of "usb-c", "hdmi"`},
+				{Field: "attributes/price", Description: "must be > 0 but found 0"},
+				{Field: "attributes/ram", Description: "must be >= 4 but found 2"},
+				{Field: "attributes/screen", Description: "missing properties: 'size'"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedViolations, validateAttributes(laptop, tc.input))
+		})
+	}
+}
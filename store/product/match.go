@@ -0,0 +1,413 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Filters and orderings are evaluated in Go by the repositories that do not
+// translate them into database queries. The evaluation mirrors how MongoDB
+// evaluates the query returned by toBSON:
+//
+//   - a path that reaches a list matches when the list itself,
+//     or any of its elements, satisfies the restriction;
+//   - paths traverse lists of documents, as in attributes.variants.color;
+//   - = null matches products where the field is null or missing;
+//   - <, <=, > and >= only match values of the same type as the literal,
+//     where all numbers are of the same type.
+//
+// Values are ordered as in MongoDB: null and missing values first,
+// then numbers, strings, documents, lists, binary data, ObjectIDs,
+// booleans and dates. When sorting,
+// a list is ordered by its smallest element in ascending order
+// and by its largest element in descending order.
+
+// typeRank is the position of the type of a value in the ordering of values.
+type typeRank int
+
+const (
+	nullRank typeRank = iota
+	numberRank
+	stringRank
+	documentRank
+	listRank
+	binaryRank
+	objectIdRank
+	boolRank
+	dateRank
+	otherRank
+)
+
+func (c *comparison) matches(p *models.Product) bool {
+	values := pathValues(p, c.field)
+	if c.presence {
+		return len(values) > 0
+	}
+	switch c.operator {
+	case "=", ":":
+		return matchesEqual(values, c.value)
+	case "!=":
+		return !matchesEqual(values, c.value)
+	}
+	if c.value == nil {
+		// Only null is comparable with null, and it is only equal to itself.
+		if c.operator == "<=" || c.operator == ">=" {
+			return matchesEqual(values, nil)
+		}
+		return false
+	}
+	for _, v := range candidates(values) {
+		if rankOf(v) != rankOf(c.value) {
+			continue
+		}
+		cmp := compareValues(v, c.value)
+		switch c.operator {
+		case "<":
+			if cmp < 0 {
+				return true
+			}
+		case "<=":
+			if cmp <= 0 {
+				return true
+			}
+		case ">":
+			if cmp > 0 {
+				return true
+			}
+		case ">=":
+			if cmp >= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *conjunction) matches(p *models.Product) bool {
+	for _, child := range c.children {
+		if !child.matches(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *disjunction) matches(p *models.Product) bool {
+	for _, child := range d.children {
+		if child.matches(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *negation) matches(p *models.Product) bool {
+	return !n.child.matches(p)
+}
+
+// matchesEqual reports whether any of the values reached by a path is equal to v.
+func matchesEqual(values []interface{}, v interface{}) bool {
+	if v == nil && len(values) == 0 {
+		return true
+	}
+	for _, c := range candidates(values) {
+		if rankOf(c) == rankOf(v) && compareValues(c, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns the values a restriction is checked against:
+// the values themselves and the elements of those that are lists.
+func candidates(values []interface{}) []interface{} {
+	var all []interface{}
+	for _, v := range values {
+		all = append(all, v)
+		if list, ok := asList(v); ok {
+			all = append(all, list...)
+		}
+	}
+	return all
+}
+
+// pathValues returns the values reached by a field path in a product.
+// It returns no value when the path is missing.
+func pathValues(p *models.Product, path string) []interface{} {
+	if _, ok := topLevelFields[path]; ok {
+		return []interface{}{fieldValue(p, path)}
+	}
+	if p.Attributes == nil {
+		return nil
+	}
+	keys := strings.Split(strings.TrimPrefix(path, attributesField+"."), ".")
+	return traverse(p.Attributes, keys)
+}
+
+// traverse follows the keys from the given value, descending into lists of documents.
+func traverse(v interface{}, keys []string) []interface{} {
+	if len(keys) == 0 {
+		return []interface{}{v}
+	}
+	if doc, ok := asDocument(v); ok {
+		child, ok := doc[keys[0]]
+		if !ok {
+			return nil
+		}
+		return traverse(child, keys[1:])
+	}
+	if list, ok := asList(v); ok {
+		var values []interface{}
+		for _, e := range list {
+			if _, ok := asDocument(e); ok {
+				values = append(values, traverse(e, keys)...)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// asDocument returns the fields of a value holding a document.
+func asDocument(v interface{}) (map[string]interface{}, bool) {
+	switch doc := v.(type) {
+	case map[string]interface{}:
+		return doc, true
+	case primitive.M:
+		return doc, true
+	case primitive.D:
+		return doc.Map(), true
+	}
+	return nil, false
+}
+
+// asList returns the elements of a value holding a list.
+func asList(v interface{}) ([]interface{}, bool) {
+	switch list := v.(type) {
+	case []interface{}:
+		return list, true
+	case primitive.A:
+		return list, true
+	}
+	return nil, false
+}
+
+// asNumber returns the value of a number as a float64.
+// Decimals are rounded to the nearest float64.
+func asNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(n.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// asBinary returns the value of a value holding binary data.
+func asBinary(v interface{}) (primitive.Binary, bool) {
+	switch b := v.(type) {
+	case primitive.Binary:
+		return b, true
+	case []byte:
+		return primitive.Binary{Data: b}, true
+	}
+	return primitive.Binary{}, false
+}
+
+// asDate returns the value of a value holding a date.
+func asDate(v interface{}) (primitive.DateTime, bool) {
+	switch d := v.(type) {
+	case primitive.DateTime:
+		return d, true
+	case time.Time:
+		return primitive.NewDateTimeFromTime(d), true
+	}
+	return 0, false
+}
+
+// rankOf returns the type rank of a value.
+func rankOf(v interface{}) typeRank {
+	if v == nil {
+		return nullRank
+	}
+	if _, ok := asNumber(v); ok {
+		return numberRank
+	}
+	if _, ok := v.(string); ok {
+		return stringRank
+	}
+	if _, ok := asDocument(v); ok {
+		return documentRank
+	}
+	if _, ok := asList(v); ok {
+		return listRank
+	}
+	if _, ok := asBinary(v); ok {
+		return binaryRank
+	}
+	if _, ok := v.(primitive.ObjectID); ok {
+		return objectIdRank
+	}
+	if _, ok := v.(bool); ok {
+		return boolRank
+	}
+	if _, ok := asDate(v); ok {
+		return dateRank
+	}
+	return otherRank
+}
+
+// compareValues returns -1, 0 or 1 as a is ordered before, equal to or after b.
+func compareValues(a, b interface{}) int {
+	ra, rb := rankOf(a), rankOf(b)
+	if ra != rb {
+		return compareInts(int(ra), int(rb))
+	}
+	switch ra {
+	case numberRank:
+		x, _ := asNumber(a)
+		y, _ := asNumber(b)
+		switch {
+		case x < y || (math.IsNaN(x) && !math.IsNaN(y)):
+			return -1
+		case x > y || (!math.IsNaN(x) && math.IsNaN(y)):
+			return 1
+		}
+		return 0
+	case stringRank:
+		return strings.Compare(a.(string), b.(string))
+	case binaryRank:
+		// As in MongoDB, shorter binary data comes first, then the subtype is compared.
+		x, _ := asBinary(a)
+		y, _ := asBinary(b)
+		if c := compareInts(len(x.Data), len(y.Data)); c != 0 {
+			return c
+		}
+		if c := compareInts(int(x.Subtype), int(y.Subtype)); c != 0 {
+			return c
+		}
+		return bytes.Compare(x.Data, y.Data)
+	case objectIdRank:
+		x, y := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case dateRank:
+		x, _ := asDate(a)
+		y, _ := asDate(b)
+		return compareInts64(int64(x), int64(y))
+	case boolRank:
+		x, y := a.(bool), b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case documentRank:
+		x, _ := asDocument(a)
+		y, _ := asDocument(b)
+		return compareDocuments(x, y)
+	case listRank:
+		x, _ := asList(a)
+		y, _ := asList(b)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareValues(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(x), len(y))
+	}
+	return 0
+}
+
+// compareDocuments compares documents field by field, in key order.
+func compareDocuments(a, b map[string]interface{}) int {
+	ka, kb := sortedKeys(a), sortedKeys(b)
+	for i := 0; i < len(ka) && i < len(kb); i++ {
+		if c := strings.Compare(ka[i], kb[i]); c != 0 {
+			return c
+		}
+		if c := compareValues(a[ka[i]], b[kb[i]]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(ka), len(kb))
+}
+
+func sortedKeys(doc map[string]interface{}) []string {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareInts(a, b int) int {
+	return compareInts64(int64(a), int64(b))
+}
+
+func compareInts64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// sortValue returns the value a product is ordered by for a key:
+// the smallest or largest element of a list, depending on the direction.
+func sortValue(v interface{}, descending bool) interface{} {
+	list, ok := asList(v)
+	if !ok {
+		return v
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	selected := list[0]
+	for _, e := range list[1:] {
+		c := compareValues(e, selected)
+		if (descending && c > 0) || (!descending && c < 0) {
+			selected = e
+		}
+	}
+	return selected
+}
+
+// compareSortValues compares the values of the sort keys of two products,
+// honoring the direction of each key.
+func compareSortValues(keys []sortKey, a, b []interface{}) int {
+	for i, k := range keys {
+		c := compareValues(sortValue(a[i], k.descending), sortValue(b[i], k.descending))
+		if k.descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
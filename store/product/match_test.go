@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatches(t *testing.T) {
+	p := &models.Product{
+		Uuid:  "uuid",
+		Name:  "shirt",
+		Price: 20,
+		Attributes: map[string]interface{}{
+			"color":        "blue",
+			"sizes":        []interface{}{"S", "M"},
+			"stock":        12.0,
+			"discontinued": false,
+			"notes":        nil,
+			"dimensions":   map[string]interface{}{"height": 3.0},
+			"variants": []interface{}{
+				map[string]interface{}{"color": "red"},
+				primitive.D{{Key: "color", Value: "green"}},
+			},
+		},
+	}
+	testCases := []struct {
+		filter         string
+		expectedOutput bool
+	}{
+		{filter: `name = "shirt"`, expectedOutput: true},
+		{filter: `name != "shirt"`, expectedOutput: false},
+		{filter: `price >= 20 AND price < 21`, expectedOutput: true},
+		{filter: `attributes.color = "blue"`, expectedOutput: true},
+		{filter: `attributes.sizes:"M"`, expectedOutput: true},
+		{filter: `attributes.sizes = "L"`, expectedOutput: false},
+		{filter: `attributes.sizes > "R"`, expectedOutput: true},
+		{filter: `attributes.stock > 10`, expectedOutput: true},
+		{filter: `attributes.stock > "10"`, expectedOutput: false},
+		{filter: `attributes.discontinued = false`, expectedOutput: true},
+		{filter: `attributes.dimensions.height <= 3`, expectedOutput: true},
+		{filter: `attributes.variants.color = "green"`, expectedOutput: true},
+		{filter: `attributes.variants.color = "blue"`, expectedOutput: false},
+		{filter: `attributes.notes = null`, expectedOutput: true},
+		{filter: `attributes.missing = null`, expectedOutput: true},
+		{filter: `attributes.missing != null`, expectedOutput: false},
+		{filter: `attributes.color > null`, expectedOutput: false},
+		{filter: `attributes.notes >= null`, expectedOutput: true},
+		{filter: `attributes.notes:*`, expectedOutput: true},
+		{filter: `attributes.missing:*`, expectedOutput: false},
+		{filter: `attributes.missing != "a"`, expectedOutput: true},
+		{filter: `attributes.color = "red" OR attributes.stock = 12`, expectedOutput: true},
+		{filter: `NOT attributes.color = "blue"`, expectedOutput: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			node, err := parseFilter(tc.filter)
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedOutput, node.matches(p))
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	decimal, err := primitive.ParseDecimal128("1.5")
+	require.Nil(t, err)
+	ordered := []interface{}{
+		nil,
+		int32(-1),
+		0.5,
+		decimal,
+		int64(2),
+		"a",
+		"b",
+		map[string]interface{}{"a": 1.0},
+		primitive.D{{Key: "b", Value: 1.0}},
+		[]interface{}{1.0},
+		primitive.A{1.0, 2.0},
+		[]byte("b"),
+		primitive.Binary{Subtype: 0x80, Data: []byte("a")},
+		primitive.Binary{Data: []byte("aa")},
+		primitive.ObjectID{1},
+		primitive.ObjectID{2},
+		false,
+		true,
+		primitive.DateTime(-1),
+		time.UnixMilli(1),
+	}
+	for i := range ordered {
+		require.Equal(t, 0, compareValues(ordered[i], ordered[i]))
+		for j := i + 1; j < len(ordered); j++ {
+			require.Equal(t, -1, compareValues(ordered[i], ordered[j]), "%v < %v", ordered[i], ordered[j])
+			require.Equal(t, 1, compareValues(ordered[j], ordered[i]), "%v > %v", ordered[j], ordered[i])
+		}
+	}
+	require.Equal(t, 0, compareValues(float32(1.5), 1.5))
+}
+
+func TestSortValue(t *testing.T) {
+	list := []interface{}{3.0, 1.0, 2.0}
+	require.Equal(t, 1.0, sortValue(list, false))
+	require.Equal(t, 3.0, sortValue(list, true))
+	require.Nil(t, sortValue([]interface{}{}, false))
+	require.Equal(t, "a", sortValue("a", true))
+}
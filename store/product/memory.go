@@ -0,0 +1,561 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRepository is a ProductRepository that keeps products in memory.
+// It supports the same filters, orderings and pagination as MongoRepository,
+// and is meant for tests and local development.
+// It is safe for concurrent use.
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	products     productSet
+	keys         map[string]*idempotencyKey // Idempotency keys by request id.
+	keyQueue     []*idempotencyKey          // Idempotency keys in the order they expire.
+	uuidProvider func() string
+	changes      poller
+}
+
+var _ ProductRepository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty in-memory repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		products:     newProductSet(),
+		keys:         make(map[string]*idempotencyKey),
+		uuidProvider: uuid.NewString,
+	}
+}
+
+// productSet holds the products of a MemoryRepository by uuid,
+// along with the uuids of the products by their natural keys,
+// and the product types by name.
+// Stored products are never modified in place: writes replace them
+// with updated copies, so that readers can use them without holding the lock.
+type productSet struct {
+	byUuid map[string]*models.Product
+	byKey  map[string]map[string]string // Uuids by value, for each unique field.
+	types  map[string]*models.ProductType
+}
+
+// newProductSet returns an empty productSet.
+func newProductSet() productSet {
+	s := productSet{
+		byUuid: make(map[string]*models.Product),
+		byKey:  make(map[string]map[string]string),
+		types:  make(map[string]*models.ProductType),
+	}
+	for _, field := range uniqueFields {
+		s.byKey[field] = make(map[string]string)
+	}
+	return s
+}
+
+// clone returns a copy of the set that can be written without changing it.
+func (s productSet) clone() productSet {
+	c := newProductSet()
+	for uuid, p := range s.byUuid {
+		c.byUuid[uuid] = p
+	}
+	for field, uuids := range s.byKey {
+		for value, uuid := range uuids {
+			c.byKey[field][value] = uuid
+		}
+	}
+	for name, t := range s.types {
+		c.types[name] = t
+	}
+	return c
+}
+
+// checkUnique fails if another product has the sku or slug of the given one.
+func (s productSet) checkUnique(p *models.Product) error {
+	for _, field := range uniqueFields {
+		value := uniqueValue(p, field)
+		if uuid, ok := s.byKey[field][value]; ok && value != "" && uuid != p.Uuid {
+			return uniqueValueTakenError(p.Uuid, field, value)
+		}
+	}
+	return nil
+}
+
+// productType returns the product type with the given name, or nil if there is none.
+func (s productSet) productType(name string) (*models.ProductType, error) {
+	return s.types[name], nil
+}
+
+// put stores a product, replacing the natural keys of the product it replaces, if any.
+func (s productSet) put(p *models.Product) {
+	s.drop(p.Uuid)
+	s.byUuid[p.Uuid] = p
+	for _, field := range uniqueFields {
+		if value := uniqueValue(p, field); value != "" {
+			s.byKey[field][value] = p.Uuid
+		}
+	}
+}
+
+// drop removes the product with the given uuid, if any.
+func (s productSet) drop(uuid string) {
+	p, ok := s.byUuid[uuid]
+	if !ok {
+		return
+	}
+	for _, field := range uniqueFields {
+		delete(s.byKey[field], uniqueValue(p, field))
+	}
+	delete(s.byUuid, uuid)
+}
+
+// insert stores a new product.
+func (s productSet) insert(newProduct *models.Product) error {
+	if _, ok := s.byUuid[newProduct.Uuid]; ok {
+		return alreadyExistsError(newProduct.Uuid)
+	}
+	if err := s.checkUnique(newProduct); err != nil {
+		return err
+	}
+	if err := checkProductType(newProduct, s.productType); err != nil {
+		return err
+	}
+	s.put(cloneProduct(newProduct))
+	return nil
+}
+
+// update writes the fields of a product named by the normalized update mask paths.
+func (s productSet) update(productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	current, ok := s.byUuid[productToUpdate.Uuid]
+	if !ok {
+		return nil, notFoundError(productToUpdate.Uuid)
+	}
+	if expectedRevision != 0 && current.Revision != expectedRevision {
+		return nil, conflictError(current.Uuid, expectedRevision, current.Revision)
+	}
+	if len(paths) == 0 {
+		return cloneProduct(current), nil
+	}
+	updated := cloneProduct(current)
+	applyUpdate(updated, productToUpdate, paths)
+	if err := s.checkUnique(updated); err != nil {
+		return nil, err
+	}
+	if err := checkUpdatedAttributes(updated, paths, s.productType); err != nil {
+		return nil, err
+	}
+	s.put(updated)
+	return cloneProduct(updated), nil
+}
+
+// remove deletes a product, returning it.
+func (s productSet) remove(uuid string, expectedRevision int64) (*models.Product, error) {
+	current, ok := s.byUuid[uuid]
+	if !ok {
+		return nil, notFoundError(uuid)
+	}
+	if expectedRevision != 0 && current.Revision != expectedRevision {
+		return nil, conflictError(current.Uuid, expectedRevision, current.Revision)
+	}
+	s.drop(uuid)
+	return current, nil
+}
+
+// Create stores a new product.
+// It behaves like MongoRepository.Create.
+func (r *MemoryRepository) Create(ctx context.Context, newProduct *models.Product, productId, requestId string) (*models.Product, error) {
+	key, err := prepareCreate(newProduct, productId, requestId, r.uuidProvider)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeExpiredKeys()
+	if key != nil {
+		if stored, ok := r.keys[key.RequestId]; ok {
+			return stored.replay(key)
+		}
+	}
+	if err := r.products.insert(newProduct); err != nil {
+		return nil, err
+	}
+	if key != nil {
+		r.keys[key.RequestId] = key
+		r.keyQueue = append(r.keyQueue, key)
+	}
+	return newProduct, nil
+}
+
+// removeExpiredKeys forgets the idempotency keys that expired.
+// As every key lives for the same time, the queue is ordered by expiry.
+func (r *MemoryRepository) removeExpiredKeys() {
+	n := 0
+	for n < len(r.keyQueue) && r.keyQueue[n].expired() {
+		delete(r.keys, r.keyQueue[n].RequestId)
+		r.keyQueue[n] = nil
+		n++
+	}
+	r.keyQueue = r.keyQueue[n:]
+}
+
+// Get retrieves a product by uuid, sku or slug.
+func (r *MemoryRepository) Get(ctx context.Context, req *productcatalog.GetProductRequest) (*models.Product, error) {
+	field, value, err := productKey(req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	uuid := value
+	if field != uuidField {
+		uuid = r.products.byKey[field][value]
+	}
+	p, ok := r.products.byUuid[uuid]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, keyNotFoundError(field, value)
+	}
+	return cloneProduct(p), nil
+}
+
+// Update writes the fields of a product named by the update mask paths.
+// It behaves like MongoRepository.Update.
+func (r *MemoryRepository) Update(ctx context.Context, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	paths, err := prepareUpdate(productToUpdate, paths)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.products.update(productToUpdate, paths, expectedRevision)
+}
+
+// Delete deletes a product by uuid.
+// It behaves like MongoRepository.Delete.
+func (r *MemoryRepository) Delete(ctx context.Context, req *productcatalog.DeleteProductRequest) (*models.Product, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.products.remove(req.GetUuid(), req.GetExpectedRevision())
+}
+
+// BatchCreate stores new products.
+// It behaves like MongoRepository.BatchCreate.
+func (r *MemoryRepository) BatchCreate(ctx context.Context, newProducts []*models.Product, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(newProducts)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(newProducts))
+	for i, p := range newProducts {
+		if err := validateProduct(p); err != nil {
+			results[i].Err = err
+			continue
+		}
+		p.Price = legacyPrice(p.Prices)
+		p.Uuid = r.uuidProvider()
+		p.Revision = 1
+	}
+	return r.runBatch("products", results, allOrNothing, func(s productSet, i int) (*models.Product, error) {
+		if err := s.insert(newProducts[i]); err != nil {
+			return nil, err
+		}
+		return newProducts[i], nil
+	})
+}
+
+// BatchUpdate updates products.
+// It behaves like MongoRepository.BatchUpdate.
+func (r *MemoryRepository) BatchUpdate(ctx context.Context, updates []ProductUpdate, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(updates)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(updates))
+	paths := make([][]string, len(updates))
+	uuids := make([]string, len(updates))
+	for i, u := range updates {
+		paths[i], results[i].Err = prepareUpdate(u.Product, u.Paths)
+		uuids[i] = u.Product.Uuid
+	}
+	rejectRepeatedUuids(results, uuids)
+	return r.runBatch("requests", results, allOrNothing, func(s productSet, i int) (*models.Product, error) {
+		return s.update(updates[i].Product, paths[i], updates[i].ExpectedRevision)
+	})
+}
+
+// BatchDelete deletes products.
+// It behaves like MongoRepository.BatchDelete.
+func (r *MemoryRepository) BatchDelete(ctx context.Context, reqs []*productcatalog.DeleteProductRequest, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(reqs))
+	uuids := make([]string, len(reqs))
+	for i, req := range reqs {
+		results[i].Err = validateUuid(req.GetUuid())
+		uuids[i] = req.GetUuid()
+	}
+	rejectRepeatedUuids(results, uuids)
+	return r.runBatch("requests", results, allOrNothing, func(s productSet, i int) (*models.Product, error) {
+		return s.remove(reqs[i].GetUuid(), reqs[i].GetExpectedRevision())
+	})
+}
+
+// BatchUpsert creates or replaces products.
+// It behaves like MongoRepository.BatchUpsert.
+func (r *MemoryRepository) BatchUpsert(ctx context.Context, products []*models.Product) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(products)); err != nil {
+		return nil, err
+	}
+	results, err := r.runBatch("products", prepareUpsert(products, r.uuidProvider), false, func(s productSet, i int) (*models.Product, error) {
+		p := products[i]
+		if _, ok := s.byUuid[p.Uuid]; ok {
+			return s.update(p, updatableFields, 0)
+		}
+		if err := checkCreatedUuid(p.Uuid); err != nil {
+			return nil, err
+		}
+		p.Revision = 1
+		if err := s.insert(p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	markCreated(results)
+	return results, nil
+}
+
+// runBatch applies, under the write lock, the items of a batch that passed validation.
+// All or nothing batches are applied to a copy of the products,
+// which replaces them only when every item succeeds.
+func (r *MemoryRepository) runBatch(field string, results []BatchResult, allOrNothing bool, apply func(s productSet, i int) (*models.Product, error)) ([]BatchResult, error) {
+	if allOrNothing {
+		if err := batchError(field, results); err != nil {
+			return nil, err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.products
+	if allOrNothing {
+		s = r.products.clone()
+	}
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].Product, results[i].Err = apply(s, i)
+		if allOrNothing && results[i].Err != nil {
+			return nil, batchItemError(field, i, results[i].Err)
+		}
+	}
+	r.products = s
+	return results, nil
+}
+
+// List lists one page of the products matching the request filter.
+// It behaves like MongoRepository.List.
+func (r *MemoryRepository) List(ctx context.Context, req *productcatalog.ListProductsRequest) ([]*models.Product, string, error) {
+	q, err := parseListRequest(req)
+	if err != nil {
+		return nil, "", err
+	}
+	r.mu.RLock()
+	matched := make([]*models.Product, 0, len(r.products.byUuid))
+	for _, p := range r.products.byUuid {
+		if q.filter == nil || q.filter.matches(p) {
+			matched = append(matched, p)
+		}
+	}
+	r.mu.RUnlock()
+	page, nextPageToken, err := q.page(matched)
+	if err != nil {
+		return nil, "", err
+	}
+	products := make([]*models.Product, len(page))
+	for i, p := range page {
+		products[i] = cloneProduct(p)
+	}
+	return products, nextPageToken, nil
+}
+
+// Stream calls send with every product matching the request filter.
+// It behaves like MongoRepository.Stream, streaming the products
+// stored when it is called.
+func (r *MemoryRepository) Stream(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+	q, err := parseStreamRequest(req)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	matched := make([]*models.Product, 0, len(r.products.byUuid))
+	for _, p := range r.products.byUuid {
+		if q.filter == nil || q.filter.matches(p) {
+			matched = append(matched, p)
+		}
+	}
+	r.mu.RUnlock()
+	q.size = len(matched)
+	products, _, err := q.page(matched)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := send(cloneProduct(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch calls send with the changes made to products, polling for them
+// every second, until ctx is done or send fails. Watches share the reads of
+// the products, which fail past the number of products set with SetMaxWatchedProducts.
+// Watches cannot be resumed.
+func (r *MemoryRepository) Watch(ctx context.Context, resumeToken string, send func(*Change) error) error {
+	if resumeToken != "" {
+		return errResumeNotSupported
+	}
+	return r.changes.watch(ctx, r.Stream, send)
+}
+
+// SetMaxWatchedProducts sets the largest number of products Watch reads, 100000 by default,
+// as each read holds every product in memory. Watches already running keep the previous one.
+func (r *MemoryRepository) SetMaxWatchedProducts(max int) {
+	r.changes.setMaxProducts(max)
+}
+
+// CreateType stores a new product type.
+// It behaves like MongoRepository.CreateType.
+func (r *MemoryRepository) CreateType(ctx context.Context, newType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(newType); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products.types[newType.Name]; ok {
+		return nil, productTypeExistsError(newType.Name)
+	}
+	r.products.types[newType.Name] = cloneProductType(newType)
+	return newType, nil
+}
+
+// GetType retrieves a product type by name.
+// It behaves like MongoRepository.GetType.
+func (r *MemoryRepository) GetType(ctx context.Context, name string) (*models.ProductType, error) {
+	r.mu.RLock()
+	t, ok := r.products.types[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, productTypeNotFoundError(name)
+	}
+	return cloneProductType(t), nil
+}
+
+// ListTypes lists every product type, ordered by name.
+func (r *MemoryRepository) ListTypes(ctx context.Context) ([]*models.ProductType, error) {
+	r.mu.RLock()
+	types := make([]*models.ProductType, 0, len(r.products.types))
+	for _, t := range r.products.types {
+		types = append(types, cloneProductType(t))
+	}
+	r.mu.RUnlock()
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types, nil
+}
+
+// UpdateType replaces the product type with the same name.
+// It behaves like MongoRepository.UpdateType.
+func (r *MemoryRepository) UpdateType(ctx context.Context, productType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(productType); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products.types[productType.Name]; !ok {
+		return nil, productTypeNotFoundError(productType.Name)
+	}
+	r.products.types[productType.Name] = cloneProductType(productType)
+	return productType, nil
+}
+
+// DeleteType deletes a product type by name.
+// It behaves like MongoRepository.DeleteType.
+func (r *MemoryRepository) DeleteType(ctx context.Context, name string) (*models.ProductType, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.products.types[name]
+	if !ok {
+		return nil, productTypeNotFoundError(name)
+	}
+	for _, p := range r.products.byUuid {
+		if p.ProductType == name {
+			return nil, productTypeInUseError(name)
+		}
+	}
+	delete(r.products.types, name)
+	return t, nil
+}
+
+// cloneProduct returns a deep copy of a product.
+func cloneProduct(p *models.Product) *models.Product {
+	c := *p
+	c.Attributes = cloneAttributes(p.Attributes)
+	return &c
+}
+
+// cloneAttributes returns a deep copy of the attributes of a product.
+func cloneAttributes(attributes map[string]interface{}) map[string]interface{} {
+	if attributes == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(attributes))
+	for k, v := range attributes {
+		c[k] = cloneValue(v)
+	}
+	return c
+}
+
+// cloneValue returns a deep copy of an attribute value.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return cloneAttributes(v)
+	case primitive.M:
+		return primitive.M(cloneAttributes(v))
+	case primitive.D:
+		c := make(primitive.D, len(v))
+		for i, e := range v {
+			c[i] = primitive.E{Key: e.Key, Value: cloneValue(e.Value)}
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = cloneValue(e)
+		}
+		return c
+	case primitive.A:
+		c := make(primitive.A, len(v))
+		for i, e := range v {
+			c[i] = cloneValue(e)
+		}
+		return c
+	}
+	return v
+}
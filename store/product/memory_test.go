@@ -0,0 +1,266 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.TODO()
+	repo := NewMemoryRepository()
+	ids := []string{"uuid1", "uuid2"}
+	repo.uuidProvider = func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+
+	created, err := repo.Create(ctx, &models.Product{
+		Name:       "name",
+		Prices:     usd("1"),
+		Attributes: map[string]interface{}{"color": "blue", "size": 12.0},
+	}, "", "")
+	require.Nil(t, err)
+	require.Equal(t, &models.Product{
+		Uuid:       "uuid1",
+		Name:       "name",
+		Price:      1,
+		Prices:     usd("1"),
+		Attributes: map[string]interface{}{"color": "blue", "size": 12.0},
+		Revision:   1,
+	}, created)
+
+	// The stored product does not share attributes with the caller.
+	created.Attributes["color"] = "changed"
+	got, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "uuid1"})
+	require.Nil(t, err)
+	require.Equal(t, "blue", got.Attributes["color"])
+
+	_, err = repo.Create(ctx, &models.Product{Name: "other", Prices: usd("2")}, "", "")
+	require.Nil(t, err)
+
+	updated, err := repo.Update(ctx, &models.Product{
+		Uuid:       "uuid1",
+		Attributes: map[string]interface{}{"color": "red"},
+	}, []string{"attributes.color", "attributes.size"}, 1)
+	require.Nil(t, err)
+	require.Equal(t, &models.Product{
+		Uuid:       "uuid1",
+		Name:       "name",
+		Price:      1,
+		Prices:     usd("1"),
+		Attributes: map[string]interface{}{"color": "red"},
+		Revision:   2,
+	}, updated)
+
+	_, err = repo.Update(ctx, &models.Product{Uuid: "uuid1", Name: "new name"}, nil, 1)
+	require.Equal(t, `product with uuid "uuid1" is at revision 2, expected 1`, err.Error())
+	require.Equal(t, KindConflict, KindOf(err))
+
+	_, err = repo.Update(ctx, &models.Product{Uuid: "uuid3", Name: "new name"}, nil, 0)
+	require.Equal(t, KindNotFound, KindOf(err))
+
+	products, nextPageToken, err := repo.List(ctx, &productcatalog.ListProductsRequest{Filter: `attributes.color = "red"`})
+	require.Nil(t, err)
+	require.Empty(t, nextPageToken)
+	require.Equal(t, []*models.Product{updated}, products)
+
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: "uuid1", ExpectedRevision: 1})
+	require.Equal(t, KindConflict, KindOf(err))
+
+	deleted, err := repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: "uuid1", ExpectedRevision: 2})
+	require.Nil(t, err)
+	require.Equal(t, updated, deleted)
+
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: "uuid1"})
+	require.Equal(t, errors.New(`product with uuid "uuid1" does not exist`).Error(), err.Error())
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: "uuid1"})
+	require.Equal(t, KindNotFound, KindOf(err))
+}
+
+func TestMemoryRepositoryValidation(t *testing.T) {
+	ctx := context.TODO()
+	repo := NewMemoryRepository()
+	_, err := repo.Create(ctx, &models.Product{Prices: usd("-1")}, "", "")
+	require.Equal(t, "invalid argument: name: must not be empty; prices[0].amount: must be a number that is not negative", err.Error())
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{})
+	require.Equal(t, "invalid argument: uuid: must not be empty", err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: "uuid"}, []string{"uuid"}, 0)
+	require.Equal(t, `invalid argument: update_mask: unknown or immutable field "uuid"`, err.Error())
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{})
+	require.Equal(t, "invalid argument: uuid: must not be empty", err.Error())
+	_, _, err = repo.List(ctx, &productcatalog.ListProductsRequest{OrderBy: "size"})
+	require.Equal(t, `invalid argument: order_by: unknown field "size"`, err.Error())
+}
+
+func TestMemoryRepositoryList(t *testing.T) {
+	ctx := context.TODO()
+	repo := NewMemoryRepository()
+	products := []*models.Product{
+		{Name: "a", Prices: usd("3"), Attributes: map[string]interface{}{"weight": 2.0}},
+		{Name: "b", Prices: usd("1"), Attributes: map[string]interface{}{"weight": 1.0}},
+		{Name: "c", Prices: usd("2")},
+		{Name: "d", Prices: usd("1"), Attributes: map[string]interface{}{"weight": 3.0}},
+		{Name: "e", Prices: usd("5"), Attributes: map[string]interface{}{"weight": 1.0}},
+	}
+	for i, p := range products {
+		id := fmt.Sprintf("uuid%d", i)
+		repo.uuidProvider = func() string { return id }
+		_, err := repo.Create(ctx, p, "", "")
+		require.Nil(t, err)
+	}
+	testCases := []struct {
+		name           string
+		input          *productcatalog.ListProductsRequest
+		expectedOutput []string
+	}{
+		{
+			name:           "ordered by uuid",
+			input:          &productcatalog.ListProductsRequest{},
+			expectedOutput: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:           "ordered by price",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "price desc"},
+			expectedOutput: []string{"e", "a", "c", "b", "d"},
+		},
+		{
+			name:           "ordered by attribute with missing values",
+			input:          &productcatalog.ListProductsRequest{OrderBy: "attributes.weight, name desc"},
+			expectedOutput: []string{"c", "e", "b", "a", "d"},
+		},
+		{
+			name:           "filtered and ordered",
+			input:          &productcatalog.ListProductsRequest{Filter: "attributes.weight <= 2", OrderBy: "attributes.weight desc"},
+			expectedOutput: []string{"a", "b", "e"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Pages of two products must add up to the whole listing.
+			req := tc.input
+			req.PageSize = 2
+			var names []string
+			for {
+				page, nextPageToken, err := repo.List(ctx, req)
+				require.Nil(t, err)
+				require.LessOrEqual(t, len(page), 2)
+				for _, p := range page {
+					names = append(names, p.Name)
+				}
+				if nextPageToken == "" {
+					break
+				}
+				req.PageToken = nextPageToken
+			}
+			require.Equal(t, tc.expectedOutput, names)
+
+			// Streaming returns the whole listing at once.
+			names = nil
+			err := repo.Stream(ctx, &productcatalog.StreamProductsRequest{Filter: req.Filter, OrderBy: req.OrderBy}, func(p *models.Product) error {
+				names = append(names, p.Name)
+				return nil
+			})
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedOutput, names)
+		})
+	}
+}
+
+func TestMemoryRepositoryStream(t *testing.T) {
+	repo := NewMemoryRepository()
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := repo.Create(context.TODO(), &models.Product{Name: name}, "", "")
+		require.Nil(t, err)
+	}
+
+	// Streaming stops at the first error returned by send.
+	sent := 0
+	err := repo.Stream(context.TODO(), &productcatalog.StreamProductsRequest{}, func(p *models.Product) error {
+		sent++
+		return errors.New("random error")
+	})
+	require.Equal(t, "random error", err.Error())
+	require.Equal(t, 1, sent)
+
+	// And when the context is canceled.
+	ctx, cancel := context.WithCancel(context.TODO())
+	sent = 0
+	err = repo.Stream(ctx, &productcatalog.StreamProductsRequest{}, func(p *models.Product) error {
+		sent++
+		cancel()
+		return nil
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 1, sent)
+
+	err = repo.Stream(context.TODO(), &productcatalog.StreamProductsRequest{Filter: "name ="}, nil)
+	require.Equal(t, "invalid argument: filter: expected a value at position 6, got end of filter", err.Error())
+}
+
+func TestMemoryRepositoryConcurrency(t *testing.T) {
+	ctx := context.TODO()
+	repo := NewMemoryRepository()
+	created, err := repo.Create(ctx, &models.Product{Name: "name", Attributes: map[string]interface{}{"count": 0.0}}, "", "")
+	require.Nil(t, err)
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.Update(ctx, &models.Product{Uuid: created.Uuid, Attributes: map[string]interface{}{"count": float64(i)}}, nil, 0)
+			require.Nil(t, err)
+			_, _, err = repo.List(ctx, &productcatalog.ListProductsRequest{Filter: "attributes.count >= 0"})
+			require.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+	got, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: created.Uuid})
+	require.Nil(t, err)
+	require.Equal(t, int64(writers+1), got.Revision)
+}
+
+func TestMemoryRepositoryBatches(t *testing.T) {
+	testRepositoryBatches(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryWatch(t *testing.T) {
+	testRepositoryWatch(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryIdempotency(t *testing.T) {
+	repo := NewMemoryRepository()
+	testRepositoryIdempotency(t, repo)
+
+	// Expired keys are removed by later creates.
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { return time.Now().Add(3 * IdempotencyKeyTTL) }
+	_, err := repo.Create(context.TODO(), &models.Product{Name: "d"}, "", "")
+	require.Nil(t, err)
+	require.Empty(t, repo.keys)
+	require.Empty(t, repo.keyQueue)
+}
+
+func TestMemoryRepositoryUniqueness(t *testing.T) {
+	testRepositoryUniqueness(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryProductTypes(t *testing.T) {
+	testRepositoryProductTypes(t, NewMemoryRepository())
+}
+
+func TestMemoryRepositoryAttributeLimits(t *testing.T) {
+	testRepositoryAttributeLimits(t, NewMemoryRepository())
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations returns the migrations of the collections used by the repository,
+// to be applied with a store.Migrator before serving requests.
+// New migrations are appended with the next version; applied ones must not change.
+func (r *MongoRepository) Migrations() []store.Migration {
+	return []store.Migration{
+		{Version: 1, Description: "create product indexes", Up: r.createProductIndexes},
+		{Version: 2, Description: "create idempotency key indexes", Up: r.createIdempotencyKeyIndexes},
+		{Version: 3, Description: "create sku and slug indexes", Up: r.createNaturalKeyIndexes},
+		{Version: 4, Description: "backfill product revisions and attributes", Up: r.backfillProducts},
+		{Version: 5, Description: "create product type index", Up: r.createProductTypeIndexes},
+		{Version: 6, Description: "convert product prices to decimal prices", Up: r.convertPrices},
+	}
+}
+
+// RenameAttributeMigration returns a migration renaming an attribute key in every
+// product that has it, for deployments to append to Migrations with the next version.
+func (r *MongoRepository) RenameAttributeMigration(version int, from, to string) store.Migration {
+	return store.Migration{
+		Version:     version,
+		Description: fmt.Sprintf(`rename attribute "%s" to "%s"`, from, to),
+		Up:          r.renameAttribute(from, to),
+	}
+}
+
+// createProductIndexes creates the unique index on uuid, which backs the lookups
+// by uuid and the stable ordering used for pagination, and the compound indexes
+// backing the most common orderings, by name and by price.
+func (r *MongoRepository) createProductIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "uuid", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: 1}, {Key: "uuid", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "price", Value: 1}, {Key: "uuid", Value: 1}},
+		},
+	}
+	if err := r.coll.createIndexes(ctx, indexes); err != nil {
+		return wrapDbError(err, "", "creating indexes")
+	}
+	return nil
+}
+
+// createIdempotencyKeyIndexes creates a TTL index making MongoDB
+// delete the idempotency keys of creates once expired.
+func (r *MongoRepository) createIdempotencyKeyIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if err := r.coll.createIdempotencyKeyIndexes(ctx, indexes); err != nil {
+		return wrapDbError(err, "", "creating idempotency key indexes")
+	}
+	return nil
+}
+
+// createNaturalKeyIndexes creates the unique indexes on sku and slug.
+// Natural keys are optional, so only products that have one are indexed.
+func (r *MongoRepository) createNaturalKeyIndexes(ctx context.Context) error {
+	var indexes []mongo.IndexModel
+	for _, field := range uniqueFields {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys: bson.D{{Key: field, Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{field: bson.M{"$gt": ""}}),
+		})
+	}
+	if err := r.coll.createIndexes(ctx, indexes); err != nil {
+		return wrapDbError(err, "", "creating sku and slug indexes")
+	}
+	return nil
+}
+
+// createProductTypeIndexes creates the index on product_type, backing the check
+// that a product type is not used by products before deleting it.
+// Only products that have a type are indexed.
+func (r *MongoRepository) createProductTypeIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: productTypeField, Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{productTypeField: bson.M{"$gt": ""}}),
+		},
+	}
+	if err := r.coll.createIndexes(ctx, indexes); err != nil {
+		return wrapDbError(err, "", "creating product type indexes")
+	}
+	return nil
+}
+
+// backfillProducts gives the products written before revisions existed their first
+// revision, and an empty attributes map to those without one, so that their
+// attributes can be updated one at a time.
+func (r *MongoRepository) backfillProducts(ctx context.Context) error {
+	err := r.coll.updateMany(ctx,
+		bson.M{"revision": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revision": 1}},
+	)
+	if err != nil {
+		return wrapDbError(err, "", "backfilling revisions")
+	}
+	// A null query value matches both missing and null fields.
+	err = r.coll.updateMany(ctx,
+		bson.M{attributesField: nil},
+		bson.M{"$set": bson.M{attributesField: bson.M{}}, "$inc": bson.M{"revision": 1}},
+	)
+	if err != nil {
+		return wrapDbError(err, "", "backfilling attributes")
+	}
+	return nil
+}
+
+// convertPrices gives the products written before prices existed a price in
+// LegacyPriceCurrency holding their float price, rounded to cents, and no prices
+// to those without a price. The products get a new revision, so that conditional
+// writes based on the old prices fail.
+func (r *MongoRepository) convertPrices(ctx context.Context) error {
+	amount := bson.M{"$round": bson.A{bson.M{"$toDecimal": "$price"}, 2}}
+	err := r.coll.updateMany(ctx,
+		bson.M{pricesField: nil},
+		bson.A{bson.M{"$set": bson.M{
+			pricesField: bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$price", 0}},
+				bson.A{bson.M{"currency_code": LegacyPriceCurrency, "amount": amount}},
+				bson.A{},
+			}},
+			"revision": bson.M{"$add": bson.A{"$revision", 1}},
+		}}},
+	)
+	if err != nil {
+		return wrapDbError(err, "", "converting prices")
+	}
+	return nil
+}
+
+// renameAttribute returns the step of a migration renaming an attribute key
+// in every product that has it. The products get a new revision, so that
+// conditional writes based on the old attributes fail.
+func (r *MongoRepository) renameAttribute(from, to string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := r.coll.updateMany(ctx,
+			bson.M{attributesField + "." + from: bson.M{"$exists": true}},
+			bson.M{
+				"$rename": bson.M{attributesField + "." + from: attributesField + "." + to},
+				"$inc":    bson.M{"revision": 1},
+			},
+		)
+		if err != nil {
+			return wrapDbError(err, "", `renaming attribute "%s" to "%s"`, from, to)
+		}
+		return nil
+	}
+}
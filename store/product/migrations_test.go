@@ -0,0 +1,188 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMigrations(t *testing.T) {
+	testCases := []struct {
+		name                            string
+		version                         int
+		mockCreateIndexes               func(ctx context.Context, models []mongo.IndexModel) error
+		mockCreateIdempotencyKeyIndexes func(ctx context.Context, models []mongo.IndexModel) error
+		mockUpdateMany                  func(ctx context.Context, filter interface{}, update interface{}) error
+		expectedError                   error
+	}{
+		{
+			name:    "product indexes",
+			version: 1,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				require.Len(t, models, 3)
+				require.Equal(t, bson.D{{Key: "uuid", Value: 1}}, models[0].Keys)
+				require.True(t, *models[0].Options.Unique)
+				return nil
+			},
+		},
+		{
+			name:    "error when creating product indexes",
+			version: 1,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("creating indexes: random error"),
+		},
+		{
+			name:    "idempotency key indexes",
+			version: 2,
+			mockCreateIdempotencyKeyIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				require.Equal(t, int32(0), *models[0].Options.ExpireAfterSeconds)
+				return nil
+			},
+		},
+		{
+			name:    "error when creating idempotency key indexes",
+			version: 2,
+			mockCreateIdempotencyKeyIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("creating idempotency key indexes: random error"),
+		},
+		{
+			name:    "sku and slug indexes",
+			version: 3,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				require.Len(t, models, 2)
+				for i, field := range []string{"sku", "slug"} {
+					require.Equal(t, bson.D{{Key: field, Value: 1}}, models[i].Keys)
+					require.True(t, *models[i].Options.Unique)
+					require.Equal(t, bson.M{field: bson.M{"$gt": ""}}, models[i].Options.PartialFilterExpression)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "error when creating sku and slug indexes",
+			version: 3,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("creating sku and slug indexes: random error"),
+		},
+		{
+			name:    "product type index",
+			version: 5,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				require.Len(t, models, 1)
+				require.Equal(t, bson.D{{Key: "product_type", Value: 1}}, models[0].Keys)
+				require.Equal(t, bson.M{"product_type": bson.M{"$gt": ""}}, models[0].Options.PartialFilterExpression)
+				return nil
+			},
+		},
+		{
+			name:    "error when creating product type index",
+			version: 5,
+			mockCreateIndexes: func(ctx context.Context, models []mongo.IndexModel) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("creating product type indexes: random error"),
+		},
+		{
+			name:    "price conversion",
+			version: 6,
+			mockUpdateMany: func(ctx context.Context, filter interface{}, update interface{}) error {
+				require.Equal(t, bson.M{"prices": nil}, filter)
+				set := update.(bson.A)[0].(bson.M)["$set"].(bson.M)
+				require.Equal(t, bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$price", 0}},
+					bson.A{bson.M{"currency_code": "USD", "amount": bson.M{"$round": bson.A{bson.M{"$toDecimal": "$price"}, 2}}}},
+					bson.A{},
+				}}, set["prices"])
+				require.Equal(t, bson.M{"$add": bson.A{"$revision", 1}}, set["revision"])
+				return nil
+			},
+		},
+		{
+			name:    "error when converting prices",
+			version: 6,
+			mockUpdateMany: func(ctx context.Context, filter interface{}, update interface{}) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("converting prices: random error"),
+		},
+		{
+			name:    "backfill",
+			version: 4,
+			mockUpdateMany: func() func(ctx context.Context, filter interface{}, update interface{}) error {
+				calls := 0
+				return func(ctx context.Context, filter interface{}, update interface{}) error {
+					calls++
+					if calls == 1 {
+						require.Equal(t, bson.M{"revision": bson.M{"$exists": false}}, filter)
+						require.Equal(t, bson.M{"$set": bson.M{"revision": 1}}, update)
+					} else {
+						require.Equal(t, bson.M{"attributes": nil}, filter)
+						require.Equal(t, bson.M{"$set": bson.M{"attributes": bson.M{}}, "$inc": bson.M{"revision": 1}}, update)
+					}
+					return nil
+				}
+			}(),
+		},
+		{
+			name:    "error when backfilling",
+			version: 4,
+			mockUpdateMany: func(ctx context.Context, filter interface{}, update interface{}) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("backfilling revisions: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{
+				mockCreateIndexes:               tc.mockCreateIndexes,
+				mockCreateIdempotencyKeyIndexes: tc.mockCreateIdempotencyKeyIndexes,
+				mockUpdateMany:                  tc.mockUpdateMany,
+			}}
+			migrations := repo.Migrations()
+			for i, m := range migrations {
+				require.Equal(t, i+1, m.Version)
+			}
+			err := migrations[tc.version-1].Up(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRenameAttribute(t *testing.T) {
+	repo := &MongoRepository{coll: &mockCollection{
+		mockUpdateMany: func(ctx context.Context, filter interface{}, update interface{}) error {
+			require.Equal(t, bson.M{"attributes.colour": bson.M{"$exists": true}}, filter)
+			require.Equal(t, bson.M{
+				"$rename": bson.M{"attributes.colour": "attributes.color"},
+				"$inc":    bson.M{"revision": 1},
+			}, update)
+			return errors.New("random error")
+		},
+	}}
+	migration := repo.RenameAttributeMigration(7, "colour", "color")
+	require.Equal(t, 7, migration.Version)
+	require.Equal(t, `rename attribute "colour" to "color"`, migration.Description)
+	err := migration.Up(context.TODO())
+	require.Equal(t, `renaming attribute "colour" to "color": random error`, err.Error())
+}
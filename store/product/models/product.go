@@ -5,11 +5,24 @@
 // Package models provides the data models used in the application.
 package models
 
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
 // Product represents a product with its associated attributes.
 type Product struct {
 	Uuid        string                 `bson:"uuid"`
 	Name        string                 `bson:"name"`
 	Description string                 `bson:"description"`
 	Price       float32                `bson:"price"`
+	Prices      []Money                `bson:"prices"`
+	Sku         string                 `bson:"sku"`
+	Slug        string                 `bson:"slug"`
+	ProductType string                 `bson:"product_type"`
 	Attributes  map[string]interface{} `bson:"attributes"`
+	Revision    int64                  `bson:"revision"`
+}
+
+// Money represents an amount of money in a currency.
+type Money struct {
+	CurrencyCode string               `bson:"currency_code"`
+	Amount       primitive.Decimal128 `bson:"amount"`
 }
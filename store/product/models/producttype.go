@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package models
+
+// Attribute types of an AttributeSchema.
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeInteger = "integer"
+	AttributeBool    = "bool"
+	AttributeList    = "list"
+	AttributeStruct  = "struct"
+)
+
+// ProductType represents a kind of product, with the schema of its attributes.
+type ProductType struct {
+	Name                      string                     `bson:"_id"`
+	Description               string                     `bson:"description"`
+	Attributes                map[string]AttributeSchema `bson:"attributes"`
+	AllowAdditionalAttributes bool                       `bson:"allow_additional_attributes"`
+	JsonSchema                string                     `bson:"json_schema,omitempty"`
+}
+
+// AttributeSchema represents the constraints on the value of an attribute.
+type AttributeSchema struct {
+	Type          string        `bson:"type"`
+	Required      bool          `bson:"required"`
+	AllowedValues []interface{} `bson:"allowed_values,omitempty"`
+	Minimum       *float64      `bson:"minimum,omitempty"`
+	Maximum       *float64      `bson:"maximum,omitempty"`
+	Pattern       string        `bson:"pattern,omitempty"`
+}
@@ -0,0 +1,1225 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName = "products"
+	// idempotencyKeysCollectionName is the collection holding the idempotency keys of creates.
+	idempotencyKeysCollectionName = "idempotency_keys"
+	// productTypesCollectionName is the collection holding the product types, keyed by name.
+	productTypesCollectionName = "product_types"
+	// maxUpdateAttempts is how many times Update reads and writes a product whose
+	// attributes are checked against its type, when another writer changes it in between.
+	maxUpdateAttempts = 3
+)
+
+// Cursor is an interface that defines the methods necessary for iterating
+// over query results in a data layer.
+// This interface is particularly useful for simplifying unit tests
+// by allowing the implementation of mock cursors that can be used
+// for testing data retrieval and manipulation operations.
+type Cursor interface {
+	Decode(interface{}) error
+	Err() error
+	Close(context.Context) error
+	Next(context.Context) bool
+}
+
+type cursorWrapper struct {
+	*mongo.Cursor
+}
+
+// collection is the subset of the operations of a MongoDB collection used by MongoRepository.
+// It allows unit tests to inject a fake collection.
+type collection interface {
+	insertOne(ctx context.Context, document interface{}) error
+	find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+	findOne(ctx context.Context, filter interface{}, p *models.Product) error
+	findOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, p *models.Product) error
+	findOneAndDelete(ctx context.Context, filter interface{}, p *models.Product) error
+	createIndexes(ctx context.Context, models []mongo.IndexModel) error
+	updateMany(ctx context.Context, filter interface{}, update interface{}) error
+	bulkWrite(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+	transactionsSupported(ctx context.Context) (bool, error)
+	withTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	watch(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error)
+	enablePreImages(ctx context.Context) error
+	createIdempotencyKeyIndexes(ctx context.Context, models []mongo.IndexModel) error
+	insertIdempotencyKey(ctx context.Context, key *idempotencyKey) error
+	findIdempotencyKey(ctx context.Context, requestId string, key *idempotencyKey) error
+	commitIdempotencyKey(ctx context.Context, requestId string) error
+	deleteIdempotencyKey(ctx context.Context, filter interface{}) error
+	insertProductType(ctx context.Context, t *models.ProductType) error
+	findProductType(ctx context.Context, name string, t *models.ProductType) error
+	findProductTypes(ctx context.Context) ([]*models.ProductType, error)
+	replaceProductType(ctx context.Context, t *models.ProductType) (bool, error)
+	deleteProductType(ctx context.Context, name string, t *models.ProductType) error
+}
+
+// mongoCollection implements collection on top of a MongoDB collection.
+type mongoCollection struct {
+	*mongo.Collection
+}
+
+func (c *mongoCollection) insertOne(ctx context.Context, document interface{}) error {
+	_, err := c.InsertOne(ctx, document)
+	return err
+}
+
+func (c *mongoCollection) find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+	cur, err := c.Find(ctx, filter, opts...)
+	return &cursorWrapper{cur}, err
+}
+
+func (c *mongoCollection) findOne(ctx context.Context, filter interface{}, p *models.Product) error {
+	return c.FindOne(ctx, filter).Decode(p)
+}
+
+func (c *mongoCollection) findOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, p *models.Product) error {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	return c.FindOneAndUpdate(ctx, filter, update, opts).Decode(p)
+}
+
+func (c *mongoCollection) findOneAndDelete(ctx context.Context, filter interface{}, p *models.Product) error {
+	return c.FindOneAndDelete(ctx, filter).Decode(p)
+}
+
+func (c *mongoCollection) createIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := c.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func (c *mongoCollection) updateMany(ctx context.Context, filter interface{}, update interface{}) error {
+	_, err := c.UpdateMany(ctx, filter, update)
+	return err
+}
+
+func (c *mongoCollection) bulkWrite(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+	return c.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+}
+
+// transactionsSupported reports whether the deployment supports transactions,
+// which are only available on replica sets and sharded clusters.
+func (c *mongoCollection) transactionsSupported(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := c.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+// withTransaction runs fn in a transaction, which is committed when fn succeeds.
+// fn may be called again when the transaction fails with a transient error.
+func (c *mongoCollection) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := c.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// watch opens a change stream on the collection.
+func (c *mongoCollection) watch(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+	cs, err := c.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// enablePreImages makes the change streams of the collection
+// able to report documents as they were before being changed.
+func (c *mongoCollection) enablePreImages(ctx context.Context) error {
+	return c.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: c.Name()},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+}
+
+// idempotencyKeys returns the collection holding the idempotency keys of creates.
+func (c *mongoCollection) idempotencyKeys() *mongo.Collection {
+	return c.Database().Collection(idempotencyKeysCollectionName)
+}
+
+func (c *mongoCollection) createIdempotencyKeyIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := c.idempotencyKeys().Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func (c *mongoCollection) insertIdempotencyKey(ctx context.Context, key *idempotencyKey) error {
+	_, err := c.idempotencyKeys().InsertOne(ctx, key)
+	return err
+}
+
+func (c *mongoCollection) findIdempotencyKey(ctx context.Context, requestId string, key *idempotencyKey) error {
+	return c.idempotencyKeys().FindOne(ctx, bson.M{"_id": requestId}).Decode(key)
+}
+
+func (c *mongoCollection) commitIdempotencyKey(ctx context.Context, requestId string) error {
+	_, err := c.idempotencyKeys().UpdateOne(ctx, bson.M{"_id": requestId}, bson.M{"$set": bson.M{"committed": true}})
+	return err
+}
+
+func (c *mongoCollection) deleteIdempotencyKey(ctx context.Context, filter interface{}) error {
+	_, err := c.idempotencyKeys().DeleteOne(ctx, filter)
+	return err
+}
+
+// productTypes returns the collection holding the product types.
+func (c *mongoCollection) productTypes() *mongo.Collection {
+	return c.Database().Collection(productTypesCollectionName)
+}
+
+func (c *mongoCollection) insertProductType(ctx context.Context, t *models.ProductType) error {
+	_, err := c.productTypes().InsertOne(ctx, t)
+	return err
+}
+
+func (c *mongoCollection) findProductType(ctx context.Context, name string, t *models.ProductType) error {
+	return c.productTypes().FindOne(ctx, bson.M{"_id": name}).Decode(t)
+}
+
+func (c *mongoCollection) findProductTypes(ctx context.Context) ([]*models.ProductType, error) {
+	cur, err := c.productTypes().Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	types := []*models.ProductType{}
+	if err := cur.All(ctx, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// replaceProductType replaces the product type with the same name, reporting whether there was one.
+func (c *mongoCollection) replaceProductType(ctx context.Context, t *models.ProductType) (bool, error) {
+	res, err := c.productTypes().ReplaceOne(ctx, bson.M{"_id": t.Name}, t)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (c *mongoCollection) deleteProductType(ctx context.Context, name string, t *models.ProductType) error {
+	return c.productTypes().FindOneAndDelete(ctx, bson.M{"_id": name}).Decode(t)
+}
+
+// MongoRepository is the ProductRepository that stores products in MongoDB.
+type MongoRepository struct {
+	coll         collection
+	uuidProvider func() string
+	changes      poller
+}
+
+var _ ProductRepository = (*MongoRepository)(nil)
+
+// NewMongoRepository creates a repository that stores products
+// in the products collection of the given database.
+func NewMongoRepository(db *store.MongoDb) *MongoRepository {
+	return &MongoRepository{
+		coll:         &mongoCollection{db.Client.Database(db.DatabaseName).Collection(collectionName)},
+		uuidProvider: uuid.NewString,
+	}
+}
+
+// Get retrieves a product from the database by uuid, sku or slug.
+func (r *MongoRepository) Get(ctx context.Context, req *productcatalog.GetProductRequest) (*models.Product, error) {
+	field, value, err := productKey(req)
+	if err != nil {
+		return nil, err
+	}
+	var product models.Product
+	if err := r.coll.findOne(ctx, bson.M{field: value}, &product); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, keyNotFoundError(field, value)
+		}
+		return nil, wrapDbError(err, req.GetUuid(), `getting product with %s "%s"`, field, value)
+	}
+	return &product, nil
+}
+
+// Create creates a new product in the database. Its uuid is productId when not empty,
+// failing with an error of kind KindAlreadyExists when taken, and a new uuid otherwise.
+//
+// When requestId is not empty, the request is first recorded, along with the product
+// it creates, in the idempotency keys collection. A retry finds the record and returns
+// its product instead of creating another. As the record and the product are written
+// separately, the record is only marked as committed once the product is inserted:
+// a retry of a request whose outcome is unknown, as after a timeout, inserts the
+// recorded product if it is not there yet.
+func (r *MongoRepository) Create(ctx context.Context, newProduct *models.Product, productId, requestId string) (*models.Product, error) {
+	key, err := prepareCreate(newProduct, productId, requestId, r.uuidProvider)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProductType(newProduct, r.productTypeGetter(ctx)); err != nil {
+		return nil, err
+	}
+	if key == nil {
+		if err := r.coll.insertOne(ctx, newProduct); err != nil {
+			return nil, wrapWriteError(err, newProduct, "inserting product")
+		}
+		return newProduct, nil
+	}
+	stored, err := r.recordIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		if _, err := stored.replay(key); err != nil {
+			return nil, err
+		}
+		if stored.Committed {
+			return cloneProduct(stored.Product), nil
+		}
+		key = stored
+	}
+	if err := r.coll.insertOne(ctx, key.Product); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, wrapWriteError(err, key.Product, "inserting product")
+		}
+		// A retry finding the product means that the first attempt inserted it,
+		// whereas not finding it means that another product has its sku or slug.
+		inserted := false
+		if stored != nil {
+			var current models.Product
+			switch findErr := r.coll.findOne(ctx, bson.M{"uuid": key.Product.Uuid}, &current); findErr {
+			case nil:
+				inserted = true
+			case mongo.ErrNoDocuments:
+			default:
+				return nil, wrapDbError(findErr, key.Product.Uuid, "finding inserted product")
+			}
+		}
+		if !inserted {
+			if err := r.coll.deleteIdempotencyKey(ctx, bson.M{"_id": key.RequestId}); err != nil {
+				return nil, wrapDbError(err, "", "deleting idempotency key")
+			}
+			return nil, wrapWriteError(err, key.Product, "inserting product")
+		}
+	}
+	if err := r.coll.commitIdempotencyKey(ctx, key.RequestId); err != nil {
+		return nil, wrapDbError(err, "", "committing idempotency key")
+	}
+	return cloneProduct(key.Product), nil
+}
+
+// recordIdempotencyKey stores the idempotency key of a create,
+// returning the key stored by an earlier attempt of the request, if any.
+// Expired keys that MongoDB did not delete yet are replaced.
+func (r *MongoRepository) recordIdempotencyKey(ctx context.Context, key *idempotencyKey) (*idempotencyKey, error) {
+	for {
+		err := r.coll.insertIdempotencyKey(ctx, key)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, wrapDbError(err, "", "inserting idempotency key")
+		}
+		stored := new(idempotencyKey)
+		err = r.coll.findIdempotencyKey(ctx, key.RequestId, stored)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, wrapDbError(err, "", "finding idempotency key")
+		}
+		if !stored.expired() {
+			return stored, nil
+		}
+		filter := bson.M{"_id": key.RequestId, "expires_at": stored.ExpiresAt}
+		if err := r.coll.deleteIdempotencyKey(ctx, filter); err != nil {
+			return nil, wrapDbError(err, "", "deleting idempotency key")
+		}
+	}
+}
+
+// Update writes the fields of a product named by the update mask paths
+// and returns the product as stored. When no path is given, the mask is
+// implied from the populated fields of the product.
+// When expectedRevision is not zero, the product is only updated if it is
+// at that revision, failing with an error of kind KindConflict otherwise.
+// It fails with an error of kind KindNotFound if the product does not exist.
+//
+// Updates writing the type or the attributes of a product read it first, to check
+// the updated attributes against the schema of its type, and then write it
+// conditioned on the revision read. When no revision is expected, the update is
+// tried again if another writer changed the product in between.
+func (r *MongoRepository) Update(ctx context.Context, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	paths, err := prepareUpdate(productToUpdate, paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		p, err := r.Get(ctx, &productcatalog.GetProductRequest{Uuid: productToUpdate.Uuid})
+		if err != nil {
+			return nil, err
+		}
+		if expectedRevision != 0 && p.Revision != expectedRevision {
+			return nil, conflictError(p.Uuid, expectedRevision, p.Revision)
+		}
+		return p, nil
+	}
+	if writesAttributes(paths) {
+		return r.updateChecked(ctx, productToUpdate, paths, expectedRevision)
+	}
+	var updatedProduct models.Product
+	err = r.coll.findOneAndUpdate(ctx, revisionFilter(productToUpdate.Uuid, expectedRevision), updateDocument(productToUpdate, paths), &updatedProduct)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, r.missingError(ctx, productToUpdate.Uuid, expectedRevision)
+		}
+		return nil, wrapWriteError(err, productToUpdate, `updating product with uuid "%s"`, productToUpdate.Uuid)
+	}
+	return &updatedProduct, nil
+}
+
+// updateChecked writes the masked paths of a product after checking
+// the attributes of the updated product, against the schema of its type among others.
+func (r *MongoRepository) updateChecked(ctx context.Context, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error) {
+	productType := r.productTypeGetter(ctx)
+	for attempt := 1; ; attempt++ {
+		current, err := r.Get(ctx, &productcatalog.GetProductRequest{Uuid: productToUpdate.Uuid})
+		if err != nil {
+			return nil, err
+		}
+		if expectedRevision != 0 && current.Revision != expectedRevision {
+			return nil, conflictError(current.Uuid, expectedRevision, current.Revision)
+		}
+		updated := cloneProduct(current)
+		applyUpdate(updated, productToUpdate, paths)
+		if err := checkUpdatedAttributes(updated, paths, productType); err != nil {
+			return nil, err
+		}
+		var updatedProduct models.Product
+		err = r.coll.findOneAndUpdate(ctx, revisionFilter(current.Uuid, current.Revision), updateDocument(productToUpdate, paths), &updatedProduct)
+		if err == nil {
+			return &updatedProduct, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, wrapWriteError(err, productToUpdate, `updating product with uuid "%s"`, productToUpdate.Uuid)
+		}
+		if expectedRevision != 0 || attempt == maxUpdateAttempts {
+			return nil, r.missingError(ctx, current.Uuid, current.Revision)
+		}
+	}
+}
+
+// productTypeGetter returns a function reading product types as checkProductType expects,
+// caching them so that batches read each type once.
+func (r *MongoRepository) productTypeGetter(ctx context.Context) func(name string) (*models.ProductType, error) {
+	cache := make(map[string]*models.ProductType)
+	return func(name string) (*models.ProductType, error) {
+		if t, ok := cache[name]; ok {
+			return t, nil
+		}
+		t := new(models.ProductType)
+		if err := r.coll.findProductType(ctx, name, t); err != nil {
+			if err != mongo.ErrNoDocuments {
+				return nil, wrapDbError(err, "", `getting product type "%s"`, name)
+			}
+			t = nil
+		}
+		cache[name] = t
+		return t, nil
+	}
+}
+
+// Delete deletes a product from the database by uuid and returns
+// a snapshot of it as it was right before the deletion.
+// When the request has an expected revision, the product is only deleted
+// if it is at that revision, failing with an error of kind KindConflict otherwise.
+// It fails with an error of kind KindNotFound if the product does not exist.
+func (r *MongoRepository) Delete(ctx context.Context, req *productcatalog.DeleteProductRequest) (*models.Product, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+	var deletedProduct models.Product
+	err := r.coll.findOneAndDelete(ctx, revisionFilter(req.Uuid, req.ExpectedRevision), &deletedProduct)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, r.missingError(ctx, req.Uuid, req.ExpectedRevision)
+		}
+		return nil, wrapDbError(err, req.Uuid, `deleting product with uuid "%s"`, req.Uuid)
+	}
+	return &deletedProduct, nil
+}
+
+// List lists one page of the products matching the request filter,
+// in the requested order, tie-broken by uuid.
+// Alongside the products it returns the token for the next page,
+// which is empty when there are no more products.
+func (r *MongoRepository) List(ctx context.Context, req *productcatalog.ListProductsRequest) ([]*models.Product, string, error) {
+	q, err := parseListRequest(req)
+	if err != nil {
+		return nil, "", err
+	}
+	var conditions bson.A
+	if q.filter != nil {
+		conditions = append(conditions, q.filter.toBSON())
+	}
+	if q.after != nil {
+		conditions = append(conditions, afterCondition(q.keys, q.after))
+	}
+	filter := bson.M{}
+	switch len(conditions) {
+	case 0:
+	case 1:
+		filter = conditions[0].(bson.M)
+	default:
+		filter = bson.M{"$and": conditions}
+	}
+	// One extra product is fetched to find out whether there is a next page.
+	opts := options.Find().
+		SetSort(sortDocument(q.keys)).
+		SetLimit(int64(q.size) + 1)
+	cur, err := r.coll.find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", wrapDbError(err, "", "finding products")
+	}
+	defer cur.Close(ctx)
+	var products []*models.Product
+	for cur.Next(ctx) {
+		var product models.Product
+		if err = cur.Decode(&product); err != nil {
+			return nil, "", errors.Wrap(err, "decoding product")
+		}
+		products = append(products, &product)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, "", wrapDbError(err, "", "cursor error")
+	}
+	if len(products) <= q.size {
+		return products, "", nil
+	}
+	products = products[:q.size]
+	nextPageToken, err := q.nextPageToken(products[q.size-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return products, nextPageToken, nil
+}
+
+// Stream calls send with every product matching the request filter, in the
+// requested order, tie-broken by uuid. Products are decoded one at a time as
+// the cursor is iterated, so that the whole result is never held in memory.
+func (r *MongoRepository) Stream(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error {
+	q, err := parseStreamRequest(req)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{}
+	if q.filter != nil {
+		filter = q.filter.toBSON()
+	}
+	cur, err := r.coll.find(ctx, filter, options.Find().SetSort(sortDocument(q.keys)))
+	if err != nil {
+		return wrapDbError(err, "", "finding products")
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var product models.Product
+		if err := cur.Decode(&product); err != nil {
+			return errors.Wrap(err, "decoding product")
+		}
+		if err := send(&product); err != nil {
+			return err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return wrapDbError(err, "", "cursor error")
+	}
+	return nil
+}
+
+// changeStreamHistoryLostCode is the error code returned by MongoDB
+// when a change stream cannot be resumed because the oplog moved past it.
+const changeStreamHistoryLostCode = 286
+
+// changeEvent is the part of a change stream event read by Watch.
+type changeEvent struct {
+	ID                       bson.Raw        `bson:"_id"`
+	OperationType            string          `bson:"operationType"`
+	FullDocument             *models.Product `bson:"fullDocument"`
+	FullDocumentBeforeChange *models.Product `bson:"fullDocumentBeforeChange"`
+}
+
+// change converts the event into a Change, returning nil
+// for the events that do not change a product.
+func (e *changeEvent) change() *Change {
+	c := &Change{
+		Before:      e.FullDocumentBeforeChange,
+		After:       e.FullDocument,
+		ResumeToken: base64.RawURLEncoding.EncodeToString(e.ID),
+	}
+	switch e.OperationType {
+	case "insert":
+		c.Type = ChangeCreated
+		c.Before = nil
+	case "update", "replace":
+		c.Type = ChangeUpdated
+	case "delete":
+		c.Type = ChangeDeleted
+		c.After = nil
+	default:
+		return nil
+	}
+	if c.After != nil {
+		c.Uuid = c.After.Uuid
+	} else if c.Before != nil {
+		c.Uuid = c.Before.Uuid
+	}
+	return c
+}
+
+// decodeResumeToken parses a resume token previously returned by Watch.
+func decodeResumeToken(s string) (bson.Raw, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || bson.Raw(b).Validate() != nil {
+		return nil, invalidArgumentError(FieldViolation{Field: "resume_token", Description: "is not a valid resume token"})
+	}
+	return bson.Raw(b), nil
+}
+
+// wrapChangeStreamError annotates an error returned by a change stream,
+// reporting resume tokens that are too old as invalid arguments.
+func wrapChangeStreamError(err error, format string) error {
+	var se mongo.ServerError
+	if errors.As(err, &se) && se.HasErrorCode(changeStreamHistoryLostCode) {
+		return invalidArgumentError(FieldViolation{Field: "resume_token", Description: "is no longer available"})
+	}
+	return wrapDbError(err, "", format)
+}
+
+// EnablePreImages enables change stream pre-images on the products collection,
+// so that the changes reported by Watch carry the products as they were before
+// being updated or deleted. Pre-images need MongoDB 6.0 or later.
+// It does nothing on deployments without change streams, where Watch polls for changes.
+func (r *MongoRepository) EnablePreImages(ctx context.Context) error {
+	supported, err := r.coll.transactionsSupported(ctx)
+	if err != nil {
+		return wrapDbError(err, "", "checking change stream support")
+	}
+	if !supported {
+		return nil
+	}
+	if err := r.coll.enablePreImages(ctx); err != nil {
+		return wrapDbError(err, "", "enabling pre-images")
+	}
+	return nil
+}
+
+// Watch calls send with every change made to products after the call, or after
+// the change with the given resume token, until ctx is done or send fails.
+// Change streams, like transactions, need a replica set or a sharded cluster.
+// On a standalone server, Watch polls the products instead, as the other backends
+// do, up to the number of products set with SetMaxWatchedProducts, and cannot resume.
+// Changes carry the products as they were before being changed only when
+// pre-images are enabled, see EnablePreImages.
+func (r *MongoRepository) Watch(ctx context.Context, resumeToken string, send func(*Change) error) error {
+	supported, err := r.coll.transactionsSupported(ctx)
+	if err != nil {
+		return wrapDbError(err, "", "checking change stream support")
+	}
+	if !supported {
+		if resumeToken != "" {
+			return errResumeNotSupported
+		}
+		return r.changes.watch(ctx, r.Stream, send)
+	}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != "" {
+		token, err := decodeResumeToken(resumeToken)
+		if err != nil {
+			return err
+		}
+		opts.SetResumeAfter(token)
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete", "invalidate"}},
+	}}}}
+	cs, err := r.coll.watch(ctx, pipeline, opts)
+	if err != nil {
+		return wrapChangeStreamError(err, "watching products")
+	}
+	defer cs.Close(ctx)
+	for cs.Next(ctx) {
+		var event changeEvent
+		if err := cs.Decode(&event); err != nil {
+			return errors.Wrap(err, "decoding change")
+		}
+		if event.OperationType == "invalidate" {
+			return errors.New("change stream invalidated, the products collection was dropped or renamed")
+		}
+		if c := event.change(); c != nil {
+			if err := send(c); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cs.Err(); err != nil {
+		return wrapChangeStreamError(err, "change stream error")
+	}
+	return ctx.Err()
+}
+
+// SetMaxWatchedProducts sets the largest number of products Watch reads when polling,
+// 100000 by default, as each read holds every product in memory.
+// Watches already running keep the previous one.
+func (r *MongoRepository) SetMaxWatchedProducts(max int) {
+	r.changes.setMaxProducts(max)
+}
+
+// CreateType stores a new product type in the database,
+// failing with an error of kind KindAlreadyExists when its name is taken.
+func (r *MongoRepository) CreateType(ctx context.Context, newType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(newType); err != nil {
+		return nil, err
+	}
+	if err := r.coll.insertProductType(ctx, newType); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, productTypeExistsError(newType.Name)
+		}
+		return nil, wrapDbError(err, "", "inserting product type")
+	}
+	return newType, nil
+}
+
+// GetType retrieves a product type from the database by name.
+// It fails with an error of kind KindNotFound if the product type does not exist.
+func (r *MongoRepository) GetType(ctx context.Context, name string) (*models.ProductType, error) {
+	t, err := r.productTypeGetter(ctx)(name)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, productTypeNotFoundError(name)
+	}
+	return t, nil
+}
+
+// ListTypes lists every product type, ordered by name.
+func (r *MongoRepository) ListTypes(ctx context.Context) ([]*models.ProductType, error) {
+	types, err := r.coll.findProductTypes(ctx)
+	if err != nil {
+		return nil, wrapDbError(err, "", "listing product types")
+	}
+	return types, nil
+}
+
+// UpdateType replaces the product type with the same name.
+// The products already written are not checked against the new schema.
+// It fails with an error of kind KindNotFound if the product type does not exist.
+func (r *MongoRepository) UpdateType(ctx context.Context, productType *models.ProductType) (*models.ProductType, error) {
+	if err := validateProductType(productType); err != nil {
+		return nil, err
+	}
+	matched, err := r.coll.replaceProductType(ctx, productType)
+	if err != nil {
+		return nil, wrapDbError(err, "", `updating product type "%s"`, productType.Name)
+	}
+	if !matched {
+		return nil, productTypeNotFoundError(productType.Name)
+	}
+	return productType, nil
+}
+
+// DeleteType deletes a product type from the database by name, returning it.
+// It fails with an error of kind KindConflict when products use the type, and
+// of kind KindNotFound if it does not exist. A product written with the type
+// while it is being deleted is not detected, as MongoDB has no foreign keys.
+func (r *MongoRepository) DeleteType(ctx context.Context, name string) (*models.ProductType, error) {
+	var used models.Product
+	err := r.coll.findOne(ctx, bson.M{productTypeField: name}, &used)
+	if err == nil {
+		return nil, productTypeInUseError(name)
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, wrapDbError(err, "", `finding products of type "%s"`, name)
+	}
+	var deleted models.ProductType
+	if err := r.coll.deleteProductType(ctx, name, &deleted); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, productTypeNotFoundError(name)
+		}
+		return nil, wrapDbError(err, "", `deleting product type "%s"`, name)
+	}
+	return &deleted, nil
+}
+
+// BatchCreate stores new products with a single bulk write, reporting the outcome of each one.
+// When allOrNothing is set, the first failed item fails the whole batch. On deployments
+// that support transactions, the batch then stores no product. Elsewhere every product
+// is validated before any is written, but a failure while writing leaves the products
+// written before it in place.
+func (r *MongoRepository) BatchCreate(ctx context.Context, newProducts []*models.Product, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(newProducts)); err != nil {
+		return nil, err
+	}
+	return r.runBatch(ctx, "products", allOrNothing, func(ctx context.Context) ([]BatchResult, error) {
+		results := make([]BatchResult, len(newProducts))
+		productType := r.productTypeGetter(ctx)
+		var writes []batchWrite
+		for i, p := range newProducts {
+			if err := validateProduct(p); err != nil {
+				results[i].Err = err
+				continue
+			}
+			p.Price = legacyPrice(p.Prices)
+			if err := checkProductType(p, productType); err != nil {
+				results[i].Err = err
+				continue
+			}
+			p.Uuid = r.uuidProvider()
+			p.Revision = 1
+			writes = append(writes, batchWrite{
+				index:   i,
+				model:   mongo.NewInsertOneModel().SetDocument(p),
+				product: p,
+			})
+		}
+		if _, err := r.bulkWrite(ctx, results, writes, allOrNothing, "inserting", nil); err != nil {
+			return nil, err
+		}
+		return results, nil
+	})
+}
+
+// BatchUpdate updates products with a single bulk write, reporting the outcome of each update,
+// with the same all or nothing semantics as BatchCreate. The products are read beforehand,
+// to check them as Update does and to write each one conditioned on the revision read.
+// An update whose product was written by someone else in the meantime fails
+// with an error of kind KindConflict.
+func (r *MongoRepository) BatchUpdate(ctx context.Context, updates []ProductUpdate, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(updates)); err != nil {
+		return nil, err
+	}
+	return r.runBatch(ctx, "requests", allOrNothing, func(ctx context.Context) ([]BatchResult, error) {
+		results := make([]BatchResult, len(updates))
+		paths := make([][]string, len(updates))
+		uuids := make([]string, len(updates))
+		for i, u := range updates {
+			paths[i], results[i].Err = prepareUpdate(u.Product, u.Paths)
+			uuids[i] = u.Product.Uuid
+		}
+		rejectRepeatedUuids(results, uuids)
+		current, err := r.findByUuid(ctx, results, uuids)
+		if err != nil {
+			return nil, err
+		}
+		productType := r.productTypeGetter(ctx)
+		var writes []batchWrite
+		for i, u := range updates {
+			if results[i].Err != nil {
+				continue
+			}
+			p, ok := current[u.Product.Uuid]
+			switch {
+			case !ok:
+				results[i].Err = notFoundError(u.Product.Uuid)
+			case u.ExpectedRevision != 0 && p.Revision != u.ExpectedRevision:
+				results[i].Err = conflictError(p.Uuid, u.ExpectedRevision, p.Revision)
+			case len(paths[i]) == 0:
+				results[i].Product = p
+			default:
+				updated := cloneProduct(p)
+				applyUpdate(updated, u.Product, paths[i])
+				if err := checkUpdatedAttributes(updated, paths[i], productType); err != nil {
+					results[i].Err = err
+					continue
+				}
+				writes = append(writes, batchWrite{
+					index:    i,
+					uuid:     p.Uuid,
+					revision: p.Revision,
+					model: mongo.NewUpdateOneModel().
+						SetFilter(revisionFilter(p.Uuid, p.Revision)).
+						SetUpdate(updateDocument(u.Product, paths[i])),
+					product: updated,
+				})
+			}
+		}
+		unconfirmed, err := r.bulkWrite(ctx, results, writes, allOrNothing, "updating", func(res *mongo.BulkWriteResult) int64 {
+			return res.MatchedCount
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := r.confirmWrites(ctx, results, unconfirmed, false); err != nil {
+			return nil, err
+		}
+		return results, nil
+	})
+}
+
+// BatchDelete deletes products with a single bulk write, reporting the outcome of each deletion,
+// with the same all or nothing semantics as BatchCreate. As in BatchUpdate, the products are
+// read beforehand, and a deletion whose product was written by someone else in the meantime
+// fails with an error of kind KindConflict.
+func (r *MongoRepository) BatchDelete(ctx context.Context, reqs []*productcatalog.DeleteProductRequest, allOrNothing bool) ([]BatchResult, error) {
+	if err := validateBatchSize("requests", len(reqs)); err != nil {
+		return nil, err
+	}
+	return r.runBatch(ctx, "requests", allOrNothing, func(ctx context.Context) ([]BatchResult, error) {
+		results := make([]BatchResult, len(reqs))
+		uuids := make([]string, len(reqs))
+		for i, req := range reqs {
+			results[i].Err = validateUuid(req.GetUuid())
+			uuids[i] = req.GetUuid()
+		}
+		rejectRepeatedUuids(results, uuids)
+		current, err := r.findByUuid(ctx, results, uuids)
+		if err != nil {
+			return nil, err
+		}
+		var writes []batchWrite
+		for i, req := range reqs {
+			if results[i].Err != nil {
+				continue
+			}
+			p, ok := current[req.GetUuid()]
+			switch {
+			case !ok:
+				results[i].Err = notFoundError(req.GetUuid())
+			case req.GetExpectedRevision() != 0 && p.Revision != req.GetExpectedRevision():
+				results[i].Err = conflictError(p.Uuid, req.GetExpectedRevision(), p.Revision)
+			default:
+				writes = append(writes, batchWrite{
+					index:    i,
+					uuid:     p.Uuid,
+					revision: p.Revision,
+					model:    mongo.NewDeleteOneModel().SetFilter(revisionFilter(p.Uuid, p.Revision)),
+					product:  p,
+				})
+			}
+		}
+		unconfirmed, err := r.bulkWrite(ctx, results, writes, allOrNothing, "deleting", func(res *mongo.BulkWriteResult) int64 {
+			return res.DeletedCount
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := r.confirmWrites(ctx, results, unconfirmed, true); err != nil {
+			return nil, err
+		}
+		return results, nil
+	})
+}
+
+// BatchUpsert creates or replaces products with a single bulk write, reporting the outcome of each one.
+// Products with a uuid replace every field of the stored product with that uuid, incrementing its
+// revision, or are created with that uuid when there is none. Products without a uuid are created
+// with a new one. As in BatchUpdate, the products are read beforehand, and a product written by
+// someone else in the meantime fails with an error of kind KindConflict.
+func (r *MongoRepository) BatchUpsert(ctx context.Context, products []*models.Product) ([]BatchResult, error) {
+	if err := validateBatchSize("products", len(products)); err != nil {
+		return nil, err
+	}
+	results := prepareUpsert(products, r.uuidProvider)
+	uuids := make([]string, len(products))
+	for i, p := range products {
+		uuids[i] = p.Uuid
+	}
+	current, err := r.findByUuid(ctx, results, uuids)
+	if err != nil {
+		return nil, err
+	}
+	productType := r.productTypeGetter(ctx)
+	var writes []batchWrite
+	for i, p := range products {
+		if results[i].Err != nil {
+			continue
+		}
+		if err := checkProductType(p, productType); err != nil {
+			results[i].Err = err
+			continue
+		}
+		stored, ok := current[p.Uuid]
+		if !ok {
+			if err := checkCreatedUuid(p.Uuid); err != nil {
+				results[i].Err = err
+				continue
+			}
+			p.Revision = 1
+			writes = append(writes, batchWrite{
+				index:   i,
+				uuid:    p.Uuid,
+				model:   mongo.NewInsertOneModel().SetDocument(p),
+				product: p,
+			})
+			continue
+		}
+		replaced := cloneProduct(stored)
+		applyUpdate(replaced, p, updatableFields)
+		writes = append(writes, batchWrite{
+			index:    i,
+			uuid:     p.Uuid,
+			revision: stored.Revision,
+			model: mongo.NewUpdateOneModel().
+				SetFilter(revisionFilter(p.Uuid, stored.Revision)).
+				SetUpdate(updateDocument(p, updatableFields)),
+			product: replaced,
+		})
+	}
+	unconfirmed, err := r.bulkWrite(ctx, results, writes, false, "importing", func(res *mongo.BulkWriteResult) int64 {
+		return res.InsertedCount + res.MatchedCount
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.confirmWrites(ctx, results, unconfirmed, false); err != nil {
+		return nil, err
+	}
+	markCreated(results)
+	return results, nil
+}
+
+// batchWrite is a write of a batch operation.
+type batchWrite struct {
+	index    int    // Index of the item the write belongs to.
+	uuid     string // Uuid of the product written, for writes that may need to be confirmed.
+	revision int64  // Revision the product was read at, zero for insertions.
+	model    mongo.WriteModel
+	product  *models.Product // Product reported for the item when the write succeeds.
+}
+
+// runBatch runs a batch operation. Operations that are all or nothing run
+// in a transaction when the deployment supports it, and fail with the error
+// of their first failed item. op must check every item before writing any,
+// and must not write anything when an all or nothing item fails the check.
+// It may be called again when a transaction fails with a transient error.
+func (r *MongoRepository) runBatch(ctx context.Context, field string, allOrNothing bool, op func(ctx context.Context) ([]BatchResult, error)) ([]BatchResult, error) {
+	if !allOrNothing {
+		return op(ctx)
+	}
+	run := func(ctx context.Context) ([]BatchResult, error) {
+		results, err := op(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := batchError(field, results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+	supported, err := r.coll.transactionsSupported(ctx)
+	if err != nil {
+		return nil, wrapDbError(err, "", "checking transaction support")
+	}
+	if !supported {
+		return run(ctx)
+	}
+	var results []BatchResult
+	err = r.coll.withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		results, err = run(ctx)
+		return err
+	})
+	if err != nil {
+		var perr *Error
+		if errors.As(err, &perr) {
+			return nil, err
+		}
+		return nil, wrapDbError(err, "", "running transaction")
+	}
+	return results, nil
+}
+
+// findByUuid returns the stored products with the given uuids, by uuid,
+// skipping the uuids of the items that already failed.
+func (r *MongoRepository) findByUuid(ctx context.Context, results []BatchResult, uuids []string) (map[string]*models.Product, error) {
+	var pending []string
+	for i, uuid := range uuids {
+		if results[i].Err == nil {
+			pending = append(pending, uuid)
+		}
+	}
+	products := make(map[string]*models.Product, len(pending))
+	if len(pending) == 0 {
+		return products, nil
+	}
+	cur, err := r.coll.find(ctx, bson.M{"uuid": bson.M{"$in": pending}})
+	if err != nil {
+		return nil, wrapDbError(err, "", "finding products")
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var product models.Product
+		if err := cur.Decode(&product); err != nil {
+			return nil, errors.Wrap(err, "decoding product")
+		}
+		products[product.Uuid] = &product
+	}
+	if err := cur.Err(); err != nil {
+		return nil, wrapDbError(err, "", "cursor error")
+	}
+	return products, nil
+}
+
+// bulkWrite sends the writes of a batch in a single bulk write, recording the outcome
+// of each item in results. Nothing is written when an all or nothing item already failed.
+// Updates and deletions that match no document are not reported as errors by MongoDB:
+// when fewer writes than sent matched a document, as counted by matched, the writes
+// that did not fail are returned, for the caller to find out which ones took effect.
+func (r *MongoRepository) bulkWrite(ctx context.Context, results []BatchResult, writes []batchWrite, allOrNothing bool, action string, matched func(*mongo.BulkWriteResult) int64) ([]batchWrite, error) {
+	if len(writes) == 0 {
+		return nil, nil
+	}
+	if allOrNothing {
+		for _, r := range results {
+			if r.Err != nil {
+				return nil, nil
+			}
+		}
+	}
+	writeModels := make([]mongo.WriteModel, len(writes))
+	for i, w := range writes {
+		writeModels[i] = w.model
+	}
+	res, err := r.coll.bulkWrite(ctx, writeModels, allOrNothing)
+	failed := make(map[int]bool)
+	if err != nil {
+		var bwe mongo.BulkWriteException
+		if !errors.As(err, &bwe) || len(bwe.WriteErrors) == 0 {
+			return nil, wrapDbError(err, "", "writing products")
+		}
+		for _, we := range bwe.WriteErrors {
+			w := writes[we.Index]
+			uuid := w.product.Uuid
+			results[w.index].Err = wrapWriteError(we.WriteError, w.product, `%s product with uuid "%s"`, action, uuid)
+			failed[we.Index] = true
+		}
+	}
+	var succeeded []batchWrite
+	for i, w := range writes {
+		if !failed[i] {
+			results[w.index].Product = w.product
+			succeeded = append(succeeded, w)
+		}
+	}
+	if matched == nil || (res != nil && matched(res) == int64(len(succeeded))) {
+		return nil, nil
+	}
+	return succeeded, nil
+}
+
+// confirmWrites reads again the products of writes that may not have matched
+// the revision they were conditioned on, failing the items of those that did not.
+// An update took effect when the product is at the next revision, holding the
+// updated fields, and a deletion when the product no longer exists.
+func (r *MongoRepository) confirmWrites(ctx context.Context, results []BatchResult, writes []batchWrite, deletions bool) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	uuids := make([]string, len(writes))
+	for i, w := range writes {
+		uuids[i] = w.uuid
+	}
+	current, err := r.findByUuid(ctx, make([]BatchResult, len(uuids)), uuids)
+	if err != nil {
+		return err
+	}
+	for _, w := range writes {
+		p, ok := current[w.uuid]
+		switch {
+		case deletions && !ok:
+		case !ok:
+			results[w.index] = BatchResult{Err: notFoundError(w.uuid)}
+		case deletions || p.Revision != w.revision+1 || !sameFields(p, w.product):
+			results[w.index] = BatchResult{Err: conflictError(w.uuid, w.revision, p.Revision)}
+		}
+	}
+	return nil
+}
+
+// sameFields reports whether two products hold the same fields,
+// whatever the types their attributes were decoded into.
+func sameFields(a, b *models.Product) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		a.Price == b.Price &&
+		samePrices(a.Prices, b.Prices) &&
+		a.Sku == b.Sku &&
+		a.Slug == b.Slug &&
+		a.ProductType == b.ProductType &&
+		compareValues(a.Attributes, b.Attributes) == 0
+}
+
+// revisionFilter returns the query matching the product with the given uuid,
+// restricted to the expected revision when it is not zero.
+func revisionFilter(uuid string, expectedRevision int64) bson.M {
+	if expectedRevision == 0 {
+		return bson.M{"uuid": uuid}
+	}
+	return bson.M{"uuid": uuid, "revision": expectedRevision}
+}
+
+// missingError returns the error for a conditional write that matched no product.
+// When a revision was expected, the product is looked up again to tell
+// a product that does not exist apart from one at another revision.
+func (r *MongoRepository) missingError(ctx context.Context, uuid string, expectedRevision int64) error {
+	if expectedRevision == 0 {
+		return notFoundError(uuid)
+	}
+	var current models.Product
+	if err := r.coll.findOne(ctx, bson.M{"uuid": uuid}, &current); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return notFoundError(uuid)
+		}
+		return wrapDbError(err, uuid, `getting product with uuid "%s"`, uuid)
+	}
+	return conflictError(uuid, expectedRevision, current.Revision)
+}
+
+// validateUuid checks that a product uuid was informed.
+func validateUuid(uuid string) error {
+	if strings.TrimSpace(uuid) == "" {
+		return invalidArgumentError(FieldViolation{Field: "uuid", Description: "must not be empty"})
+	}
+	return nil
+}
+
+// validateProduct checks the fields of a product that is about to be written.
+func validateProduct(p *models.Product) error {
+	var violations []FieldViolation
+	if strings.TrimSpace(p.Name) == "" {
+		violations = append(violations, FieldViolation{Field: "name", Description: "must not be empty"})
+	}
+	violations = append(violations, validatePrices(p.Prices)...)
+	for _, field := range uniqueFields {
+		violations = append(violations, validateUniqueValue(field, uniqueValue(p, field))...)
+	}
+	if p.ProductType != "" {
+		violations = append(violations, validateProductTypeName(productTypeField, p.ProductType)...)
+	}
+	violations = append(violations, validateAttributeKeys(p.Attributes)...)
+	if len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return nil
+}
@@ -0,0 +1,2456 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNewMongoRepository(t *testing.T) {
+	repo := NewMongoRepository(&store.MongoDb{DatabaseName: "db", Client: &mongo.Client{}})
+	coll, ok := repo.coll.(*mongoCollection)
+	require.True(t, ok)
+	require.Equal(t, "db", coll.Database().Name())
+	require.Equal(t, collectionName, coll.Name())
+	require.NotNil(t, repo.uuidProvider)
+}
+
+func TestCreate(t *testing.T) {
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	input := func() *models.Product {
+		return &models.Product{
+			Name:        "name",
+			Description: "description",
+			Prices:      usd("1"),
+			Attributes: map[string]interface{}{
+				"attr": "value",
+			},
+		}
+	}
+	created := func(uuid string) *models.Product {
+		p := input()
+		p.Uuid = uuid
+		p.Price = 1
+		p.Revision = 1
+		return p
+	}
+	// storedKey returns the idempotency key stored for the input, with the given request id.
+	storedKey := func(requestId string, committed bool) *idempotencyKey {
+		key, err := newIdempotencyKey(requestId, "", input())
+		require.Nil(t, err)
+		key.Product = created("uuid")
+		key.Committed = committed
+		return key
+	}
+	duplicateKeyError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+	testCases := []struct {
+		name                     string
+		input                    *models.Product
+		productId                string
+		requestId                string
+		mockInsertOne            func(ctx context.Context, document interface{}) error
+		mockFindOne              func(ctx context.Context, filter interface{}, p *models.Product) error
+		mockInsertIdempotencyKey func(ctx context.Context, key *idempotencyKey) error
+		mockFindIdempotencyKey   func(ctx context.Context, requestId string, key *idempotencyKey) error
+		mockCommitIdempotencyKey func(ctx context.Context, requestId string) error
+		mockDeleteIdempotencyKey func(ctx context.Context, filter interface{}) error
+		expectedOutput           *models.Product
+		expectedError            error
+	}{
+		{
+			name:           "happy path",
+			input:          input(),
+			expectedOutput: created("uuid"),
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return nil
+			},
+		},
+		{
+			name:           "product id",
+			input:          input(),
+			productId:      "my-product-1",
+			expectedOutput: created("my-product-1"),
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				require.Equal(t, "my-product-1", document.(*models.Product).Uuid)
+				return nil
+			},
+		},
+		{
+			name: "sku taken",
+			input: func() *models.Product {
+				p := input()
+				p.Sku = "SKU-1"
+				return p
+			}(),
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+					Code:    11000,
+					Message: `E11000 duplicate key error collection: db.products index: sku_1 dup key: { sku: "SKU-1" }`,
+				}}}
+			},
+			expectedError: errors.New(`product with sku "SKU-1" already exists`),
+		},
+		{
+			name:           "request id",
+			input:          input(),
+			requestId:      "request",
+			expectedOutput: created("uuid"),
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				require.Equal(t, storedKey("request", false), key)
+				require.Equal(t, now.Add(IdempotencyKeyTTL), key.ExpiresAt)
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return nil
+			},
+			mockCommitIdempotencyKey: func(ctx context.Context, requestId string) error {
+				require.Equal(t, "request", requestId)
+				return nil
+			},
+		},
+		{
+			name:           "retried request",
+			input:          input(),
+			requestId:      "request",
+			expectedOutput: created("uuid"),
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return duplicateKeyError
+			},
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", true)
+				return nil
+			},
+		},
+		{
+			name:           "retried request whose product may not have been inserted",
+			input:          input(),
+			requestId:      "request",
+			expectedOutput: created("uuid"),
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return duplicateKeyError
+			},
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", false)
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				require.Equal(t, created("uuid"), document)
+				return duplicateKeyError
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"uuid": "uuid"}, filter)
+				*p = *created("uuid")
+				return nil
+			},
+			mockCommitIdempotencyKey: func(ctx context.Context, requestId string) error {
+				return nil
+			},
+		},
+		{
+			name:      "retried request whose sku or slug is in use",
+			input:     input(),
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return duplicateKeyError
+			},
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", false)
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return duplicateKeyError
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockDeleteIdempotencyKey: func(ctx context.Context, filter interface{}) error {
+				require.Equal(t, bson.M{"_id": "request"}, filter)
+				return nil
+			},
+			expectedError: errors.New("inserting product: write exception: write errors: [duplicate key]"),
+		},
+		{
+			name:      "error when finding the product of a retried request",
+			input:     input(),
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return duplicateKeyError
+			},
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", false)
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return duplicateKeyError
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("finding inserted product: random error"),
+		},
+		{
+			name:      "request id reused for a different request",
+			input:     &models.Product{Name: "other name"},
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return duplicateKeyError
+			},
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", true)
+				return nil
+			},
+			expectedError: errors.New("invalid argument: request_id: was used for a different request"),
+		},
+		{
+			name:           "expired request id",
+			input:          input(),
+			requestId:      "request",
+			expectedOutput: created("uuid"),
+			mockInsertIdempotencyKey: func() func(ctx context.Context, key *idempotencyKey) error {
+				calls := 0
+				return func(ctx context.Context, key *idempotencyKey) error {
+					calls++
+					if calls == 1 {
+						return duplicateKeyError
+					}
+					return nil
+				}
+			}(),
+			mockFindIdempotencyKey: func(ctx context.Context, requestId string, key *idempotencyKey) error {
+				*key = *storedKey("request", true)
+				key.ExpiresAt = now
+				return nil
+			},
+			mockDeleteIdempotencyKey: func(ctx context.Context, filter interface{}) error {
+				require.Equal(t, bson.M{"_id": "request", "expires_at": now}, filter)
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return nil
+			},
+			mockCommitIdempotencyKey: func(ctx context.Context, requestId string) error {
+				return nil
+			},
+		},
+		{
+			name:      "product id in use",
+			input:     input(),
+			productId: "my-product",
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return duplicateKeyError
+			},
+			mockDeleteIdempotencyKey: func(ctx context.Context, filter interface{}) error {
+				require.Equal(t, bson.M{"_id": "request"}, filter)
+				return nil
+			},
+			expectedError: errors.New("inserting product: write exception: write errors: [duplicate key]"),
+		},
+		{
+			name:      "error when inserting idempotency key",
+			input:     input(),
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("inserting idempotency key: random error"),
+		},
+		{
+			name:      "error when committing idempotency key",
+			input:     input(),
+			requestId: "request",
+			mockInsertIdempotencyKey: func(ctx context.Context, key *idempotencyKey) error {
+				return nil
+			},
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return nil
+			},
+			mockCommitIdempotencyKey: func(ctx context.Context, requestId string) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("committing idempotency key: random error"),
+		},
+		{
+			name:  "error",
+			input: input(),
+			mockInsertOne: func(ctx context.Context, document interface{}) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("inserting product: random error"),
+		},
+		{
+			name: "invalid product",
+			input: &models.Product{
+				Prices: usd("-1"),
+			},
+			expectedError: errors.New("invalid argument: name: must not be empty; prices[0].amount: must be a number that is not negative"),
+		},
+		{
+			name: "invalid sku and slug",
+			input: &models.Product{
+				Name: "name",
+				Sku:  "-sku",
+				Slug: "My Slug",
+			},
+			expectedError: errors.New("invalid argument: sku: must be up to 64 letters, digits, dots, underscores or hyphens, starting with a letter or digit; slug: must be up to 128 lowercase letters or digits, in words separated by single hyphens"),
+		},
+		{
+			name:          "invalid product id and request id",
+			input:         input(),
+			productId:     "My Product",
+			requestId:     strings.Repeat("a", 129),
+			expectedError: errors.New("invalid argument: product_id: must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit; request_id: must not be longer than 128 characters"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{
+				coll: &mockCollection{
+					mockInsertOne:            tc.mockInsertOne,
+					mockFindOne:              tc.mockFindOne,
+					mockInsertIdempotencyKey: tc.mockInsertIdempotencyKey,
+					mockFindIdempotencyKey:   tc.mockFindIdempotencyKey,
+					mockCommitIdempotencyKey: tc.mockCommitIdempotencyKey,
+					mockDeleteIdempotencyKey: tc.mockDeleteIdempotencyKey,
+				},
+				uuidProvider: func() string { return "uuid" },
+			}
+			output, err := repo.Create(context.TODO(), tc.input, tc.productId, tc.requestId)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          *productcatalog.GetProductRequest
+		mockFindOne    func(ctx context.Context, filter interface{}, p *models.Product) error
+		expectedOutput *models.Product
+		expectedError  error
+		// expectedUuid, expectedKeyField and expectedKey identify the product the error refers to.
+		expectedUuid     string
+		expectedKeyField string
+		expectedKey      string
+	}{
+		{
+			name: "happy path",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Name = "name"
+				p.Description = "description"
+				p.Price = 1
+				p.Attributes = map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				}
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "description",
+				Price:       1,
+				Attributes: map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				},
+			},
+		},
+		{
+			name:  "by sku",
+			input: &productcatalog.GetProductRequest{Sku: "SKU-1"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"sku": "SKU-1"}, filter)
+				p.Uuid = "uuid"
+				p.Sku = "SKU-1"
+				return nil
+			},
+			expectedOutput: &models.Product{Uuid: "uuid", Sku: "SKU-1"},
+		},
+		{
+			name:  "by slug",
+			input: &productcatalog.GetProductRequest{Slug: "my-product"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"slug": "my-product"}, filter)
+				p.Uuid = "uuid"
+				p.Slug = "my-product"
+				return nil
+			},
+			expectedOutput: &models.Product{Uuid: "uuid", Slug: "my-product"},
+		},
+		{
+			name:          "more than one key",
+			input:         &productcatalog.GetProductRequest{Uuid: "uuid", Slug: "my-product"},
+			expectedError: errors.New("invalid argument: slug: must not be set along with uuid"),
+		},
+		{
+			name:          "no key",
+			input:         &productcatalog.GetProductRequest{},
+			expectedError: errors.New("invalid argument: uuid: must not be empty"),
+		},
+		{
+			name: "error",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`getting product with uuid "uuid": random error`),
+		},
+		{
+			name: "document not found",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product with uuid "uuid" does not exist`),
+			expectedUuid:  "uuid",
+		},
+		{
+			name:  "document not found by sku",
+			input: &productcatalog.GetProductRequest{Sku: "SKU-1"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError:    errors.New(`product with sku "SKU-1" does not exist`),
+			expectedKeyField: "sku",
+			expectedKey:      "SKU-1",
+		},
+		{
+			name:  "timeout by slug",
+			input: &productcatalog.GetProductRequest{Slug: "my-product"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return context.DeadlineExceeded
+			},
+			expectedError: errors.New(`getting product with slug "my-product": context deadline exceeded`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := tc.input
+			if input == nil {
+				input = &productcatalog.GetProductRequest{Uuid: "uuid"}
+			}
+			repo := &MongoRepository{coll: &mockCollection{mockFindOne: tc.mockFindOne}}
+			output, err := repo.Get(context.TODO(), input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				if tc.expectedUuid != "" || tc.expectedKey != "" {
+					var perr *Error
+					require.True(t, errors.As(err, &perr))
+					require.Equal(t, tc.expectedUuid, perr.Uuid)
+					require.Equal(t, tc.expectedKeyField, perr.KeyField)
+					require.Equal(t, tc.expectedKey, perr.Key)
+				}
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		input                *models.Product
+		paths                []string
+		expectedRevision     int64
+		mockFindOneAndUpdate func(ctx context.Context, filter interface{}, update interface{}, p *models.Product) error
+		mockFindOne          func(ctx context.Context, filter interface{}, p *models.Product) error
+		mockFindProductType  func(ctx context.Context, name string, t *models.ProductType) error
+		expectedOutput       *models.Product
+		expectedError        error
+	}{
+		{
+			name: "happy path",
+			input: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "description",
+				Price:       1,
+				Attributes: map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				},
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				return nil
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Name = "name"
+				p.Description = "description"
+				p.Price = 1
+				p.Attributes = map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				}
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "description",
+				Price:       1,
+				Attributes: map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				},
+			},
+		},
+		{
+			name: "error",
+			input: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "description",
+				Price:       1,
+				Attributes: map[string]interface{}{
+					"color": "blue",
+					"size":  12.0,
+				},
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				return nil
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`updating product with uuid "uuid": random error`),
+		},
+		{
+			name: "document not found",
+			input: &models.Product{
+				Uuid: "uuid",
+				Name: "name",
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product with uuid "uuid" does not exist`),
+		},
+		{
+			name: "masked fields",
+			input: &models.Product{
+				Uuid:        "uuid",
+				Description: "new description",
+				Attributes: map[string]interface{}{
+					"color": "red",
+				},
+			},
+			paths: []string{"description", "attributes.color", "attributes.size"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				return nil
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				expectedUpdate := bson.M{
+					"$set":   bson.M{"description": "new description", "attributes.color": "red"},
+					"$unset": bson.M{"attributes.size": ""},
+					"$inc":   bson.M{"revision": 1},
+				}
+				require.Equal(t, bson.M{"uuid": "uuid"}, filter)
+				require.Equal(t, expectedUpdate, update)
+				p.Uuid = "uuid"
+				p.Name = "name"
+				p.Description = "new description"
+				p.Attributes = map[string]interface{}{
+					"color": "red",
+				}
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:        "uuid",
+				Name:        "name",
+				Description: "new description",
+				Attributes: map[string]interface{}{
+					"color": "red",
+				},
+			},
+		},
+		{
+			name: "expected revision",
+			input: &models.Product{
+				Uuid: "uuid",
+				Name: "new name",
+			},
+			expectedRevision: 2,
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"uuid": "uuid", "revision": int64(2)}, filter)
+				p.Uuid = "uuid"
+				p.Name = "new name"
+				p.Revision = 3
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:     "uuid",
+				Name:     "new name",
+				Revision: 3,
+			},
+		},
+		{
+			name: "revision mismatch",
+			input: &models.Product{
+				Uuid: "uuid",
+				Name: "new name",
+			},
+			expectedRevision: 2,
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"uuid": "uuid"}, filter)
+				p.Uuid = "uuid"
+				p.Revision = 5
+				return nil
+			},
+			expectedError: errors.New(`product with uuid "uuid" is at revision 5, expected 2`),
+		},
+		{
+			name: "expected revision of product that does not exist",
+			input: &models.Product{
+				Uuid: "uuid",
+				Name: "new name",
+			},
+			expectedRevision: 2,
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product with uuid "uuid" does not exist`),
+		},
+		{
+			name: "empty update mask with revision mismatch",
+			input: &models.Product{
+				Uuid: "uuid",
+			},
+			expectedRevision: 2,
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Revision = 1
+				return nil
+			},
+			expectedError: errors.New(`product with uuid "uuid" is at revision 1, expected 2`),
+		},
+		{
+			name: "attributes checked against the product type",
+			input: &models.Product{
+				Uuid:       "uuid",
+				Attributes: map[string]interface{}{"size": "XL"},
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.ProductType = "shirt"
+				return nil
+			},
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				require.Equal(t, "shirt", name)
+				pt.Name = "shirt"
+				pt.Attributes = map[string]models.AttributeSchema{"size": {Type: models.AttributeInteger}}
+				return nil
+			},
+			expectedError: errors.New("invalid argument: attributes.size: must be of type integer"),
+		},
+		{
+			name: "product type that does not exist",
+			input: &models.Product{
+				Uuid:        "uuid",
+				ProductType: "shirt",
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				return nil
+			},
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`invalid argument: product_type: product type "shirt" does not exist`),
+		},
+		{
+			name: "typed product written at the revision read",
+			input: &models.Product{
+				Uuid:       "uuid",
+				Attributes: map[string]interface{}{"size": 42.0},
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.ProductType = "shirt"
+				p.Revision = 4
+				return nil
+			},
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				pt.Name = "shirt"
+				pt.Attributes = map[string]models.AttributeSchema{"size": {Type: models.AttributeInteger}}
+				return nil
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"uuid": "uuid", "revision": int64(4)}, filter)
+				p.Uuid = "uuid"
+				p.ProductType = "shirt"
+				p.Attributes = map[string]interface{}{"size": 42.0}
+				p.Revision = 5
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:        "uuid",
+				ProductType: "shirt",
+				Attributes:  map[string]interface{}{"size": 42.0},
+				Revision:    5,
+			},
+		},
+		{
+			name: "typed product changed by another writer in between",
+			input: &models.Product{
+				Uuid:       "uuid",
+				Attributes: map[string]interface{}{"size": 42.0},
+			},
+			mockFindOne: func() func(ctx context.Context, filter interface{}, p *models.Product) error {
+				revision := int64(0)
+				return func(ctx context.Context, filter interface{}, p *models.Product) error {
+					revision++
+					p.Uuid = "uuid"
+					p.ProductType = "shirt"
+					p.Revision = revision
+					return nil
+				}
+			}(),
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				pt.Name = "shirt"
+				pt.AllowAdditionalAttributes = true
+				return nil
+			},
+			mockFindOneAndUpdate: func(ctx context.Context, filter, update interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product with uuid "uuid" is at revision 4, expected 3`),
+		},
+		{
+			name: "attribute past the limit of keys",
+			input: &models.Product{
+				Uuid:       "uuid",
+				Attributes: map[string]interface{}{"extra": true},
+			},
+			paths: []string{"attributes.extra"},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Attributes = make(map[string]interface{})
+				for i := 0; i < MaxAttributeKeys; i++ {
+					p.Attributes[fmt.Sprintf("k%d", i)] = true
+				}
+				return nil
+			},
+			expectedError: errors.New("invalid argument: attributes: must not have more than 1000 keys, counting those of nested documents"),
+		},
+		{
+			name: "invalid update mask",
+			input: &models.Product{
+				Uuid: "uuid",
+			},
+			paths:         []string{"uuid"},
+			expectedError: errors.New(`invalid argument: update_mask: unknown or immutable field "uuid"`),
+		},
+		{
+			name: "masked field is invalid",
+			input: &models.Product{
+				Uuid: "uuid",
+			},
+			paths:         []string{"name"},
+			expectedError: errors.New("invalid argument: name: must not be empty"),
+		},
+		{
+			name: "missing uuid",
+			input: &models.Product{
+				Name: "name",
+			},
+			expectedError: errors.New("invalid argument: uuid: must not be empty"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{
+				mockFindOneAndUpdate: tc.mockFindOneAndUpdate,
+				mockFindOne:          tc.mockFindOne,
+				mockFindProductType:  tc.mockFindProductType,
+			}}
+			output, err := repo.Update(context.TODO(), tc.input, tc.paths, tc.expectedRevision)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		expectedRevision     int64
+		mockFindOneAndDelete func(ctx context.Context, filter interface{}, p *models.Product) error
+		mockFindOne          func(ctx context.Context, filter interface{}, p *models.Product) error
+		expectedOutput       *models.Product
+		expectedError        error
+	}{
+		{
+			name: "happy path",
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Name = "name"
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid: "uuid",
+				Name: "name",
+			},
+		},
+		{
+			name: "error",
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`deleting product with uuid "uuid": random error`),
+		},
+		{
+			name: "document not found",
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product with uuid "uuid" does not exist`),
+		},
+		{
+			name:             "expected revision",
+			expectedRevision: 4,
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"uuid": "uuid", "revision": int64(4)}, filter)
+				p.Uuid = "uuid"
+				p.Revision = 4
+				return nil
+			},
+			expectedOutput: &models.Product{
+				Uuid:     "uuid",
+				Revision: 4,
+			},
+		},
+		{
+			name:             "revision mismatch",
+			expectedRevision: 4,
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				p.Uuid = "uuid"
+				p.Revision = 6
+				return nil
+			},
+			expectedError: errors.New(`product with uuid "uuid" is at revision 6, expected 4`),
+		},
+		{
+			name:             "error when checking revision",
+			expectedRevision: 4,
+			mockFindOneAndDelete: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`getting product with uuid "uuid": random error`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFindOneAndDelete: tc.mockFindOneAndDelete, mockFindOne: tc.mockFindOne}}
+			output, err := repo.Delete(context.TODO(), &productcatalog.DeleteProductRequest{Uuid: "uuid", ExpectedRevision: tc.expectedRevision})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		input                 *productcatalog.ListProductsRequest
+		mockFind              func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		expectedOutput        []*models.Product
+		expectedNextPageToken string
+		expectedError         error
+	}{
+		{
+			name: "happy path",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				data := []models.Product{
+					{
+						Uuid:        "id",
+						Name:        "name",
+						Description: "description",
+						Price:       1,
+						Attributes: map[string]interface{}{
+							"color": "blue",
+							"size":  12.0,
+						},
+					},
+					{
+						Uuid:        "id2",
+						Name:        "name2",
+						Description: "description2",
+						Price:       12,
+						Attributes: map[string]interface{}{
+							"color": "red",
+							"size":  1.0,
+						},
+					},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{
+					Uuid:        "id",
+					Name:        "name",
+					Description: "description",
+					Price:       1,
+					Attributes: map[string]interface{}{
+						"color": "blue",
+						"size":  12.0,
+					},
+				},
+				{
+					Uuid:        "id2",
+					Name:        "name2",
+					Description: "description2",
+					Price:       12,
+					Attributes: map[string]interface{}{
+						"color": "red",
+						"size":  1.0,
+					},
+				},
+			},
+		},
+		{
+			name: "error when finding products",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("finding products: random error"),
+		},
+		{
+			name: "error when decoding product",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				data := []models.Product{
+					{
+						Uuid:        "id",
+						Name:        "name",
+						Description: "description",
+						Price:       1,
+						Attributes: map[string]interface{}{
+							"color": "blue",
+							"size":  12.0,
+						},
+					},
+				}
+				return &MockCursor{data: data, decodeErr: errors.New("random error")}, nil
+			},
+			expectedError: errors.New("decoding product: random error"),
+		},
+		{
+			name: "error in cursor",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				data := []models.Product{
+					{
+						Uuid:        "id",
+						Name:        "name",
+						Description: "description",
+						Price:       1,
+						Attributes: map[string]interface{}{
+							"color": "blue",
+							"size":  12.0,
+						},
+					},
+				}
+				return &MockCursor{data: data, err: errors.New("random error")}, nil
+			},
+			expectedError: errors.New("cursor error: random error"),
+		},
+		{
+			name:  "page with next page token",
+			input: &productcatalog.ListProductsRequest{PageSize: 1},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, int64(2), *opts[0].Limit)
+				data := []models.Product{
+					{Uuid: "id", Name: "name"},
+					{Uuid: "id2", Name: "name2"},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id", Name: "name"},
+			},
+			expectedNextPageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{"id"}, Checksum: queryChecksum("", "uuid")}),
+		},
+		{
+			name:  "page from page token",
+			input: &productcatalog.ListProductsRequest{PageSize: 1, PageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{"id"}, Checksum: queryChecksum("", "uuid")})},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{"uuid": bson.M{"$gt": "id"}}, filter)
+				data := []models.Product{
+					{Uuid: "id2", Name: "name2"},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id2", Name: "name2"},
+			},
+		},
+		{
+			name:  "filtered page from page token",
+			input: &productcatalog.ListProductsRequest{Filter: `name = "name2"`, PageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{"id"}, Checksum: queryChecksum(`name = "name2"`, "uuid")})},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				expectedFilter := bson.M{"$and": bson.A{
+					bson.M{"name": bson.M{"$eq": "name2"}},
+					bson.M{"uuid": bson.M{"$gt": "id"}},
+				}}
+				require.Equal(t, expectedFilter, filter)
+				data := []models.Product{
+					{Uuid: "id2", Name: "name2"},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id2", Name: "name2"},
+			},
+		},
+		{
+			name:  "ordered page with next page token",
+			input: &productcatalog.ListProductsRequest{PageSize: 1, OrderBy: "price desc"},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.D{{Key: "price", Value: -1}, {Key: "uuid", Value: 1}}, opts[0].Sort)
+				data := []models.Product{
+					{Uuid: "id2", Name: "name2", Price: 12},
+					{Uuid: "id", Name: "name", Price: 1},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id2", Name: "name2", Price: 12},
+			},
+			expectedNextPageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{12.0, "id2"}, Checksum: queryChecksum("", "price desc,uuid")}),
+		},
+		{
+			name:  "ordered page from page token",
+			input: &productcatalog.ListProductsRequest{PageSize: 1, OrderBy: "price desc", PageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{12.0, "id2"}, Checksum: queryChecksum("", "price desc,uuid")})},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				expectedFilter := bson.M{"$or": bson.A{
+					bson.M{"$or": bson.A{bson.M{"price": bson.M{"$lt": 12.0}}, bson.M{"price": nil}}},
+					bson.M{"$and": bson.A{bson.M{"price": bson.M{"$eq": 12.0}}, bson.M{"uuid": bson.M{"$gt": "id2"}}}},
+				}}
+				require.Equal(t, expectedFilter, filter)
+				data := []models.Product{
+					{Uuid: "id", Name: "name", Price: 1},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id", Name: "name", Price: 1},
+			},
+		},
+		{
+			name:          "page token used with a different ordering",
+			input:         &productcatalog.ListProductsRequest{OrderBy: "name", PageToken: mustEncodePageToken(&pageToken{LastValues: bson.A{12.0, "id2"}, Checksum: queryChecksum("", "price desc,uuid")})},
+			expectedError: errors.New("invalid argument: page_token: was issued for a request with different parameters"),
+		},
+		{
+			name:          "invalid order by",
+			input:         &productcatalog.ListProductsRequest{OrderBy: "price sideways"},
+			expectedError: errors.New(`invalid argument: order_by: invalid direction "sideways" for field "price", must be asc or desc`),
+		},
+		{
+			name:          "invalid filter",
+			input:         &productcatalog.ListProductsRequest{Filter: "name ="},
+			expectedError: errors.New("invalid argument: filter: expected a value at position 6, got end of filter"),
+		},
+		{
+			name:          "invalid page token",
+			input:         &productcatalog.ListProductsRequest{PageToken: "invalid"},
+			expectedError: errors.New("invalid argument: page_token: is not a valid page token"),
+		},
+		{
+			name:          "negative page size",
+			input:         &productcatalog.ListProductsRequest{PageSize: -1},
+			expectedError: errors.New("invalid argument: page_size: must not be negative"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFind: tc.mockFind}}
+			input := tc.input
+			if input == nil {
+				input = &productcatalog.ListProductsRequest{}
+			}
+			output, nextPageToken, err := repo.List(context.TODO(), input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+				require.Equal(t, tc.expectedNextPageToken, nextPageToken)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          *productcatalog.StreamProductsRequest
+		mockFind       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		mockSend       func(p *models.Product) error
+		expectedOutput []*models.Product
+		expectedError  error
+	}{
+		{
+			name:  "happy path",
+			input: &productcatalog.StreamProductsRequest{Filter: "price > 0", OrderBy: "price desc"},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{"price": bson.M{"$gt": 0.0}}, filter)
+				require.Equal(t, bson.D{{Key: "price", Value: -1}, {Key: "uuid", Value: 1}}, opts[0].Sort)
+				require.Nil(t, opts[0].Limit)
+				data := []models.Product{
+					{Uuid: "id2", Name: "name2", Price: 12},
+					{Uuid: "id", Name: "name", Price: 1},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			expectedOutput: []*models.Product{
+				{Uuid: "id2", Name: "name2", Price: 12},
+				{Uuid: "id", Name: "name", Price: 1},
+			},
+		},
+		{
+			name: "no filter",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{}, filter)
+				return &MockCursor{}, nil
+			},
+		},
+		{
+			name: "error when sending product",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				data := []models.Product{
+					{Uuid: "id", Name: "name"},
+					{Uuid: "id2", Name: "name2"},
+				}
+				return &MockCursor{data: data}, nil
+			},
+			mockSend: func(p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("random error"),
+		},
+		{
+			name: "error when finding products",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("finding products: random error"),
+		},
+		{
+			name: "error when decoding product",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{data: []models.Product{{Uuid: "id"}}, decodeErr: errors.New("random error")}, nil
+			},
+			expectedError: errors.New("decoding product: random error"),
+		},
+		{
+			name: "error in cursor",
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{err: errors.New("random error")}, nil
+			},
+			expectedError: errors.New("cursor error: random error"),
+		},
+		{
+			name:          "invalid filter",
+			input:         &productcatalog.StreamProductsRequest{Filter: "name ="},
+			expectedError: errors.New("invalid argument: filter: expected a value at position 6, got end of filter"),
+		},
+		{
+			name:          "invalid order by",
+			input:         &productcatalog.StreamProductsRequest{OrderBy: "price sideways"},
+			expectedError: errors.New(`invalid argument: order_by: invalid direction "sideways" for field "price", must be asc or desc`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFind: tc.mockFind}}
+			input := tc.input
+			if input == nil {
+				input = &productcatalog.StreamProductsRequest{}
+			}
+			var output []*models.Product
+			send := tc.mockSend
+			if send == nil {
+				send = func(p *models.Product) error {
+					output = append(output, p)
+					return nil
+				}
+			}
+			err := repo.Stream(context.TODO(), input, send)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestBatchCreate(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		input                     []*models.Product
+		allOrNothing              bool
+		mockBulkWrite             func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+		mockTransactionsSupported func(ctx context.Context) (bool, error)
+		mockWithTransaction       func(ctx context.Context, fn func(ctx context.Context) error) error
+		mockFindProductType       func(ctx context.Context, name string, t *models.ProductType) error
+		expectedOutput            []BatchResult
+		expectedError             error
+	}{
+		{
+			name:  "happy path",
+			input: []*models.Product{{Name: "a"}, {Name: ""}, {Name: "b"}},
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.False(t, ordered)
+				require.Len(t, models, 2)
+				return &mongo.BulkWriteResult{InsertedCount: 2}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "uuid1", Name: "a", Revision: 1}},
+				{Err: errors.New("invalid argument: name: must not be empty")},
+				{Product: &models.Product{Uuid: "uuid2", Name: "b", Revision: 1}},
+			},
+		},
+		{
+			name: "products checked against their type",
+			input: []*models.Product{
+				{Name: "a", ProductType: "shirt", Attributes: map[string]interface{}{"size": "M"}},
+				{Name: "b", ProductType: "shirt"},
+				{Name: "c", ProductType: "hat"},
+			},
+			mockFindProductType: func() func(ctx context.Context, name string, pt *models.ProductType) error {
+				read := map[string]bool{}
+				return func(ctx context.Context, name string, pt *models.ProductType) error {
+					require.False(t, read[name], "product type read twice")
+					read[name] = true
+					if name != "shirt" {
+						return mongo.ErrNoDocuments
+					}
+					pt.Name = "shirt"
+					pt.Attributes = map[string]models.AttributeSchema{"size": {Type: models.AttributeString, Required: true}}
+					return nil
+				}
+			}(),
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.Len(t, models, 1)
+				return &mongo.BulkWriteResult{InsertedCount: 1}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "uuid1", Name: "a", ProductType: "shirt", Attributes: map[string]interface{}{"size": "M"}, Revision: 1}},
+				{Err: errors.New("invalid argument: attributes.size: is required")},
+				{Err: errors.New(`invalid argument: product_type: product type "hat" does not exist`)},
+			},
+		},
+		{
+			name:  "write error",
+			input: []*models.Product{{Name: "a"}, {Name: "b"}},
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return &mongo.BulkWriteResult{InsertedCount: 1}, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+					{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+				}}
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "uuid1", Name: "a", Revision: 1}},
+				{Err: errors.New(`inserting product with uuid "uuid2": duplicate key`)},
+			},
+		},
+		{
+			name:  "error when writing products",
+			input: []*models.Product{{Name: "a"}},
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("writing products: random error"),
+		},
+		{
+			name:          "empty batch",
+			expectedError: errors.New("invalid argument: products: must not be empty"),
+		},
+		{
+			name:         "all or nothing in a transaction",
+			input:        []*models.Product{{Name: "a"}},
+			allOrNothing: true,
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.True(t, ordered)
+				require.Equal(t, "transaction", ctx.Value(transactionKey{}))
+				return &mongo.BulkWriteResult{InsertedCount: 1}, nil
+			},
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+			mockWithTransaction: func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(context.WithValue(ctx, transactionKey{}, "transaction"))
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "uuid1", Name: "a", Revision: 1}},
+			},
+		},
+		{
+			name:         "all or nothing without transactions",
+			input:        []*models.Product{{Name: "a"}},
+			allOrNothing: true,
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.True(t, ordered)
+				return &mongo.BulkWriteResult{InsertedCount: 1}, nil
+			},
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "uuid1", Name: "a", Revision: 1}},
+			},
+		},
+		{
+			name:         "all or nothing with an invalid product",
+			input:        []*models.Product{{Name: "a"}, {Name: ""}},
+			allOrNothing: true,
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+			expectedError: errors.New("invalid argument: products[1].name: must not be empty"),
+		},
+		{
+			name:         "all or nothing with a write error",
+			input:        []*models.Product{{Name: "a"}, {Name: "b"}},
+			allOrNothing: true,
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return &mongo.BulkWriteResult{}, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+					{WriteError: mongo.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}},
+				}}
+			},
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+			mockWithTransaction: func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			},
+			expectedError: errors.New(`products[0]: inserting product with uuid "uuid1": duplicate key`),
+		},
+		{
+			name:         "error when checking transaction support",
+			input:        []*models.Product{{Name: "a"}},
+			allOrNothing: true,
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, errors.New("random error")
+			},
+			expectedError: errors.New("checking transaction support: random error"),
+		},
+		{
+			name:         "error when running transaction",
+			input:        []*models.Product{{Name: "a"}},
+			allOrNothing: true,
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+			mockWithTransaction: func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("running transaction: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{
+				coll: &mockCollection{
+					mockBulkWrite:             tc.mockBulkWrite,
+					mockTransactionsSupported: tc.mockTransactionsSupported,
+					mockWithTransaction:       tc.mockWithTransaction,
+					mockFindProductType:       tc.mockFindProductType,
+				},
+				uuidProvider: sequentialUuids(),
+			}
+			output, err := repo.BatchCreate(context.TODO(), tc.input, tc.allOrNothing)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				requireBatchResults(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestBatchUpdate(t *testing.T) {
+	stored := []models.Product{
+		{Uuid: "id", Name: "name", Revision: 1},
+		{Uuid: "id2", Name: "name2", Revision: 3},
+	}
+	testCases := []struct {
+		name           string
+		input          []ProductUpdate
+		allOrNothing   bool
+		mockFind       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		mockBulkWrite  func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+		expectedOutput []BatchResult
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			input: []ProductUpdate{
+				{Product: &models.Product{Uuid: "id", Name: "new name"}},
+				{Product: &models.Product{Uuid: "id2", Prices: usd("2")}, Paths: []string{"prices"}, ExpectedRevision: 3},
+			},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{"uuid": bson.M{"$in": []string{"id", "id2"}}}, filter)
+				return &MockCursor{data: stored}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.Equal(t, bson.M{"uuid": "id", "revision": int64(1)}, writeModels[0].(*mongo.UpdateOneModel).Filter)
+				require.Equal(t, bson.M{"uuid": "id2", "revision": int64(3)}, writeModels[1].(*mongo.UpdateOneModel).Filter)
+				return &mongo.BulkWriteResult{MatchedCount: 2, ModifiedCount: 2}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "id", Name: "new name", Revision: 2}},
+				{Product: &models.Product{Uuid: "id2", Name: "name2", Price: 2, Prices: usd("2"), Revision: 4}},
+			},
+		},
+		{
+			name: "failed checks",
+			input: []ProductUpdate{
+				{Product: &models.Product{Uuid: "id", Name: "new name"}, ExpectedRevision: 2},
+				{Product: &models.Product{Uuid: "id3", Name: "new name"}},
+				{Product: &models.Product{Uuid: "id2"}, Paths: []string{"size"}},
+				{Product: &models.Product{Uuid: "id2"}},
+			},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{"uuid": bson.M{"$in": []string{"id", "id3", "id2"}}}, filter)
+				return &MockCursor{data: stored}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Err: errors.New(`product with uuid "id" is at revision 1, expected 2`)},
+				{Err: errors.New(`product with uuid "id3" does not exist`)},
+				{Err: errors.New(`invalid argument: update_mask: unknown or immutable field "size"`)},
+				{Product: &models.Product{Uuid: "id2", Name: "name2", Revision: 3}},
+			},
+		},
+		{
+			name: "products written concurrently",
+			input: []ProductUpdate{
+				{Product: &models.Product{Uuid: "id", Name: "new name"}},
+				{Product: &models.Product{Uuid: "id2", Name: "new name2"}},
+			},
+			mockFind: func() func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				calls := 0
+				return func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+					calls++
+					if calls == 1 {
+						return &MockCursor{data: stored}, nil
+					}
+					return &MockCursor{data: []models.Product{
+						{Uuid: "id", Name: "new name", Revision: 2},
+						{Uuid: "id2", Name: "other name", Revision: 4},
+					}}, nil
+				}
+			}(),
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return &mongo.BulkWriteResult{MatchedCount: 1, ModifiedCount: 1}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "id", Name: "new name", Revision: 2}},
+				{Err: errors.New(`product with uuid "id2" is at revision 4, expected 3`)},
+			},
+		},
+		{
+			name:  "error when finding products",
+			input: []ProductUpdate{{Product: &models.Product{Uuid: "id", Name: "new name"}}},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("finding products: random error"),
+		},
+		{
+			name:          "too many updates",
+			input:         make([]ProductUpdate, MaxBatchSize+1),
+			expectedError: errors.New("invalid argument: requests: must not have more than 1000 items"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFind: tc.mockFind, mockBulkWrite: tc.mockBulkWrite}}
+			output, err := repo.BatchUpdate(context.TODO(), tc.input, tc.allOrNothing)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				requireBatchResults(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	stored := []models.Product{
+		{Uuid: "id", Name: "name", Revision: 1},
+		{Uuid: "id2", Name: "name2", Revision: 3},
+	}
+	testCases := []struct {
+		name           string
+		input          []*productcatalog.DeleteProductRequest
+		mockFind       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		mockBulkWrite  func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+		expectedOutput []BatchResult
+		expectedError  error
+	}{
+		{
+			name:  "happy path",
+			input: []*productcatalog.DeleteProductRequest{{Uuid: "id"}, {Uuid: "id2", ExpectedRevision: 3}, {Uuid: "id3"}, {Uuid: "id", ExpectedRevision: 1}},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{data: stored}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.Equal(t, bson.M{"uuid": "id", "revision": int64(1)}, writeModels[0].(*mongo.DeleteOneModel).Filter)
+				require.Equal(t, bson.M{"uuid": "id2", "revision": int64(3)}, writeModels[1].(*mongo.DeleteOneModel).Filter)
+				return &mongo.BulkWriteResult{DeletedCount: 2}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "id", Name: "name", Revision: 1}},
+				{Product: &models.Product{Uuid: "id2", Name: "name2", Revision: 3}},
+				{Err: errors.New(`product with uuid "id3" does not exist`)},
+				{Err: errors.New("invalid argument: uuid: is repeated in the batch")},
+			},
+		},
+		{
+			name:  "products written concurrently",
+			input: []*productcatalog.DeleteProductRequest{{Uuid: "id"}, {Uuid: "id2"}},
+			mockFind: func() func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				calls := 0
+				return func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+					calls++
+					if calls == 1 {
+						return &MockCursor{data: stored}, nil
+					}
+					return &MockCursor{data: []models.Product{{Uuid: "id2", Name: "other name", Revision: 4}}}, nil
+				}
+			}(),
+			mockBulkWrite: func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return &mongo.BulkWriteResult{DeletedCount: 1}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "id", Name: "name", Revision: 1}},
+				{Err: errors.New(`product with uuid "id2" is at revision 4, expected 3`)},
+			},
+		},
+		{
+			name:  "all items failed",
+			input: []*productcatalog.DeleteProductRequest{{Uuid: ""}},
+			expectedOutput: []BatchResult{
+				{Err: errors.New("invalid argument: uuid: must not be empty")},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFind: tc.mockFind, mockBulkWrite: tc.mockBulkWrite}}
+			output, err := repo.BatchDelete(context.TODO(), tc.input, false)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				requireBatchResults(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestBatchUpsert(t *testing.T) {
+	stored := []models.Product{
+		{Uuid: "id", Name: "name", Description: "description", Revision: 2},
+	}
+	testCases := []struct {
+		name           string
+		input          []*models.Product
+		mockFind       func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		mockBulkWrite  func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+		expectedOutput []BatchResult
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			input: []*models.Product{
+				{Uuid: "id", Name: "new name", Prices: usd("1")},
+				{Uuid: "id2", Name: "name2"},
+				{Name: "name3"},
+				{Name: ""},
+			},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				require.Equal(t, bson.M{"uuid": bson.M{"$in": []string{"id", "id2", "uuid1"}}}, filter)
+				return &MockCursor{data: stored}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.False(t, ordered)
+				require.Len(t, writeModels, 3)
+				require.Equal(t, bson.M{"uuid": "id", "revision": int64(2)}, writeModels[0].(*mongo.UpdateOneModel).Filter)
+				require.Equal(t, &models.Product{Uuid: "id2", Name: "name2", Revision: 1}, writeModels[1].(*mongo.InsertOneModel).Document)
+				require.Equal(t, &models.Product{Uuid: "uuid1", Name: "name3", Revision: 1}, writeModels[2].(*mongo.InsertOneModel).Document)
+				return &mongo.BulkWriteResult{InsertedCount: 2, MatchedCount: 1, ModifiedCount: 1}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Product: &models.Product{Uuid: "id", Name: "new name", Price: 1, Prices: usd("1"), Attributes: map[string]interface{}{}, Revision: 3}},
+				{Product: &models.Product{Uuid: "id2", Name: "name2", Revision: 1}, Created: true},
+				{Product: &models.Product{Uuid: "uuid1", Name: "name3", Revision: 1}, Created: true},
+				{Err: errors.New("invalid argument: name: must not be empty")},
+			},
+		},
+		{
+			name:  "invalid uuid of a created product",
+			input: []*models.Product{{Uuid: "ID", Name: "name"}, {Uuid: "id", Name: "new name", Prices: usd("1")}},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{data: []models.Product{{Uuid: "id", Name: "name", Revision: 2}}}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				require.Len(t, writeModels, 1)
+				return &mongo.BulkWriteResult{MatchedCount: 1, ModifiedCount: 1}, nil
+			},
+			expectedOutput: []BatchResult{
+				{Err: errors.New("invalid argument: uuid: must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit")},
+				{Product: &models.Product{Uuid: "id", Name: "new name", Price: 1, Prices: usd("1"), Attributes: map[string]interface{}{}, Revision: 3}},
+			},
+		},
+		{
+			name:  "product created concurrently",
+			input: []*models.Product{{Uuid: "id2", Name: "name2"}},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return &mongo.BulkWriteResult{}, mongo.BulkWriteException{
+					WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}}},
+				}
+			},
+			expectedOutput: []BatchResult{
+				{Err: errors.New(`importing product with uuid "id2": duplicate key`)},
+			},
+		},
+		{
+			name:  "error when writing products",
+			input: []*models.Product{{Uuid: "id2", Name: "name2"}},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{}, nil
+			},
+			mockBulkWrite: func(ctx context.Context, writeModels []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("writing products: random error"),
+		},
+		{
+			name:          "empty batch",
+			expectedError: errors.New("invalid argument: products: must not be empty"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{
+				coll:         &mockCollection{mockFind: tc.mockFind, mockBulkWrite: tc.mockBulkWrite},
+				uuidProvider: sequentialUuids(),
+			}
+			output, err := repo.BatchUpsert(context.TODO(), tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				requireBatchResults(t, tc.expectedOutput, output)
+				for i := range tc.expectedOutput {
+					require.Equal(t, tc.expectedOutput[i].Created, output[i].Created, "item %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateType(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		input                 *models.ProductType
+		mockInsertProductType func(ctx context.Context, t *models.ProductType) error
+		expectedError         error
+	}{
+		{
+			name:  "happy path",
+			input: &models.ProductType{Name: "shirt"},
+			mockInsertProductType: func(ctx context.Context, pt *models.ProductType) error {
+				require.Equal(t, "shirt", pt.Name)
+				return nil
+			},
+		},
+		{
+			name:          "invalid product type",
+			input:         &models.ProductType{},
+			expectedError: errors.New("invalid argument: name: must not be empty"),
+		},
+		{
+			name:  "name taken",
+			input: &models.ProductType{Name: "shirt"},
+			mockInsertProductType: func(ctx context.Context, pt *models.ProductType) error {
+				return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+			},
+			expectedError: errors.New(`product type "shirt" already exists`),
+		},
+		{
+			name:  "error",
+			input: &models.ProductType{Name: "shirt"},
+			mockInsertProductType: func(ctx context.Context, pt *models.ProductType) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("inserting product type: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockInsertProductType: tc.mockInsertProductType}}
+			output, err := repo.CreateType(context.TODO(), tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.input, output)
+			}
+		})
+	}
+}
+
+func TestGetType(t *testing.T) {
+	testCases := []struct {
+		name                string
+		mockFindProductType func(ctx context.Context, name string, t *models.ProductType) error
+		expectedOutput      *models.ProductType
+		expectedError       error
+	}{
+		{
+			name: "happy path",
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				pt.Name = name
+				return nil
+			},
+			expectedOutput: &models.ProductType{Name: "shirt"},
+		},
+		{
+			name: "not found",
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product type "shirt" does not exist`),
+		},
+		{
+			name: "error",
+			mockFindProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`getting product type "shirt": random error`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFindProductType: tc.mockFindProductType}}
+			output, err := repo.GetType(context.TODO(), "shirt")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestListTypes(t *testing.T) {
+	repo := &MongoRepository{coll: &mockCollection{
+		mockFindProductTypes: func(ctx context.Context) ([]*models.ProductType, error) {
+			return nil, errors.New("random error")
+		},
+	}}
+	_, err := repo.ListTypes(context.TODO())
+	require.Equal(t, "listing product types: random error", err.Error())
+}
+
+func TestUpdateType(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		input                  *models.ProductType
+		mockReplaceProductType func(ctx context.Context, t *models.ProductType) (bool, error)
+		expectedError          error
+	}{
+		{
+			name:  "happy path",
+			input: &models.ProductType{Name: "shirt", Description: "shirts"},
+			mockReplaceProductType: func(ctx context.Context, pt *models.ProductType) (bool, error) {
+				require.Equal(t, "shirts", pt.Description)
+				return true, nil
+			},
+		},
+		{
+			name:  "not found",
+			input: &models.ProductType{Name: "shirt"},
+			mockReplaceProductType: func(ctx context.Context, pt *models.ProductType) (bool, error) {
+				return false, nil
+			},
+			expectedError: errors.New(`product type "shirt" does not exist`),
+		},
+		{
+			name:          "invalid product type",
+			input:         &models.ProductType{Name: "shirt", Attributes: map[string]models.AttributeSchema{"size": {}}},
+			expectedError: errors.New("invalid argument: attributes.size.type: must be set"),
+		},
+		{
+			name:  "error",
+			input: &models.ProductType{Name: "shirt"},
+			mockReplaceProductType: func(ctx context.Context, pt *models.ProductType) (bool, error) {
+				return false, errors.New("random error")
+			},
+			expectedError: errors.New(`updating product type "shirt": random error`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockReplaceProductType: tc.mockReplaceProductType}}
+			output, err := repo.UpdateType(context.TODO(), tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.input, output)
+			}
+		})
+	}
+}
+
+func TestDeleteType(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		mockFindOne           func(ctx context.Context, filter interface{}, p *models.Product) error
+		mockDeleteProductType func(ctx context.Context, name string, t *models.ProductType) error
+		expectedOutput        *models.ProductType
+		expectedError         error
+	}{
+		{
+			name: "happy path",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				require.Equal(t, bson.M{"product_type": "shirt"}, filter)
+				return mongo.ErrNoDocuments
+			},
+			mockDeleteProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				pt.Name = name
+				return nil
+			},
+			expectedOutput: &models.ProductType{Name: "shirt"},
+		},
+		{
+			name: "used by products",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return nil
+			},
+			expectedError: errors.New(`product type "shirt" is used by products`),
+		},
+		{
+			name: "error when finding products",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`finding products of type "shirt": random error`),
+		},
+		{
+			name: "not found",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockDeleteProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				return mongo.ErrNoDocuments
+			},
+			expectedError: errors.New(`product type "shirt" does not exist`),
+		},
+		{
+			name: "error",
+			mockFindOne: func(ctx context.Context, filter interface{}, p *models.Product) error {
+				return mongo.ErrNoDocuments
+			},
+			mockDeleteProductType: func(ctx context.Context, name string, pt *models.ProductType) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New(`deleting product type "shirt": random error`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{mockFindOne: tc.mockFindOne, mockDeleteProductType: tc.mockDeleteProductType}}
+			output, err := repo.DeleteType(context.TODO(), "shirt")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestEnablePreImages(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		mockTransactionsSupported func(ctx context.Context) (bool, error)
+		mockEnablePreImages       func(ctx context.Context) error
+		expectedError             error
+	}{
+		{
+			name: "happy path",
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+			mockEnablePreImages: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		{
+			name: "change streams not supported",
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+		},
+		{
+			name: "error when checking change stream support",
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, errors.New("random error")
+			},
+			expectedError: errors.New("checking change stream support: random error"),
+		},
+		{
+			name: "error when enabling pre-images",
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return true, nil
+			},
+			mockEnablePreImages: func(ctx context.Context) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("enabling pre-images: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MongoRepository{coll: &mockCollection{
+				mockTransactionsSupported: tc.mockTransactionsSupported,
+				mockEnablePreImages:       tc.mockEnablePreImages,
+			}}
+			err := repo.EnablePreImages(context.TODO())
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	token := func(data string) bson.Raw {
+		b, err := bson.Marshal(bson.M{"_data": data})
+		if err != nil {
+			panic(err)
+		}
+		return b
+	}
+	encodedToken := func(data string) string {
+		return base64.RawURLEncoding.EncodeToString(token(data))
+	}
+	supported := func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+	testCases := []struct {
+		name                      string
+		input                     string
+		canceled                  bool
+		mockTransactionsSupported func(ctx context.Context) (bool, error)
+		mockWatch                 func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error)
+		mockFind                  func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+		mockSend                  func(c *Change) error
+		expectedOutput            []*Change
+		expectedError             error
+	}{
+		{
+			name:                      "happy path",
+			input:                     encodedToken("0"),
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				require.Equal(t, token("0"), opts.ResumeAfter)
+				require.Equal(t, options.UpdateLookup, *opts.FullDocument)
+				require.Equal(t, options.WhenAvailable, *opts.FullDocumentBeforeChange)
+				return &mockChangeStream{events: []bson.M{
+					{"_id": token("1"), "operationType": "insert", "fullDocument": models.Product{Uuid: "id", Name: "name", Revision: 1}},
+					{
+						"_id":                      token("2"),
+						"operationType":            "update",
+						"fullDocument":             models.Product{Uuid: "id", Name: "new name", Revision: 2},
+						"fullDocumentBeforeChange": models.Product{Uuid: "id", Name: "name", Revision: 1},
+					},
+					{"_id": token("3"), "operationType": "drop"},
+					{"_id": token("4"), "operationType": "delete", "fullDocumentBeforeChange": models.Product{Uuid: "id", Name: "new name", Revision: 2}},
+					{"_id": token("5"), "operationType": "delete"},
+				}}, nil
+			},
+			expectedOutput: []*Change{
+				{Type: ChangeCreated, Uuid: "id", After: &models.Product{Uuid: "id", Name: "name", Revision: 1}, ResumeToken: encodedToken("1")},
+				{
+					Type:        ChangeUpdated,
+					Uuid:        "id",
+					Before:      &models.Product{Uuid: "id", Name: "name", Revision: 1},
+					After:       &models.Product{Uuid: "id", Name: "new name", Revision: 2},
+					ResumeToken: encodedToken("2"),
+				},
+				{Type: ChangeDeleted, Uuid: "id", Before: &models.Product{Uuid: "id", Name: "new name", Revision: 2}, ResumeToken: encodedToken("4")},
+				{Type: ChangeDeleted, ResumeToken: encodedToken("5")},
+			},
+		},
+		{
+			name:                      "collection dropped",
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				require.Nil(t, opts.ResumeAfter)
+				return &mockChangeStream{events: []bson.M{{"_id": token("1"), "operationType": "invalidate"}}}, nil
+			},
+			expectedError: errors.New("change stream invalidated, the products collection was dropped or renamed"),
+		},
+		{
+			name:                      "invalid resume token",
+			input:                     "not a token",
+			mockTransactionsSupported: supported,
+			expectedError:             errors.New("invalid argument: resume_token: is not a valid resume token"),
+		},
+		{
+			name:                      "resume token no longer available",
+			input:                     encodedToken("0"),
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				return nil, mongo.CommandError{Code: 286, Message: "history lost"}
+			},
+			expectedError: errors.New("invalid argument: resume_token: is no longer available"),
+		},
+		{
+			name:                      "error when watching products",
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				return nil, errors.New("random error")
+			},
+			expectedError: errors.New("watching products: random error"),
+		},
+		{
+			name:                      "change stream error",
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				return &mockChangeStream{err: errors.New("random error")}, nil
+			},
+			expectedError: errors.New("change stream error: random error"),
+		},
+		{
+			name:                      "error when sending change",
+			mockTransactionsSupported: supported,
+			mockWatch: func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+				return &mockChangeStream{events: []bson.M{{"_id": token("1"), "operationType": "insert", "fullDocument": models.Product{Uuid: "id"}}}}, nil
+			},
+			mockSend: func(c *Change) error {
+				return errors.New("random error")
+			},
+			expectedError: errors.New("random error"),
+		},
+		{
+			name:     "polling on a standalone server",
+			canceled: true,
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+			mockFind: func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+				return &MockCursor{data: []models.Product{{Uuid: "id"}}}, nil
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name:  "resuming on a standalone server",
+			input: encodedToken("0"),
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+			expectedError: errors.New("invalid argument: resume_token: is not supported by this storage backend"),
+		},
+		{
+			name: "error when checking change stream support",
+			mockTransactionsSupported: func(ctx context.Context) (bool, error) {
+				return false, errors.New("random error")
+			},
+			expectedError: errors.New("checking change stream support: random error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			if tc.canceled {
+				cancel()
+			}
+			repo := &MongoRepository{coll: &mockCollection{
+				mockTransactionsSupported: tc.mockTransactionsSupported,
+				mockWatch:                 tc.mockWatch,
+				mockFind:                  tc.mockFind,
+			}}
+			var output []*Change
+			err := repo.Watch(ctx, tc.input, func(c *Change) error {
+				if tc.mockSend != nil {
+					return tc.mockSend(c)
+				}
+				output = append(output, c)
+				return nil
+			})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+// transactionKey is the context key marking the contexts of fake transactions.
+type transactionKey struct{}
+
+// sequentialUuids returns a uuid provider returning uuid1, uuid2 and so on.
+func sequentialUuids() func() string {
+	n := 0
+	return func() string {
+		n++
+		return fmt.Sprintf("uuid%d", n)
+	}
+}
+
+// requireBatchResults checks the products and error messages of the results of a batch.
+func requireBatchResults(t *testing.T, expected, actual []BatchResult) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].Product, actual[i].Product, "item %d", i)
+		if expected[i].Err == nil {
+			require.Nil(t, actual[i].Err, "item %d", i)
+		} else {
+			require.NotNil(t, actual[i].Err, "item %d", i)
+			require.Equal(t, expected[i].Err.Error(), actual[i].Err.Error(), "item %d", i)
+		}
+	}
+}
+
+func mustEncodePageToken(token *pageToken) string {
+	s, err := token.encode()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// mockCollection is a collection whose operations are implemented by the given functions.
+type mockCollection struct {
+	mockInsertOne             func(ctx context.Context, document interface{}) error
+	mockFind                  func(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+	mockFindOne               func(ctx context.Context, filter interface{}, p *models.Product) error
+	mockFindOneAndUpdate      func(ctx context.Context, filter interface{}, update interface{}, p *models.Product) error
+	mockFindOneAndDelete      func(ctx context.Context, filter interface{}, p *models.Product) error
+	mockCreateIndexes         func(ctx context.Context, models []mongo.IndexModel) error
+	mockUpdateMany            func(ctx context.Context, filter interface{}, update interface{}) error
+	mockBulkWrite             func(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error)
+	mockTransactionsSupported func(ctx context.Context) (bool, error)
+	mockWithTransaction       func(ctx context.Context, fn func(ctx context.Context) error) error
+	mockWatch                 func(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error)
+	mockEnablePreImages       func(ctx context.Context) error
+	// Idempotency keys.
+	mockCreateIdempotencyKeyIndexes func(ctx context.Context, models []mongo.IndexModel) error
+	mockInsertIdempotencyKey        func(ctx context.Context, key *idempotencyKey) error
+	mockFindIdempotencyKey          func(ctx context.Context, requestId string, key *idempotencyKey) error
+	mockCommitIdempotencyKey        func(ctx context.Context, requestId string) error
+	mockDeleteIdempotencyKey        func(ctx context.Context, filter interface{}) error
+	// Product types.
+	mockInsertProductType  func(ctx context.Context, t *models.ProductType) error
+	mockFindProductType    func(ctx context.Context, name string, t *models.ProductType) error
+	mockFindProductTypes   func(ctx context.Context) ([]*models.ProductType, error)
+	mockReplaceProductType func(ctx context.Context, t *models.ProductType) (bool, error)
+	mockDeleteProductType  func(ctx context.Context, name string, t *models.ProductType) error
+}
+
+func (m *mockCollection) insertOne(ctx context.Context, document interface{}) error {
+	return m.mockInsertOne(ctx, document)
+}
+
+func (m *mockCollection) find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+	return m.mockFind(ctx, filter, opts...)
+}
+
+func (m *mockCollection) findOne(ctx context.Context, filter interface{}, p *models.Product) error {
+	return m.mockFindOne(ctx, filter, p)
+}
+
+func (m *mockCollection) findOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, p *models.Product) error {
+	return m.mockFindOneAndUpdate(ctx, filter, update, p)
+}
+
+func (m *mockCollection) findOneAndDelete(ctx context.Context, filter interface{}, p *models.Product) error {
+	return m.mockFindOneAndDelete(ctx, filter, p)
+}
+
+func (m *mockCollection) createIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.mockCreateIndexes(ctx, models)
+}
+
+func (m *mockCollection) updateMany(ctx context.Context, filter interface{}, update interface{}) error {
+	return m.mockUpdateMany(ctx, filter, update)
+}
+
+func (m *mockCollection) bulkWrite(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+	return m.mockBulkWrite(ctx, models, ordered)
+}
+
+func (m *mockCollection) transactionsSupported(ctx context.Context) (bool, error) {
+	return m.mockTransactionsSupported(ctx)
+}
+
+func (m *mockCollection) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.mockWithTransaction(ctx, fn)
+}
+
+func (m *mockCollection) watch(ctx context.Context, pipeline interface{}, opts *options.ChangeStreamOptions) (Cursor, error) {
+	return m.mockWatch(ctx, pipeline, opts)
+}
+
+func (m *mockCollection) enablePreImages(ctx context.Context) error {
+	return m.mockEnablePreImages(ctx)
+}
+
+func (m *mockCollection) createIdempotencyKeyIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.mockCreateIdempotencyKeyIndexes(ctx, models)
+}
+
+func (m *mockCollection) insertIdempotencyKey(ctx context.Context, key *idempotencyKey) error {
+	return m.mockInsertIdempotencyKey(ctx, key)
+}
+
+func (m *mockCollection) findIdempotencyKey(ctx context.Context, requestId string, key *idempotencyKey) error {
+	return m.mockFindIdempotencyKey(ctx, requestId, key)
+}
+
+func (m *mockCollection) commitIdempotencyKey(ctx context.Context, requestId string) error {
+	return m.mockCommitIdempotencyKey(ctx, requestId)
+}
+
+func (m *mockCollection) deleteIdempotencyKey(ctx context.Context, filter interface{}) error {
+	return m.mockDeleteIdempotencyKey(ctx, filter)
+}
+
+type MockCursor struct {
+	data      []models.Product
+	index     int
+	decodeErr error
+	err       error
+}
+
+func (m *MockCursor) Next(ctx context.Context) bool {
+	if m.index < len(m.data) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *MockCursor) Decode(val interface{}) error {
+	if m.decodeErr != nil {
+		return m.decodeErr
+	}
+	product, ok := val.(*models.Product)
+	if !ok {
+		return errors.New("Decode type not *models.Product")
+	}
+	if m.index <= 0 || m.index > len(m.data) {
+		return errors.New("No data to decode")
+	}
+	*product = m.data[m.index-1]
+	return nil
+}
+
+func (m *MockCursor) Err() error {
+	return m.err
+}
+
+func (m *MockCursor) Close(ctx context.Context) error {
+	return nil
+}
+
+// mockChangeStream is a change stream returning the given events,
+// which are decoded as the real ones are, through BSON.
+type mockChangeStream struct {
+	events []bson.M
+	index  int
+	err    error
+}
+
+func (m *mockChangeStream) Next(ctx context.Context) bool {
+	if m.index < len(m.events) {
+		m.index++
+		return true
+	}
+	return false
+}
+
+func (m *mockChangeStream) Decode(val interface{}) error {
+	b, err := bson.Marshal(m.events[m.index-1])
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(b, val)
+}
+
+func (m *mockChangeStream) Err() error {
+	return m.err
+}
+
+func (m *mockChangeStream) Close(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCollection) insertProductType(ctx context.Context, t *models.ProductType) error {
+	return m.mockInsertProductType(ctx, t)
+}
+
+func (m *mockCollection) findProductType(ctx context.Context, name string, t *models.ProductType) error {
+	return m.mockFindProductType(ctx, name, t)
+}
+
+func (m *mockCollection) findProductTypes(ctx context.Context) ([]*models.ProductType, error) {
+	return m.mockFindProductTypes(ctx)
+}
+
+func (m *mockCollection) replaceProductType(ctx context.Context, t *models.ProductType) (bool, error) {
+	return m.mockReplaceProductType(ctx, t)
+}
+
+func (m *mockCollection) deleteProductType(ctx context.Context, name string, t *models.ProductType) error {
+	return m.mockDeleteProductType(ctx, name, t)
+}
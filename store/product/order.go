@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order by expressions follow AIP-132: a comma separated list of fields,
+// each optionally followed by "asc" or "desc", such as
+//
+//	name, price desc, attributes.weight asc
+//
+// The same fields accepted by filters can be used. Products are always
+// tie-broken by uuid, so that the ordering is total and pages remain
+// consistent when sorting by non-unique keys.
+//
+// Pagination resumes from the sort key values of the last product of a page.
+// MongoDB only compares values of the same type in queries, so attributes
+// used for sorting are expected to hold values of a single type across products.
+
+// uuidField is the field used to tie-break the ordering of products.
+const uuidField = "uuid"
+
+// sortKey is a field used to order products.
+type sortKey struct {
+	field      string
+	descending bool
+}
+
+// parseOrderBy parses an order by expression, returning the sort keys
+// with the uuid tie-break appended.
+func parseOrderBy(orderBy string) ([]sortKey, error) {
+	var keys []sortKey
+	seen := make(map[string]bool)
+	if strings.TrimSpace(orderBy) != "" {
+		for _, item := range strings.Split(orderBy, ",") {
+			parts := strings.Fields(item)
+			if len(parts) == 0 || len(parts) > 2 {
+				return nil, orderByError("invalid item %q", strings.TrimSpace(item))
+			}
+			key := sortKey{field: parts[0]}
+			if len(parts) == 2 {
+				switch strings.ToLower(parts[1]) {
+				case "asc":
+				case "desc":
+					key.descending = true
+				default:
+					return nil, orderByError("invalid direction %q for field %q, must be asc or desc", parts[1], parts[0])
+				}
+			}
+			if _, err := lookupField(key.field); err != nil {
+				return nil, orderByError("%v", err)
+			}
+			if seen[key.field] {
+				return nil, orderByError("field %q is repeated", key.field)
+			}
+			seen[key.field] = true
+			keys = append(keys, key)
+			if key.field == uuidField {
+				// Ordering by any field after uuid makes no difference.
+				return keys, nil
+			}
+		}
+	}
+	return append(keys, sortKey{field: uuidField}), nil
+}
+
+// orderByError returns an invalid argument error for the order_by field.
+func orderByError(format string, args ...interface{}) error {
+	return invalidArgumentError(FieldViolation{Field: "order_by", Description: fmt.Sprintf(format, args...)})
+}
+
+// canonicalOrderBy returns the normalized representation of sort keys.
+func canonicalOrderBy(keys []sortKey) string {
+	items := make([]string, len(keys))
+	for i, k := range keys {
+		items[i] = k.field
+		if k.descending {
+			items[i] += " desc"
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// sortDocument translates sort keys into a MongoDB sort specification.
+func sortDocument(keys []sortKey) bson.D {
+	sort := make(bson.D, len(keys))
+	for i, k := range keys {
+		direction := 1
+		if k.descending {
+			direction = -1
+		}
+		sort[i] = bson.E{Key: k.field, Value: direction}
+	}
+	return sort
+}
+
+// afterCondition returns the MongoDB query matching the products that come
+// after the product with the given sort key values.
+// For keys k1..kn it matches k1 after v1, or k1 equal to v1 and k2 after v2,
+// and so forth. Null and missing values sort before any other value.
+func afterCondition(keys []sortKey, values []interface{}) bson.M {
+	var alternatives bson.A
+	for i, k := range keys {
+		after, ok := afterKey(k, values[i])
+		if !ok {
+			continue
+		}
+		conditions := bson.A{}
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, equalKey(keys[j], values[j]))
+		}
+		conditions = append(conditions, after)
+		if len(conditions) == 1 {
+			alternatives = append(alternatives, after)
+		} else {
+			alternatives = append(alternatives, bson.M{"$and": conditions})
+		}
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0].(bson.M)
+	}
+	return bson.M{"$or": alternatives}
+}
+
+// afterKey returns the condition matching values of the key that come after v.
+// It returns false when no value can come after v.
+func afterKey(k sortKey, v interface{}) (bson.M, bool) {
+	switch {
+	case v == nil && k.descending:
+		return nil, false
+	case v == nil:
+		return bson.M{k.field: bson.M{"$ne": nil}}, true
+	case k.descending:
+		return bson.M{"$or": bson.A{
+			bson.M{k.field: bson.M{"$lt": v}},
+			bson.M{k.field: nil},
+		}}, true
+	}
+	return bson.M{k.field: bson.M{"$gt": v}}, true
+}
+
+// equalKey returns the condition matching values of the key equal to v.
+func equalKey(k sortKey, v interface{}) bson.M {
+	if v == nil {
+		return bson.M{k.field: nil}
+	}
+	return bson.M{k.field: bson.M{"$eq": v}}
+}
+
+// sortValues returns the values of the sort keys for the given product.
+func sortValues(keys []sortKey, p *models.Product) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = fieldValue(p, k.field)
+	}
+	return values
+}
+
+// fieldValue returns the value of the field with the given path,
+// or nil if the product does not have it.
+func fieldValue(p *models.Product, path string) interface{} {
+	switch path {
+	case "uuid":
+		return p.Uuid
+	case "name":
+		return p.Name
+	case "description":
+		return p.Description
+	case "price":
+		return float64(p.Price)
+	case "sku":
+		return p.Sku
+	case "slug":
+		return p.Slug
+	case productTypeField:
+		return p.ProductType
+	}
+	keys := strings.Split(strings.TrimPrefix(path, attributesField+"."), ".")
+	var current interface{} = p.Attributes
+	for _, key := range keys {
+		var ok bool
+		switch doc := current.(type) {
+		case map[string]interface{}:
+			current, ok = doc[key]
+		case primitive.M:
+			current, ok = doc[key]
+		case primitive.D:
+			for _, e := range doc {
+				if e.Key == key {
+					current, ok = e.Value, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return current
+}
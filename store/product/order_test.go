@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		expectedOutput []sortKey
+		expectedError  error
+	}{
+		{
+			name:           "empty",
+			input:          "",
+			expectedOutput: []sortKey{{field: "uuid"}},
+		},
+		{
+			name:  "several fields",
+			input: "name, price desc,attributes.weight  ASC",
+			expectedOutput: []sortKey{
+				{field: "name"},
+				{field: "price", descending: true},
+				{field: "attributes.weight"},
+				{field: "uuid"},
+			},
+		},
+		{
+			name:  "explicit uuid",
+			input: "uuid desc, name",
+			expectedOutput: []sortKey{
+				{field: "uuid", descending: true},
+			},
+		},
+		{
+			name:          "unknown field",
+			input:         "color",
+			expectedError: errors.New(`invalid argument: order_by: unknown field "color"`),
+		},
+		{
+			name:          "invalid attribute key",
+			input:         "attributes.$natural",
+			expectedError: errors.New(`invalid argument: order_by: invalid attribute key "$natural"`),
+		},
+		{
+			name:          "invalid direction",
+			input:         "name up",
+			expectedError: errors.New(`invalid argument: order_by: invalid direction "up" for field "name", must be asc or desc`),
+		},
+		{
+			name:          "empty item",
+			input:         "name,,price",
+			expectedError: errors.New(`invalid argument: order_by: invalid item ""`),
+		},
+		{
+			name:          "repeated field",
+			input:         "name, name desc",
+			expectedError: errors.New(`invalid argument: order_by: field "name" is repeated`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := parseOrderBy(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestAfterCondition(t *testing.T) {
+	keys := []sortKey{{field: "name"}, {field: "attributes.weight", descending: true}, {field: "uuid"}}
+	testCases := []struct {
+		name           string
+		values         []interface{}
+		expectedOutput bson.M
+	}{
+		{
+			name:   "present values",
+			values: []interface{}{"name", 1.5, "uuid"},
+			expectedOutput: bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$gt": "name"}},
+				bson.M{"$and": bson.A{
+					bson.M{"name": bson.M{"$eq": "name"}},
+					bson.M{"$or": bson.A{bson.M{"attributes.weight": bson.M{"$lt": 1.5}}, bson.M{"attributes.weight": nil}}},
+				}},
+				bson.M{"$and": bson.A{
+					bson.M{"name": bson.M{"$eq": "name"}},
+					bson.M{"attributes.weight": bson.M{"$eq": 1.5}},
+					bson.M{"uuid": bson.M{"$gt": "uuid"}},
+				}},
+			}},
+		},
+		{
+			name:   "missing value in descending key",
+			values: []interface{}{"name", nil, "uuid"},
+			expectedOutput: bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$gt": "name"}},
+				bson.M{"$and": bson.A{
+					bson.M{"name": bson.M{"$eq": "name"}},
+					bson.M{"attributes.weight": nil},
+					bson.M{"uuid": bson.M{"$gt": "uuid"}},
+				}},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, afterCondition(keys, tc.values))
+		})
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	p := &models.Product{
+		Uuid:  "uuid",
+		Name:  "name",
+		Price: 2.5,
+		Attributes: map[string]interface{}{
+			"weight":     1.5,
+			"dimensions": primitive.D{{Key: "height", Value: int32(10)}},
+			"tags":       primitive.M{"main": "sale"},
+		},
+	}
+	require.Equal(t, "uuid", fieldValue(p, "uuid"))
+	require.Equal(t, "name", fieldValue(p, "name"))
+	require.Equal(t, 2.5, fieldValue(p, "price"))
+	require.Equal(t, 1.5, fieldValue(p, "attributes.weight"))
+	require.Equal(t, int32(10), fieldValue(p, "attributes.dimensions.height"))
+	require.Equal(t, "sale", fieldValue(p, "attributes.tags.main"))
+	require.Nil(t, fieldValue(p, "attributes.color"))
+	require.Nil(t, fieldValue(p, "attributes.weight.value"))
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"encoding/base64"
+	"hash/fnv"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// DefaultPageSize is the number of products returned when the page size is not informed.
+	DefaultPageSize = 50
+	// MaxPageSize is the maximum number of products returned in a single page.
+	// Larger page sizes are coerced to this value.
+	MaxPageSize = 1000
+)
+
+// pageToken holds the position from which the next page of products starts.
+// It is handed to clients as an opaque, base64 encoded BSON document.
+type pageToken struct {
+	// Values of the sort keys of the last product of the previous page.
+	LastValues bson.A `bson:"v"`
+	// Checksum of the query parameters of the request that produced the token,
+	// so that a token is not used with a different filter or ordering, as required by AIP-158.
+	Checksum int64 `bson:"c"`
+}
+
+// queryChecksum returns the checksum of the query parameters of a list request.
+func queryChecksum(params ...string) int64 {
+	h := fnv.New64a()
+	for _, p := range params {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return int64(h.Sum64())
+}
+
+// encode returns the opaque string representation of the token.
+func (t *pageToken) encode() (string, error) {
+	b, err := bson.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageToken parses a token previously returned by encode,
+// checking that it was issued for a request with the same query parameters.
+func decodePageToken(s string, checksum int64) (*pageToken, error) {
+	invalid := invalidArgumentError(FieldViolation{Field: "page_token", Description: "is not a valid page token"})
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	t := new(pageToken)
+	if err := bson.Unmarshal(b, t); err != nil || len(t.LastValues) == 0 {
+		return nil, invalid
+	}
+	if t.Checksum != checksum {
+		return nil, invalidArgumentError(FieldViolation{Field: "page_token", Description: "was issued for a request with different parameters"})
+	}
+	return t, nil
+}
+
+// pageSize validates the requested page size and applies the default and maximum values.
+func pageSize(requested int32) (int, error) {
+	switch {
+	case requested < 0:
+		return 0, invalidArgumentError(FieldViolation{Field: "page_size", Description: "must not be negative"})
+	case requested == 0:
+		return DefaultPageSize, nil
+	case requested > MaxPageSize:
+		return MaxPageSize, nil
+	}
+	return int(requested), nil
+}
+
+// listQuery holds the validated parameters of a list request,
+// shared by the repository implementations.
+type listQuery struct {
+	size     int
+	filter   filterNode // Nil when the request has no filter.
+	keys     []sortKey
+	checksum int64
+	// Values of the sort keys of the last product of the previous page,
+	// nil when listing the first page.
+	after []interface{}
+}
+
+// parseListRequest validates a list request.
+func parseListRequest(req *productcatalog.ListProductsRequest) (*listQuery, error) {
+	size, err := pageSize(req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	node, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseOrderBy(req.GetOrderBy())
+	if err != nil {
+		return nil, err
+	}
+	q := &listQuery{
+		size:     size,
+		filter:   node,
+		keys:     keys,
+		checksum: queryChecksum(req.GetFilter(), canonicalOrderBy(keys)),
+	}
+	if req.GetPageToken() != "" {
+		token, err := decodePageToken(req.GetPageToken(), q.checksum)
+		if err != nil {
+			return nil, err
+		}
+		if len(token.LastValues) != len(keys) {
+			return nil, invalidArgumentError(FieldViolation{Field: "page_token", Description: "is not a valid page token"})
+		}
+		q.after = token.LastValues
+	}
+	return q, nil
+}
+
+// parseStreamRequest validates a stream request.
+// The query it returns has no page size, as products are streamed until exhausted.
+func parseStreamRequest(req *productcatalog.StreamProductsRequest) (*listQuery, error) {
+	node, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseOrderBy(req.GetOrderBy())
+	if err != nil {
+		return nil, err
+	}
+	return &listQuery{filter: node, keys: keys}, nil
+}
+
+// nextPageToken returns the token for the page that starts after the given product.
+func (q *listQuery) nextPageToken(last *models.Product) (string, error) {
+	token := &pageToken{LastValues: sortValues(q.keys, last), Checksum: q.checksum}
+	s, err := token.encode()
+	if err != nil {
+		return "", errors.Wrap(err, "encoding page token")
+	}
+	return s, nil
+}
+
+// isAfter reports whether a product comes after the last product of the previous page.
+func (q *listQuery) isAfter(p *models.Product) bool {
+	return q.after == nil || compareSortValues(q.keys, sortValues(q.keys, p), q.after) > 0
+}
+
+// page sorts the candidate products of a query and returns the requested page,
+// along with the token for the next page.
+// The candidates must include every product that matches the query
+// and could be part of the page.
+func (q *listQuery) page(candidates []*models.Product) ([]*models.Product, string, error) {
+	values := make(map[*models.Product][]interface{}, len(candidates))
+	for _, p := range candidates {
+		values[p] = sortValues(q.keys, p)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return compareSortValues(q.keys, values[candidates[i]], values[candidates[j]]) < 0
+	})
+	start := 0
+	if q.after != nil {
+		start = sort.Search(len(candidates), func(i int) bool {
+			return compareSortValues(q.keys, values[candidates[i]], q.after) > 0
+		})
+	}
+	products := candidates[start:]
+	if len(products) <= q.size {
+		return products, "", nil
+	}
+	products = products[:q.size]
+	nextPageToken, err := q.nextPageToken(products[q.size-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return products, nextPageToken, nil
+}
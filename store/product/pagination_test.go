@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPageToken(t *testing.T) {
+	checksum := queryChecksum(`name = "name"`)
+	encoded, err := (&pageToken{LastValues: bson.A{"name", "uuid"}, Checksum: checksum}).encode()
+	require.Nil(t, err)
+	decoded, err := decodePageToken(encoded, checksum)
+	require.Nil(t, err)
+	require.Equal(t, &pageToken{LastValues: bson.A{"name", "uuid"}, Checksum: checksum}, decoded)
+
+	_, err = decodePageToken(encoded, queryChecksum(`name = "other"`))
+	require.Equal(t, "invalid argument: page_token: was issued for a request with different parameters", err.Error())
+
+	_, err = decodePageToken("not a token", checksum)
+	require.Equal(t, "invalid argument: page_token: is not a valid page token", err.Error())
+}
+
+func TestPageSize(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          int32
+		expectedOutput int
+		expectedError  error
+	}{
+		{
+			name:           "default",
+			input:          0,
+			expectedOutput: DefaultPageSize,
+		},
+		{
+			name:           "requested",
+			input:          10,
+			expectedOutput: 10,
+		},
+		{
+			name:           "coerced to maximum",
+			input:          MaxPageSize + 1,
+			expectedOutput: MaxPageSize,
+		},
+		{
+			name:          "negative",
+			input:         -1,
+			expectedError: errors.New("invalid argument: page_size: must not be negative"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := pageSize(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
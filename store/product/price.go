@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// A product has prices in several currencies, at most one each, whose amounts are
+// decimals. Its price field holds the amount of the first of them as a float for
+// the clients, filters, orderings and indexes written before prices existed:
+// it is derived from the prices on every write, and never written on its own.
+
+// pricesField is the field of a product holding its prices.
+const pricesField = "prices"
+
+// LegacyPriceCurrency is the currency given to the price of the products
+// written before prices existed.
+const LegacyPriceCurrency = "USD"
+
+// currencyCodePattern matches the alphabetic currency codes of ISO 4217.
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validatePrices checks the prices of a product that are about to be written.
+func validatePrices(prices []models.Money) []FieldViolation {
+	var violations []FieldViolation
+	currencies := make(map[string]bool, len(prices))
+	for i, m := range prices {
+		field := fmt.Sprintf("%s[%d]", pricesField, i)
+		switch {
+		case !currencyCodePattern.MatchString(m.CurrencyCode):
+			violations = append(violations, FieldViolation{
+				Field:       field + ".currency_code",
+				Description: "must be three uppercase letters, as in ISO 4217",
+			})
+		case currencies[m.CurrencyCode]:
+			violations = append(violations, FieldViolation{
+				Field:       field + ".currency_code",
+				Description: fmt.Sprintf(`must not repeat currency "%s"`, m.CurrencyCode),
+			})
+		}
+		currencies[m.CurrencyCode] = true
+		if n, _, err := m.Amount.BigInt(); err != nil || n.Sign() < 0 {
+			violations = append(violations, FieldViolation{Field: field + ".amount", Description: "must be a number that is not negative"})
+		}
+	}
+	return violations
+}
+
+// legacyPrice returns the amount of the first of the prices of a product as a float,
+// or zero when it has none.
+func legacyPrice(prices []models.Money) float32 {
+	if len(prices) == 0 {
+		return 0
+	}
+	// Amounts were validated, so they always parse.
+	f, _ := strconv.ParseFloat(prices[0].Amount.String(), 32)
+	return float32(f)
+}
+
+// samePrices reports whether two products have the same prices,
+// not telling missing prices apart from an empty list.
+func samePrices(a, b []models.Money) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// money returns a price in the given currency, parsing its amount.
+func money(currencyCode, amount string) models.Money {
+	d, err := primitive.ParseDecimal128(amount)
+	if err != nil {
+		panic(err)
+	}
+	return models.Money{CurrencyCode: currencyCode, Amount: d}
+}
+
+// usd returns the prices of a product with a single price in US dollars.
+func usd(amount string) []models.Money {
+	return []models.Money{money("USD", amount)}
+}
+
+func TestValidatePrices(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          []models.Money
+		expectedOutput []FieldViolation
+	}{
+		{
+			name: "valid",
+			input: []models.Money{
+				money("USD", "19.99"),
+				money("EUR", "0"),
+				money("JPY", "1E+3"),
+			},
+		},
+		{
+			name: "invalid currency codes",
+			input: []models.Money{
+				money("usd", "1"),
+				money("", "1"),
+				money("EURO", "1"),
+			},
+			expectedOutput: []FieldViolation{
+				{Field: "prices[0].currency_code", Description: "must be three uppercase letters, as in ISO 4217"},
+				{Field: "prices[1].currency_code", Description: "must be three uppercase letters, as in ISO 4217"},
+				{Field: "prices[2].currency_code", Description: "must be three uppercase letters, as in ISO 4217"},
+			},
+		},
+		{
+			name:  "repeated currency",
+			input: []models.Money{money("USD", "1"), money("EUR", "1"), money("USD", "2")},
+			expectedOutput: []FieldViolation{
+				{Field: "prices[2].currency_code", Description: `must not repeat currency "USD"`},
+			},
+		},
+		{
+			name:  "invalid amounts",
+			input: []models.Money{money("USD", "-0.01"), money("EUR", "NaN"), money("GBP", "Infinity")},
+			expectedOutput: []FieldViolation{
+				{Field: "prices[0].amount", Description: "must be a number that is not negative"},
+				{Field: "prices[1].amount", Description: "must be a number that is not negative"},
+				{Field: "prices[2].amount", Description: "must be a number that is not negative"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, validatePrices(tc.input))
+		})
+	}
+}
+
+func TestLegacyPrice(t *testing.T) {
+	require.Equal(t, float32(0), legacyPrice(nil))
+	require.Equal(t, float32(19.99), legacyPrice([]models.Money{money("USD", "19.99"), money("EUR", "18.50")}))
+	require.Equal(t, float32(1000), legacyPrice(usd("1.000E+3")))
+}
+
+func TestSamePrices(t *testing.T) {
+	require.True(t, samePrices(nil, []models.Money{}))
+	require.True(t, samePrices(usd("1"), usd("1")))
+	require.False(t, samePrices(usd("1"), usd("1.0")))
+	require.False(t, samePrices(usd("1"), []models.Money{money("EUR", "1")}))
+	require.False(t, samePrices(usd("1"), nil))
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// A product may name a product type, whose schema its attributes must follow
+// whenever the product is written: attributes must have the type of their schema,
+// required attributes must be present, and attributes without a schema are only
+// allowed when the type allows additional attributes. Products without a type
+// have free-form attributes. Changing the schema of a type does not check the
+// products already written, and types used by products cannot be deleted.
+// Types may instead give a JSON Schema document, see jsonschema.go.
+
+// productTypeField is the field of a product naming its type.
+const productTypeField = "product_type"
+
+// attributeTypes are the types an attribute schema can have.
+var attributeTypes = map[string]bool{
+	models.AttributeString:  true,
+	models.AttributeNumber:  true,
+	models.AttributeInteger: true,
+	models.AttributeBool:    true,
+	models.AttributeList:    true,
+	models.AttributeStruct:  true,
+}
+
+// validateProductTypeName checks the name of a product type, which follows the format of product ids.
+func validateProductTypeName(field, name string) []FieldViolation {
+	switch {
+	case name == "":
+		return []FieldViolation{{Field: field, Description: "must not be empty"}}
+	case !productIdPattern.MatchString(name):
+		return []FieldViolation{{
+			Field:       field,
+			Description: "must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit",
+		}}
+	}
+	return nil
+}
+
+// validateProductType checks a product type that is about to be written.
+func validateProductType(t *models.ProductType) error {
+	violations := validateProductTypeName("name", t.Name)
+	keys := make([]string, 0, len(t.Attributes))
+	for k := range t.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field := attributesField + "." + k
+		if !validAttributeKey(k) {
+			violations = append(violations, FieldViolation{Field: field, Description: "must be a valid attribute key"})
+			continue
+		}
+		violations = append(violations, validateAttributeSchema(field, t.Attributes[k])...)
+	}
+	if t.JsonSchema != "" {
+		if len(t.Attributes) > 0 {
+			violations = append(violations, FieldViolation{Field: jsonSchemaField, Description: "must not be set along with attributes"})
+		} else {
+			violations = append(violations, validateJSONSchema(t.JsonSchema)...)
+		}
+	}
+	if len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return nil
+}
+
+// validateAttributeSchema checks the schema of the attribute at the given field.
+func validateAttributeSchema(field string, s models.AttributeSchema) []FieldViolation {
+	if !attributeTypes[s.Type] {
+		return []FieldViolation{{Field: field + ".type", Description: "must be set"}}
+	}
+	var violations []FieldViolation
+	numeric := s.Type == models.AttributeNumber || s.Type == models.AttributeInteger
+	if (s.Minimum != nil || s.Maximum != nil) && !numeric {
+		violations = append(violations, FieldViolation{Field: field, Description: "minimum and maximum only apply to numbers and integers"})
+	}
+	if s.Minimum != nil && s.Maximum != nil && *s.Minimum > *s.Maximum {
+		violations = append(violations, FieldViolation{Field: field + ".maximum", Description: "must not be less than minimum"})
+	}
+	if s.Pattern != "" {
+		if s.Type != models.AttributeString {
+			violations = append(violations, FieldViolation{Field: field + ".pattern", Description: "only applies to strings"})
+		} else if _, err := regexp.Compile(s.Pattern); err != nil {
+			violations = append(violations, FieldViolation{Field: field + ".pattern", Description: "must be a valid regular expression"})
+		}
+	}
+	for i, v := range s.AllowedValues {
+		if desc := checkAttributeType(s.Type, v); desc != "" {
+			violations = append(violations, FieldViolation{Field: fmt.Sprintf("%s.allowed_values[%d]", field, i), Description: desc})
+		}
+	}
+	return violations
+}
+
+// validateAttributes checks the attributes of a product against the schema of its type,
+// returning a violation for each attribute that does not follow it.
+func validateAttributes(t *models.ProductType, attributes map[string]interface{}) []FieldViolation {
+	if t.JsonSchema != "" {
+		return validateJSONAttributes(t.JsonSchema, attributes)
+	}
+	var violations []FieldViolation
+	keys := make([]string, 0, len(t.Attributes)+len(attributes))
+	for k := range t.Attributes {
+		keys = append(keys, k)
+	}
+	for k := range attributes {
+		if _, ok := t.Attributes[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field := attributesField + "." + k
+		s, known := t.Attributes[k]
+		v, present := attributes[k]
+		switch {
+		case !known:
+			if !t.AllowAdditionalAttributes {
+				violations = append(violations, FieldViolation{Field: field, Description: fmt.Sprintf(`is not defined by product type "%s"`, t.Name)})
+			}
+		case !present:
+			if s.Required {
+				violations = append(violations, FieldViolation{Field: field, Description: "is required"})
+			}
+		default:
+			if desc := checkAttributeValue(s, v); desc != "" {
+				violations = append(violations, FieldViolation{Field: field, Description: desc})
+			}
+		}
+	}
+	return violations
+}
+
+// checkAttributeValue checks an attribute value against its schema,
+// returning the description of the violation, if any.
+func checkAttributeValue(s models.AttributeSchema, v interface{}) string {
+	if desc := checkAttributeType(s.Type, v); desc != "" {
+		return desc
+	}
+	if len(s.AllowedValues) > 0 {
+		allowed := false
+		for _, a := range s.AllowedValues {
+			if compareValues(a, v) == 0 {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "must be one of the allowed values"
+		}
+	}
+	if n, ok := asNumber(v); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			return fmt.Sprintf("must not be less than %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return fmt.Sprintf("must not be greater than %v", *s.Maximum)
+		}
+	}
+	if str, ok := v.(string); ok && s.Pattern != "" {
+		// The pattern was checked when the type was written.
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+			return fmt.Sprintf("must match %s", s.Pattern)
+		}
+	}
+	return ""
+}
+
+// checkAttributeType checks that a value has the given attribute type,
+// returning the description of the violation, if any.
+func checkAttributeType(attributeType string, v interface{}) string {
+	ok := false
+	switch attributeType {
+	case models.AttributeString:
+		_, ok = v.(string)
+	case models.AttributeNumber:
+		_, ok = asNumber(v)
+	case models.AttributeInteger:
+		var n float64
+		n, ok = asNumber(v)
+		ok = ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case models.AttributeBool:
+		_, ok = v.(bool)
+	case models.AttributeList:
+		_, ok = asList(v)
+	case models.AttributeStruct:
+		_, ok = asDocument(v)
+	}
+	if !ok {
+		return "must be of type " + attributeType
+	}
+	return ""
+}
+
+// checkProductType checks that the attributes of a product follow the schema of its type,
+// given by productType, which returns nil when the type does not exist.
+func checkProductType(p *models.Product, productType func(name string) (*models.ProductType, error)) error {
+	if p.ProductType == "" {
+		return nil
+	}
+	t, err := productType(p.ProductType)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return invalidArgumentError(FieldViolation{
+			Field:       productTypeField,
+			Description: fmt.Sprintf(`product type "%s" does not exist`, p.ProductType),
+		})
+	}
+	if violations := validateAttributes(t, p.Attributes); len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return nil
+}
+
+// cloneProductType returns a deep copy of a product type.
+func cloneProductType(t *models.ProductType) *models.ProductType {
+	c := *t
+	if t.Attributes != nil {
+		c.Attributes = make(map[string]models.AttributeSchema, len(t.Attributes))
+		for k, s := range t.Attributes {
+			s.AllowedValues = append([]interface{}(nil), s.AllowedValues...)
+			c.Attributes[k] = s
+		}
+	}
+	return &c
+}
+
+// productTypeNotFoundError returns an error stating that the product type with the given name does not exist.
+func productTypeNotFoundError(name string) error {
+	return &Error{
+		Kind: KindNotFound,
+		msg:  fmt.Sprintf(`product type "%s" does not exist`, name),
+	}
+}
+
+// productTypeExistsError returns an error stating that a product type with the given name already exists.
+func productTypeExistsError(name string) error {
+	return &Error{
+		Kind: KindAlreadyExists,
+		msg:  fmt.Sprintf(`product type "%s" already exists`, name),
+	}
+}
+
+// productTypeInUseError returns an error stating that the product type with the given name
+// cannot be deleted, as products use it.
+func productTypeInUseError(name string) error {
+	return &Error{
+		Kind: KindConflict,
+		msg:  fmt.Sprintf(`product type "%s" is used by products`, name),
+	}
+}
+
+// writesAttributes reports whether the normalized update mask paths write
+// the type or the attributes of a product, which must then be checked again.
+func writesAttributes(paths []string) bool {
+	for _, path := range paths {
+		if path == productTypeField || path == attributesField || strings.HasPrefix(path, attributesField+".") {
+			return true
+		}
+	}
+	return false
+}
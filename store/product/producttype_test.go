@@ -0,0 +1,265 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestValidateProductType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         *models.ProductType
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			input: &models.ProductType{
+				Name: "shirt",
+				Attributes: map[string]models.AttributeSchema{
+					"size":  {Type: models.AttributeString, Required: true, AllowedValues: []interface{}{"S", "M", "L"}},
+					"color": {Type: models.AttributeString, Pattern: "^[a-z]+$"},
+					"chest": {Type: models.AttributeInteger, Minimum: float64Ptr(60), Maximum: float64Ptr(160)},
+				},
+			},
+		},
+		{
+			name:          "missing name",
+			input:         &models.ProductType{},
+			expectedError: errors.New("invalid argument: name: must not be empty"),
+		},
+		{
+			name:          "invalid name",
+			input:         &models.ProductType{Name: "Shirt"},
+			expectedError: errors.New("invalid argument: name: must be 1 to 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit"),
+		},
+		{
+			name: "invalid schemas",
+			input: &models.ProductType{
+				Name: "shirt",
+				Attributes: map[string]models.AttributeSchema{
+					"a.b":   {Type: models.AttributeString},
+					"color": {Type: models.AttributeString, Pattern: "("},
+					"fit":   {},
+					"size":  {Type: models.AttributeBool, Minimum: float64Ptr(1), Pattern: "x", AllowedValues: []interface{}{"S"}},
+					"width": {Type: models.AttributeNumber, Minimum: float64Ptr(2), Maximum: float64Ptr(1)},
+				},
+			},
+			expectedError: errors.New("invalid argument: attributes.a.b: must be a valid attribute key; " +
+				"attributes.color.pattern: must be a valid regular expression; " +
+				"attributes.fit.type: must be set; " +
+				"attributes.size: minimum and maximum only apply to numbers and integers; " +
+				"attributes.size.pattern: only applies to strings; " +
+				"attributes.size.allowed_values[0]: must be of type bool; " +
+				"attributes.width.maximum: must not be less than minimum"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProductType(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	shirt := &models.ProductType{
+		Name: "shirt",
+		Attributes: map[string]models.AttributeSchema{
+			"size":     {Type: models.AttributeString, Required: true, AllowedValues: []interface{}{"S", "M", "L"}},
+			"color":    {Type: models.AttributeString, Pattern: "^[a-z]+$"},
+			"chest":    {Type: models.AttributeInteger, Minimum: float64Ptr(60), Maximum: float64Ptr(160)},
+			"weight":   {Type: models.AttributeNumber},
+			"washable": {Type: models.AttributeBool},
+			"tags":     {Type: models.AttributeList},
+			"fabric":   {Type: models.AttributeStruct},
+		},
+	}
+	testCases := []struct {
+		name               string
+		productType        *models.ProductType
+		input              map[string]interface{}
+		expectedViolations []FieldViolation
+	}{
+		{
+			name:        "happy path",
+			productType: shirt,
+			input: map[string]interface{}{
+				"size":     "M",
+				"color":    "blue",
+				"chest":    100.0,
+				"weight":   0.25,
+				"washable": true,
+				"tags":     []interface{}{"summer"},
+				"fabric":   map[string]interface{}{"cotton": 100.0},
+			},
+		},
+		{
+			name:        "values decoded from BSON",
+			productType: shirt,
+			input: map[string]interface{}{
+				"size":   "S",
+				"chest":  int32(100),
+				"weight": int64(1),
+				"tags":   primitive.A{"summer"},
+				"fabric": primitive.D{{Key: "cotton", Value: 100.0}},
+			},
+		},
+		{
+			name:        "missing required attribute",
+			productType: shirt,
+			input:       map[string]interface{}{},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes.size", Description: "is required"},
+			},
+		},
+		{
+			name:        "invalid values",
+			productType: shirt,
+			input: map[string]interface{}{
+				"size":     "XL",
+				"color":    "Blue",
+				"chest":    100.5,
+				"weight":   "heavy",
+				"washable": "yes",
+				"tags":     "summer",
+				"fabric":   nil,
+				"sleeve":   "long",
+			},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes.chest", Description: "must be of type integer"},
+				{Field: "attributes.color", Description: "must match ^[a-z]+$"},
+				{Field: "attributes.fabric", Description: "must be of type struct"},
+				{Field: "attributes.size", Description: "must be one of the allowed values"},
+				{Field: "attributes.sleeve", Description: `is not defined by product type "shirt"`},
+				{Field: "attributes.tags", Description: "must be of type list"},
+				{Field: "attributes.washable", Description: "must be of type bool"},
+				{Field: "attributes.weight", Description: "must be of type number"},
+			},
+		},
+		{
+			name:        "out of range",
+			productType: shirt,
+			input:       map[string]interface{}{"size": "L", "chest": 59.0},
+			expectedViolations: []FieldViolation{
+				{Field: "attributes.chest", Description: "must not be less than 60"},
+			},
+		},
+		{
+			name:        "additional attributes allowed",
+			productType: &models.ProductType{Name: "misc", AllowAdditionalAttributes: true},
+			input:       map[string]interface{}{"anything": 1.0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedViolations, validateAttributes(tc.productType, tc.input))
+		})
+	}
+}
+
+func TestWritesAttributes(t *testing.T) {
+	require.False(t, writesAttributes([]string{"name", "price"}))
+	require.True(t, writesAttributes([]string{"name", "product_type"}))
+	require.True(t, writesAttributes([]string{"attributes"}))
+	require.True(t, writesAttributes([]string{"attributes.color"}))
+}
+
+// testRepositoryProductTypes checks that a repository manages product types
+// and checks the attributes of products against them.
+func testRepositoryProductTypes(t *testing.T, repo ProductRepository) {
+	ctx := context.TODO()
+	shirt := &models.ProductType{
+		Name:        "shirt",
+		Description: "shirts",
+		Attributes: map[string]models.AttributeSchema{
+			"size": {Type: models.AttributeString, Required: true, AllowedValues: []interface{}{"S", "M"}},
+		},
+	}
+	_, err := repo.CreateType(ctx, shirt)
+	require.Nil(t, err)
+	_, err = repo.CreateType(ctx, &models.ProductType{Name: "laptop", AllowAdditionalAttributes: true})
+	require.Nil(t, err)
+	_, err = repo.CreateType(ctx, &models.ProductType{Name: "shirt"})
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+	require.Equal(t, `product type "shirt" already exists`, err.Error())
+	_, err = repo.CreateType(ctx, &models.ProductType{Name: "Shirt"})
+	require.Equal(t, KindInvalidArgument, KindOf(err))
+
+	got, err := repo.GetType(ctx, "shirt")
+	require.Nil(t, err)
+	require.Equal(t, "shirt", got.Name)
+	require.Equal(t, "shirts", got.Description)
+	require.Equal(t, []interface{}{"S", "M"}, []interface{}(got.Attributes["size"].AllowedValues))
+	_, err = repo.GetType(ctx, "hat")
+	require.Equal(t, KindNotFound, KindOf(err))
+	require.Equal(t, `product type "hat" does not exist`, err.Error())
+	types, err := repo.ListTypes(ctx)
+	require.Nil(t, err)
+	require.Len(t, types, 2)
+	require.Equal(t, "laptop", types[0].Name)
+	require.Equal(t, "shirt", types[1].Name)
+
+	// Attributes are checked on creates and updates.
+	_, err = repo.Create(ctx, &models.Product{Name: "a", ProductType: "shirt", Attributes: map[string]interface{}{"size": "XL"}}, "", "")
+	require.Equal(t, "invalid argument: attributes.size: must be one of the allowed values", err.Error())
+	_, err = repo.Create(ctx, &models.Product{Name: "a", ProductType: "hat"}, "", "")
+	require.Equal(t, `invalid argument: product_type: product type "hat" does not exist`, err.Error())
+	a, err := repo.Create(ctx, &models.Product{Name: "a", ProductType: "shirt", Attributes: map[string]interface{}{"size": "S"}}, "", "")
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: a.Uuid, Attributes: map[string]interface{}{"color": "blue"}}, nil, 0)
+	require.Equal(t, `invalid argument: attributes.color: is not defined by product type "shirt"`, err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: a.Uuid}, []string{"attributes.size"}, 0)
+	require.Equal(t, "invalid argument: attributes.size: is required", err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: a.Uuid, ProductType: "laptop", Attributes: map[string]interface{}{"color": "blue"}}, nil, 0)
+	require.Nil(t, err)
+	results, err := repo.BatchCreate(ctx, []*models.Product{
+		{Name: "b", ProductType: "shirt", Attributes: map[string]interface{}{"size": "M"}},
+		{Name: "c", ProductType: "shirt"},
+	}, false)
+	require.Nil(t, err)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, "invalid argument: attributes.size: is required", results[1].Err.Error())
+
+	// Changing the schema does not check the products already written.
+	shirt.Attributes["size"] = models.AttributeSchema{Type: models.AttributeString, AllowedValues: []interface{}{"L"}}
+	_, err = repo.UpdateType(ctx, shirt)
+	require.Nil(t, err)
+	_, err = repo.UpdateType(ctx, &models.ProductType{Name: "hat"})
+	require.Equal(t, KindNotFound, KindOf(err))
+	b, err := repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: results[0].Product.Uuid})
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: b.Uuid, Name: "b2"}, nil, 0)
+	require.Nil(t, err)
+
+	// Types used by products cannot be deleted.
+	_, err = repo.DeleteType(ctx, "shirt")
+	require.Equal(t, KindConflict, KindOf(err))
+	require.Equal(t, `product type "shirt" is used by products`, err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: b.Uuid}, []string{"product_type"}, 0)
+	require.Nil(t, err)
+	deleted, err := repo.DeleteType(ctx, "shirt")
+	require.Nil(t, err)
+	require.Equal(t, "shirt", deleted.Name)
+	_, err = repo.DeleteType(ctx, "shirt")
+	require.Equal(t, KindNotFound, KindOf(err))
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+//
+// Package product provides the business logic and data operations for the product catalog.
+// It includes functions for creating, getting, updating, deleting, and listing products.
+//
+// Products are persisted through a ProductRepository, so that storage
+// backends can be plugged in and fakes can be injected in tests.
+// MongoRepository is the MongoDB backed implementation.
+package product
+
+import (
+	"context"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// ProductRepository is the interface implemented by the storage backends of the catalog.
+// Implementations return errors of type *Error for the failures callers can act upon,
+// such as products that do not exist or invalid requests.
+type ProductRepository interface {
+	// Create stores a new product, assigning its first revision and its uuid,
+	// which is productId when not empty. When requestId is not empty, retries
+	// of the request with the same request id return the product first created
+	// for IdempotencyKeyTTL, instead of creating another.
+	Create(ctx context.Context, newProduct *models.Product, productId, requestId string) (*models.Product, error)
+	// Get retrieves a product by uuid.
+	Get(ctx context.Context, req *productcatalog.GetProductRequest) (*models.Product, error)
+	// Update writes the fields of a product named by the update mask paths,
+	// optionally conditioned on its current revision.
+	Update(ctx context.Context, productToUpdate *models.Product, paths []string, expectedRevision int64) (*models.Product, error)
+	// Delete deletes a product by uuid, returning a snapshot of it.
+	Delete(ctx context.Context, req *productcatalog.DeleteProductRequest) (*models.Product, error)
+	// List lists one page of products, returning the token for the next page.
+	List(ctx context.Context, req *productcatalog.ListProductsRequest) ([]*models.Product, string, error)
+	// BatchCreate stores new products, reporting the outcome of each one.
+	// When allOrNothing is set, the first failed item fails the whole batch
+	// and no product is stored.
+	BatchCreate(ctx context.Context, newProducts []*models.Product, allOrNothing bool) ([]BatchResult, error)
+	// BatchUpdate updates products, reporting the outcome of each update,
+	// with the same all or nothing semantics as BatchCreate.
+	BatchUpdate(ctx context.Context, updates []ProductUpdate, allOrNothing bool) ([]BatchResult, error)
+	// BatchDelete deletes products, reporting the outcome of each deletion,
+	// with the same all or nothing semantics as BatchCreate.
+	BatchDelete(ctx context.Context, reqs []*productcatalog.DeleteProductRequest, allOrNothing bool) ([]BatchResult, error)
+	// BatchUpsert creates or replaces products, reporting the outcome of each one.
+	// Products with a uuid replace every field of the stored product with that uuid,
+	// or are created with it when there is none. Products without a uuid are created.
+	BatchUpsert(ctx context.Context, products []*models.Product) ([]BatchResult, error)
+	// Stream calls send with every product matching the request filter, in the
+	// requested order, as the products are read. It stops at the first error
+	// returned by send, returning it, and when ctx is done.
+	Stream(ctx context.Context, req *productcatalog.StreamProductsRequest, send func(*models.Product) error) error
+	// Watch calls send with every change made to products after the call, or after
+	// the change with the given resume token, until ctx is done or send fails.
+	// Backends that cannot resume fail with an error of kind KindInvalidArgument
+	// when given a resume token.
+	Watch(ctx context.Context, resumeToken string, send func(*Change) error) error
+	// CreateType stores a new product type.
+	CreateType(ctx context.Context, newType *models.ProductType) (*models.ProductType, error)
+	// GetType retrieves a product type by name.
+	GetType(ctx context.Context, name string) (*models.ProductType, error)
+	// ListTypes lists every product type, ordered by name.
+	ListTypes(ctx context.Context) ([]*models.ProductType, error)
+	// UpdateType replaces the product type with the same name.
+	UpdateType(ctx context.Context, productType *models.ProductType) (*models.ProductType, error)
+	// DeleteType deletes a product type by name, returning it. Types used
+	// by products cannot be deleted, failing with an error of kind KindConflict.
+	DeleteType(ctx context.Context, name string) (*models.ProductType, error)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+// The sku and slug of a product are natural keys: they are optional, but no two
+// products can have the same non-empty sku or slug, and either can be used
+// instead of the uuid to get a product.
+
+// uniqueFields are the fields whose non-empty values identify a product.
+var uniqueFields = []string{"sku", "slug"}
+
+var (
+	// skuPattern is the format of skus: up to 64 letters, digits, dots,
+	// underscores or hyphens, starting with a letter or digit.
+	skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+	// slugPattern is the format of slugs: up to 128 lowercase letters or digits,
+	// in words separated by single hyphens.
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// maxSlugLength is the maximum length of a slug.
+const maxSlugLength = 128
+
+// uniqueIndexName returns the name MongoDB gives to the unique index on a field,
+// which its duplicate key errors mention.
+func uniqueIndexName(field string) string {
+	return field + "_1"
+}
+
+// uniqueValue returns the value of a unique field of a product.
+func uniqueValue(p *models.Product, field string) string {
+	if field == "sku" {
+		return p.Sku
+	}
+	return p.Slug
+}
+
+// validateUniqueValue checks the format of the value of a unique field, which may be empty.
+func validateUniqueValue(field, value string) []FieldViolation {
+	switch {
+	case value == "":
+		return nil
+	case field == "sku" && !skuPattern.MatchString(value):
+		return []FieldViolation{{
+			Field:       "sku",
+			Description: "must be up to 64 letters, digits, dots, underscores or hyphens, starting with a letter or digit",
+		}}
+	case field == "slug" && (len(value) > maxSlugLength || !slugPattern.MatchString(value)):
+		return []FieldViolation{{
+			Field:       "slug",
+			Description: "must be up to 128 lowercase letters or digits, in words separated by single hyphens",
+		}}
+	}
+	return nil
+}
+
+// productKey returns the field and value identifying the product of a get request,
+// which must have exactly one of uuid, sku and slug.
+func productKey(req *productcatalog.GetProductRequest) (string, string, error) {
+	keys := [][2]string{{uuidField, req.GetUuid()}, {"sku", req.GetSku()}, {"slug", req.GetSlug()}}
+	var key [2]string
+	for _, k := range keys {
+		if k[1] == "" {
+			continue
+		}
+		if key[1] != "" {
+			return "", "", invalidArgumentError(FieldViolation{Field: k[0], Description: "must not be set along with " + key[0]})
+		}
+		key = k
+	}
+	if key[1] == "" {
+		return "", "", validateUuid("")
+	}
+	if key[0] == uuidField {
+		if err := validateUuid(key[1]); err != nil {
+			return "", "", err
+		}
+	}
+	return key[0], key[1], nil
+}
+
+// keyNotFoundError returns an error stating that no product has the given value of a key.
+func keyNotFoundError(field, value string) error {
+	if field == uuidField {
+		return notFoundError(value)
+	}
+	return &Error{
+		Kind:     KindNotFound,
+		KeyField: field,
+		Key:      value,
+		msg:      fmt.Sprintf(`product with %s "%s" does not exist`, field, value),
+	}
+}
+
+// uniqueValueTakenError returns an error stating that the product with the given uuid
+// cannot have the given value of a unique field, as another product already has it.
+func uniqueValueTakenError(uuid, field, value string) error {
+	return &Error{
+		Kind:     KindAlreadyExists,
+		Uuid:     uuid,
+		KeyField: field,
+		Key:      value,
+		msg:      fmt.Sprintf(`product with %s "%s" already exists`, field, value),
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/api/proto/gen/productcatalog"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+)
+
+func TestValidateUniqueValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		field    string
+		value    string
+		expected bool
+	}{
+		{name: "empty sku", field: "sku", value: "", expected: true},
+		{name: "sku", field: "sku", value: "AB-12.x_3", expected: true},
+		{name: "longest sku", field: "sku", value: strings.Repeat("a", 64), expected: true},
+		{name: "sku too long", field: "sku", value: strings.Repeat("a", 65)},
+		{name: "sku starting with a hyphen", field: "sku", value: "-ab"},
+		{name: "sku with spaces", field: "sku", value: "a b"},
+		{name: "empty slug", field: "slug", value: "", expected: true},
+		{name: "slug", field: "slug", value: "blue-shirt-2", expected: true},
+		{name: "longest slug", field: "slug", value: strings.Repeat("a", 128), expected: true},
+		{name: "slug too long", field: "slug", value: strings.Repeat("a", 129)},
+		{name: "slug with uppercase letters", field: "slug", value: "Blue-shirt"},
+		{name: "slug with repeated hyphens", field: "slug", value: "blue--shirt"},
+		{name: "slug ending with a hyphen", field: "slug", value: "blue-"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := validateUniqueValue(tc.field, tc.value)
+			if tc.expected {
+				require.Empty(t, violations)
+			} else {
+				require.Len(t, violations, 1)
+				require.Equal(t, tc.field, violations[0].Field)
+			}
+		})
+	}
+}
+
+func TestProductKey(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         *productcatalog.GetProductRequest
+		expectedField string
+		expectedValue string
+		expectedError error
+	}{
+		{
+			name:          "uuid",
+			input:         &productcatalog.GetProductRequest{Uuid: "uuid"},
+			expectedField: "uuid",
+			expectedValue: "uuid",
+		},
+		{
+			name:          "sku",
+			input:         &productcatalog.GetProductRequest{Sku: "SKU-1"},
+			expectedField: "sku",
+			expectedValue: "SKU-1",
+		},
+		{
+			name:          "slug",
+			input:         &productcatalog.GetProductRequest{Slug: "my-product"},
+			expectedField: "slug",
+			expectedValue: "my-product",
+		},
+		{
+			name:          "no key",
+			input:         &productcatalog.GetProductRequest{},
+			expectedError: errors.New("invalid argument: uuid: must not be empty"),
+		},
+		{
+			name:          "several keys",
+			input:         &productcatalog.GetProductRequest{Sku: "SKU-1", Slug: "my-product"},
+			expectedError: errors.New("invalid argument: slug: must not be set along with sku"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			field, value, err := productKey(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedField, field)
+				require.Equal(t, tc.expectedValue, value)
+			}
+		})
+	}
+}
+
+// testRepositoryUniqueness checks that a repository keeps skus and slugs unique,
+// and gets products by them.
+func testRepositoryUniqueness(t *testing.T, repo ProductRepository) {
+	ctx := context.TODO()
+	a, err := repo.Create(ctx, &models.Product{Name: "a", Sku: "SKU-A", Slug: "a"}, "", "")
+	require.Nil(t, err)
+	b, err := repo.Create(ctx, &models.Product{Name: "b"}, "", "")
+	require.Nil(t, err)
+
+	p, err := repo.Get(ctx, &productcatalog.GetProductRequest{Sku: "SKU-A"})
+	require.Nil(t, err)
+	require.Equal(t, a, p)
+	p, err = repo.Get(ctx, &productcatalog.GetProductRequest{Slug: "a"})
+	require.Nil(t, err)
+	require.Equal(t, a, p)
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Sku: "SKU-B"})
+	require.Equal(t, KindNotFound, KindOf(err))
+	require.Equal(t, `product with sku "SKU-B" does not exist`, err.Error())
+	var perr *Error
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, "", perr.Uuid)
+	require.Equal(t, "sku", perr.KeyField)
+	require.Equal(t, "SKU-B", perr.Key)
+
+	_, err = repo.Create(ctx, &models.Product{Name: "c", Sku: "SKU-A"}, "", "")
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+	require.Equal(t, `product with sku "SKU-A" already exists`, err.Error())
+	_, err = repo.Update(ctx, &models.Product{Uuid: b.Uuid, Slug: "a"}, nil, 0)
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+	require.Equal(t, `product with slug "a" already exists`, err.Error())
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, b.Uuid, perr.Uuid)
+	require.Equal(t, "slug", perr.KeyField)
+	require.Equal(t, "a", perr.Key)
+	p, err = repo.Get(ctx, &productcatalog.GetProductRequest{Uuid: b.Uuid})
+	require.Nil(t, err)
+	require.Equal(t, b, p)
+
+	// A product keeps its own keys, and frees the ones it changes or clears.
+	_, err = repo.Update(ctx, &models.Product{Uuid: a.Uuid, Sku: "SKU-A", Slug: "a-2"}, nil, 0)
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: b.Uuid, Slug: "a"}, nil, 0)
+	require.Nil(t, err)
+	_, err = repo.Update(ctx, &models.Product{Uuid: a.Uuid}, []string{"sku"}, 0)
+	require.Nil(t, err)
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Sku: "SKU-A"})
+	require.Equal(t, KindNotFound, KindOf(err))
+	_, err = repo.Create(ctx, &models.Product{Name: "c", Sku: "SKU-A"}, "", "")
+	require.Nil(t, err)
+
+	// Deleted products free their keys.
+	_, err = repo.Delete(ctx, &productcatalog.DeleteProductRequest{Uuid: b.Uuid})
+	require.Nil(t, err)
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Slug: "a"})
+	require.Equal(t, KindNotFound, KindOf(err))
+
+	// Within a batch, the item that takes a key first wins.
+	results, err := repo.BatchCreate(ctx, []*models.Product{{Name: "d", Slug: "d"}, {Name: "e", Slug: "d"}}, false)
+	require.Nil(t, err)
+	require.Nil(t, results[0].Err)
+	require.Equal(t, KindAlreadyExists, KindOf(results[1].Err))
+	_, err = repo.BatchCreate(ctx, []*models.Product{{Name: "f", Slug: "f"}, {Name: "g", Slug: "a-2"}}, true)
+	require.Equal(t, KindAlreadyExists, KindOf(err))
+	_, err = repo.Get(ctx, &productcatalog.GetProductRequest{Slug: "f"})
+	require.Equal(t, KindNotFound, KindOf(err))
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Update masks follow AIP-134. The supported paths are:
+//
+//	name, description, prices the top level fields
+//	sku, slug                 the natural keys, which an empty value clears
+//	product_type              the product type, which an empty value clears
+//	attributes                replaces the whole attributes map
+//	attributes.<key>          sets a single attribute, or removes it if the product does not have it
+//	*                         replaces every updatable field
+//
+// When no path is given, the mask is implied from the populated fields
+// of the product, with each attribute expanded to its own path,
+// so that omitted fields and attributes are left untouched.
+
+// wildcardPath is the update mask path that replaces every updatable field.
+const wildcardPath = "*"
+
+// updatableFields are the top level fields that can be named in an update mask.
+var updatableFields = []string{"name", "description", pricesField, "sku", "slug", productTypeField, attributesField}
+
+// prepareUpdate validates the product and update mask of an update request,
+// returning the normalized paths to write.
+func prepareUpdate(p *models.Product, paths []string) ([]string, error) {
+	if err := validateUuid(p.Uuid); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		// Every attribute is implied, and keys that are not valid would be reported
+		// as invalid update mask paths otherwise.
+		if violations := validateAttributeKeys(p.Attributes); len(violations) > 0 {
+			return nil, invalidArgumentError(violations...)
+		}
+		paths = impliedUpdateMask(p)
+	}
+	paths, err := normalizeUpdateMask(paths)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMaskedFields(p, paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// impliedUpdateMask returns the paths of the populated fields of a product.
+func impliedUpdateMask(p *models.Product) []string {
+	var paths []string
+	if p.Name != "" {
+		paths = append(paths, "name")
+	}
+	if p.Description != "" {
+		paths = append(paths, "description")
+	}
+	if len(p.Prices) > 0 {
+		paths = append(paths, pricesField)
+	}
+	for _, field := range uniqueFields {
+		if uniqueValue(p, field) != "" {
+			paths = append(paths, field)
+		}
+	}
+	if p.ProductType != "" {
+		paths = append(paths, productTypeField)
+	}
+	keys := make([]string, 0, len(p.Attributes))
+	for k := range p.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		paths = append(paths, attributesField+"."+k)
+	}
+	return paths
+}
+
+// TrimUpdateMask trims the surrounding spaces of the paths of an update mask,
+// removing duplicates and rejecting empty paths. Paths are written as it returns
+// them, so that whoever checks the fields an update writes must check these.
+func TrimUpdateMask(paths []string) ([]string, error) {
+	var trimmed []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return nil, updateMaskError("empty path")
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		trimmed = append(trimmed, path)
+	}
+	return trimmed, nil
+}
+
+// normalizeUpdateMask validates the paths of an update mask,
+// expanding the wildcard and removing duplicates.
+func normalizeUpdateMask(paths []string) ([]string, error) {
+	paths, err := TrimUpdateMask(paths)
+	if err != nil {
+		return nil, err
+	}
+	var normalized []string
+	seen := make(map[string]bool)
+	wholeAttributes, singleAttribute := false, false
+	for _, path := range paths {
+		var expanded []string
+		switch {
+		case path == wildcardPath:
+			expanded = updatableFields
+		case strings.HasPrefix(path, attributesField+"."):
+			key := strings.TrimPrefix(path, attributesField+".")
+			if key == "" || strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+				return nil, updateMaskError("invalid attribute path %q", path)
+			}
+			expanded = []string{path}
+		default:
+			if !isUpdatableField(path) {
+				return nil, updateMaskError("unknown or immutable field %q", path)
+			}
+			expanded = []string{path}
+		}
+		for _, p := range expanded {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if p == attributesField {
+				wholeAttributes = true
+			} else if strings.HasPrefix(p, attributesField+".") {
+				singleAttribute = true
+			}
+			normalized = append(normalized, p)
+		}
+	}
+	if wholeAttributes && singleAttribute {
+		return nil, updateMaskError("%q cannot be combined with paths of individual attributes", attributesField)
+	}
+	return normalized, nil
+}
+
+// isUpdatableField reports whether the top level field can be named in an update mask.
+func isUpdatableField(field string) bool {
+	for _, f := range updatableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// updateMaskError returns an invalid argument error for the update_mask field.
+func updateMaskError(format string, args ...interface{}) error {
+	return invalidArgumentError(FieldViolation{Field: "update_mask", Description: fmt.Sprintf(format, args...)})
+}
+
+// validateMaskedFields checks the fields of a product that are about to be written.
+func validateMaskedFields(p *models.Product, paths []string) error {
+	var violations []FieldViolation
+	var attributes map[string]interface{}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			if strings.TrimSpace(p.Name) == "" {
+				violations = append(violations, FieldViolation{Field: "name", Description: "must not be empty"})
+			}
+		case pricesField:
+			violations = append(violations, validatePrices(p.Prices)...)
+		case "sku", "slug":
+			violations = append(violations, validateUniqueValue(path, uniqueValue(p, path))...)
+		case productTypeField:
+			if p.ProductType != "" {
+				violations = append(violations, validateProductTypeName(productTypeField, p.ProductType)...)
+			}
+		case attributesField:
+			attributes = p.Attributes
+		default:
+			if !strings.HasPrefix(path, attributesField+".") {
+				break
+			}
+			// Only the attributes written are checked here, and the limits on all
+			// of them once merged, by checkUpdatedAttributes.
+			key := strings.TrimPrefix(path, attributesField+".")
+			if v, ok := p.Attributes[key]; ok {
+				if attributes == nil {
+					attributes = map[string]interface{}{}
+				}
+				attributes[key] = v
+			}
+		}
+	}
+	violations = append(violations, validateAttributeKeys(attributes)...)
+	if len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return nil
+}
+
+// updateDocument builds the MongoDB update that writes the masked paths of a product
+// and increments its revision.
+// Attributes named in the mask but missing from the product are removed.
+func updateDocument(p *models.Product, paths []string) bson.M {
+	set, unset := bson.M{}, bson.M{}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			set["name"] = p.Name
+		case "description":
+			set["description"] = p.Description
+		case pricesField:
+			prices := p.Prices
+			if prices == nil {
+				prices = []models.Money{}
+			}
+			set[pricesField] = prices
+			set["price"] = legacyPrice(prices)
+		case "sku", "slug":
+			set[path] = uniqueValue(p, path)
+		case productTypeField:
+			set[productTypeField] = p.ProductType
+		case attributesField:
+			attributes := p.Attributes
+			if attributes == nil {
+				attributes = map[string]interface{}{}
+			}
+			set[attributesField] = attributes
+		default:
+			key := strings.TrimPrefix(path, attributesField+".")
+			if v, ok := p.Attributes[key]; ok {
+				set[path] = v
+			} else {
+				unset[path] = ""
+			}
+		}
+	}
+	update := bson.M{"$inc": bson.M{"revision": 1}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// checkUpdatedAttributes checks the attributes of a product as an update leaves them,
+// given the normalized paths it wrote. Updates of single attributes only had the
+// attributes they write checked, so the limits on the number and size of attributes
+// are checked against the merged attributes, along with the schema of the product type.
+func checkUpdatedAttributes(p *models.Product, paths []string, productType func(name string) (*models.ProductType, error)) error {
+	if !writesAttributes(paths) {
+		return nil
+	}
+	if violations := validateAttributeKeys(p.Attributes); len(violations) > 0 {
+		return invalidArgumentError(violations...)
+	}
+	return checkProductType(p, productType)
+}
+
+// applyUpdate writes the masked paths of src into dst and increments its revision,
+// the same way the document built by updateDocument does.
+func applyUpdate(dst, src *models.Product, paths []string) {
+	for _, path := range paths {
+		switch path {
+		case "name":
+			dst.Name = src.Name
+		case "description":
+			dst.Description = src.Description
+		case pricesField:
+			dst.Prices = append([]models.Money{}, src.Prices...)
+			dst.Price = legacyPrice(src.Prices)
+		case "sku":
+			dst.Sku = src.Sku
+		case "slug":
+			dst.Slug = src.Slug
+		case productTypeField:
+			dst.ProductType = src.ProductType
+		case attributesField:
+			dst.Attributes = cloneAttributes(src.Attributes)
+			if dst.Attributes == nil {
+				dst.Attributes = map[string]interface{}{}
+			}
+		default:
+			key := strings.TrimPrefix(path, attributesField+".")
+			if v, ok := src.Attributes[key]; ok {
+				if dst.Attributes == nil {
+					dst.Attributes = map[string]interface{}{}
+				}
+				dst.Attributes[key] = cloneValue(v)
+			} else {
+				delete(dst.Attributes, key)
+			}
+		}
+	}
+	dst.Revision++
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package product
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiagomelo/golang-grpc-mongodb-arbitrary-data/store/product/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestImpliedUpdateMask(t *testing.T) {
+	p := &models.Product{
+		Uuid:        "uuid",
+		Description: "description",
+		Slug:        "slug",
+		Attributes: map[string]interface{}{
+			"size":  12.0,
+			"color": "blue",
+		},
+	}
+	require.Equal(t, []string{"description", "slug", "attributes.color", "attributes.size"}, impliedUpdateMask(p))
+	require.Empty(t, impliedUpdateMask(&models.Product{Uuid: "uuid"}))
+}
+
+func TestNormalizeUpdateMask(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          []string
+		expectedOutput []string
+		expectedError  error
+	}{
+		{
+			name:           "top level and attribute paths",
+			input:          []string{"name", " attributes.color ", "name"},
+			expectedOutput: []string{"name", "attributes.color"},
+		},
+		{
+			name:           "wildcard",
+			input:          []string{"*", "name"},
+			expectedOutput: []string{"name", "description", "prices", "sku", "slug", "product_type", "attributes"},
+		},
+		{
+			name:          "empty path",
+			input:         []string{"name", " "},
+			expectedError: errors.New(`invalid argument: update_mask: empty path`),
+		},
+		{
+			name:          "immutable field",
+			input:         []string{"uuid"},
+			expectedError: errors.New(`invalid argument: update_mask: unknown or immutable field "uuid"`),
+		},
+		{
+			name:          "nested attribute path",
+			input:         []string{"attributes.dimensions.height"},
+			expectedError: errors.New(`invalid argument: update_mask: invalid attribute path "attributes.dimensions.height"`),
+		},
+		{
+			name:          "operator in attribute path",
+			input:         []string{"attributes.$set"},
+			expectedError: errors.New(`invalid argument: update_mask: invalid attribute path "attributes.$set"`),
+		},
+		{
+			name:          "whole and individual attributes",
+			input:         []string{"attributes", "attributes.color"},
+			expectedError: errors.New(`invalid argument: update_mask: "attributes" cannot be combined with paths of individual attributes`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := normalizeUpdateMask(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestPrepareUpdate(t *testing.T) {
+	const id = "b6d6a9a4-6a6b-4f5e-9c3c-5f1e0d2b7a10"
+	testCases := []struct {
+		name           string
+		input          *models.Product
+		paths          []string
+		expectedOutput []string
+		expectedError  error
+	}{
+		{
+			name:           "implied mask",
+			input:          &models.Product{Uuid: id, Name: "name", Attributes: map[string]interface{}{"color": "blue"}},
+			expectedOutput: []string{"name", "attributes.color"},
+		},
+		{
+			name:          "implied mask with a reserved key",
+			input:         &models.Product{Uuid: id, Attributes: map[string]interface{}{"a.b": "blue"}},
+			expectedError: errors.New(`invalid argument: attributes: key "a.b" must not be empty, start with "$", or contain "." or NUL characters`),
+		},
+		{
+			name:           "attributes not written are not checked",
+			input:          &models.Product{Uuid: id, Attributes: map[string]interface{}{"color": "blue", "$x": 1.0}},
+			paths:          []string{"attributes.color"},
+			expectedOutput: []string{"attributes.color"},
+		},
+		{
+			name:          "attributes written are checked",
+			input:         &models.Product{Uuid: id, Attributes: map[string]interface{}{"dimensions": map[string]interface{}{"$x": 1.0}}},
+			paths:         []string{"attributes.dimensions"},
+			expectedError: errors.New(`invalid argument: attributes.dimensions: key "$x" must not be empty, start with "$", or contain "." or NUL characters`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := prepareUpdate(tc.input, tc.paths)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestUpdateDocument(t *testing.T) {
+	p := &models.Product{
+		Name:   "name",
+		Prices: usd("2"),
+		Sku:    "sku",
+		Attributes: map[string]interface{}{
+			"color": "blue",
+		},
+	}
+	require.Equal(t, bson.M{
+		"$set":   bson.M{"name": "name", "prices": usd("2"), "price": float32(2), "sku": "sku", "attributes.color": "blue"},
+		"$unset": bson.M{"attributes.size": ""},
+		"$inc":   bson.M{"revision": 1},
+	}, updateDocument(p, []string{"name", "prices", "sku", "attributes.color", "attributes.size"}))
+	require.Equal(t, bson.M{
+		"$set": bson.M{"description": "", "prices": []models.Money{}, "price": float32(0), "attributes": map[string]interface{}{}},
+		"$inc": bson.M{"revision": 1},
+	}, updateDocument(&models.Product{}, []string{"description", "prices", "attributes"}))
+}
+
+func TestApplyUpdate(t *testing.T) {
+	dst := &models.Product{
+		Uuid:     "uuid",
+		Name:     "name",
+		Price:    1,
+		Prices:   usd("1"),
+		Revision: 3,
+		Attributes: map[string]interface{}{
+			"color": "blue",
+			"size":  12.0,
+		},
+	}
+	src := &models.Product{
+		Name: "new name",
+		Sku:  "sku",
+		Slug: "slug",
+		Attributes: map[string]interface{}{
+			"color": "red",
+		},
+	}
+	applyUpdate(dst, src, []string{"name", "sku", "slug", "attributes.color", "attributes.size"})
+	require.Equal(t, &models.Product{
+		Uuid:     "uuid",
+		Name:     "new name",
+		Price:    1,
+		Prices:   usd("1"),
+		Sku:      "sku",
+		Slug:     "slug",
+		Revision: 4,
+		Attributes: map[string]interface{}{
+			"color": "red",
+		},
+	}, dst)
+
+	applyUpdate(dst, &models.Product{}, []string{"prices", "sku", "attributes"})
+	require.Equal(t, &models.Product{
+		Uuid:       "uuid",
+		Name:       "new name",
+		Slug:       "slug",
+		Prices:     []models.Money{},
+		Revision:   5,
+		Attributes: map[string]interface{}{},
+	}, dst)
+}
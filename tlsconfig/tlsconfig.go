@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+//
+// Package tlsconfig provides the TLS configuration of the gRPC listener.
+// The server certificate, and the certificate authorities verifying client
+// certificates for mutual TLS, are read from files that are reloaded when they
+// change on disk, so that certificates can be rotated without restarting.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Client authentication modes.
+const (
+	// ClientAuthNone does not ask clients for certificates.
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies the certificates clients present,
+	// but accepts clients presenting none.
+	ClientAuthOptional = "optional"
+	// ClientAuthRequire only accepts clients presenting a valid certificate.
+	ClientAuthRequire = "require"
+)
+
+// clientAuthTypes maps the client authentication modes to their tls.ClientAuthType.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	ClientAuthNone:     tls.NoClientCert,
+	ClientAuthOptional: tls.VerifyClientCertIfGiven,
+	ClientAuthRequire:  tls.RequireAndVerifyClientCert,
+}
+
+// checkInterval is how often, at most, the files are checked for changes.
+const checkInterval = time.Second
+
+// Config holds the files of the TLS configuration.
+type Config struct {
+	// CertFile and KeyFile hold the PEM encoded certificate chain and private key of the server.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM encoded certificates of the authorities
+	// verifying client certificates. Required unless ClientAuth is ClientAuthNone.
+	ClientCAFile string
+	// ClientAuth is the client authentication mode, ClientAuthNone when empty.
+	ClientAuth string
+	// OnReload, when set, is called after reloading the files that changed,
+	// with the error that made the previous configuration be kept, if any.
+	OnReload func(err error)
+}
+
+// fileState is what tells whether a file changed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloader serves a TLS configuration, reloading its files when they change.
+// Files are checked for changes on handshakes, at most once every second.
+// When reloading fails, as when a certificate was replaced but not yet its key,
+// the previous configuration is kept, and reloading retried on later handshakes.
+type Reloader struct {
+	cfg        Config
+	clientAuth tls.ClientAuthType
+	interval   time.Duration
+
+	mu        sync.Mutex
+	current   *tls.Config
+	states    []fileState
+	lastCheck time.Time
+}
+
+// New creates a Reloader of the given configuration, failing when its files
+// cannot be loaded.
+func New(cfg Config) (*Reloader, error) {
+	if cfg.ClientAuth == "" {
+		cfg.ClientAuth = ClientAuthNone
+	}
+	clientAuth, ok := clientAuthTypes[cfg.ClientAuth]
+	if !ok {
+		return nil, errors.Errorf(`unknown client authentication mode "%s", must be %s, %s or %s`,
+			cfg.ClientAuth, ClientAuthNone, ClientAuthOptional, ClientAuthRequire)
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both a certificate and a key file are required")
+	}
+	if clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.Errorf(`client authentication mode "%s" requires a client CA file`, cfg.ClientAuth)
+	}
+	r := &Reloader{cfg: cfg, clientAuth: clientAuth, interval: checkInterval}
+	states, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(states); err != nil {
+		return nil, err
+	}
+	r.lastCheck = time.Now()
+	return r, nil
+}
+
+// files returns the files of the configuration.
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// stat returns the state of the files of the configuration.
+func (r *Reloader) stat() ([]fileState, error) {
+	var states []fileState
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", f)
+		}
+		states = append(states, fileState{modTime: info.ModTime(), size: info.Size()})
+	}
+	return states, nil
+}
+
+// load loads the files of the configuration, in the given states.
+func (r *Reloader) load(states []fileState) error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "loading certificate and key")
+	}
+	c := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2"},
+	}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "reading client CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client CA file holds no PEM encoded certificate")
+		}
+		c.ClientCAs = pool
+	}
+	r.current, r.states = c, states
+	return nil
+}
+
+// changed reports whether the files are in other states than the given ones.
+func changed(previous, current []fileState) bool {
+	for i := range current {
+		if !current[i].modTime.Equal(previous[i].modTime) || current[i].size != previous[i].size {
+			return true
+		}
+	}
+	return false
+}
+
+// reload reloads the files when they changed since they were last loaded,
+// and returns the current configuration.
+func (r *Reloader) reload() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastCheck) < r.interval {
+		return r.current
+	}
+	r.lastCheck = time.Now()
+	states, err := r.stat()
+	if err == nil {
+		if !changed(r.states, states) {
+			return r.current
+		}
+		err = r.load(states)
+	}
+	if r.cfg.OnReload != nil {
+		r.cfg.OnReload(err)
+	}
+	return r.current
+}
+
+// TLSConfig returns the TLS configuration of the listener,
+// which serves the current configuration to every handshake.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.reload(), nil
+		},
+	}
+}
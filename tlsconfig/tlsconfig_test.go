@@ -0,0 +1,301 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// authority is a certificate authority issuing test certificates.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newAuthority returns a new certificate authority.
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM encoded certificate and key of the given serial number and common name.
+func (a *authority) issue(t *testing.T, serial int64, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.Nil(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes a file, with a modification time telling it changed.
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.Nil(t, os.WriteFile(path, data, 0o600))
+	require.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+// files writes the files of a configuration to a temporary directory.
+func files(t *testing.T, ca *authority) Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := Config{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	certPEM, keyPEM := ca.issue(t, 2, "localhost", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM, time.Now())
+	writeFile(t, cfg.KeyFile, keyPEM, time.Now())
+	writeFile(t, cfg.ClientCAFile, ca.pem, time.Now())
+	return cfg
+}
+
+// handshake performs a TLS handshake with a listener of the reloader,
+// returning the certificate of the server.
+func handshake(t *testing.T, r *Reloader, ca *authority, clientCerts ...tls.Certificate) (*x509.Certificate, error) {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", r.TLSConfig())
+	require.Nil(t, err)
+	defer lis.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: clientCerts,
+	})
+	if err != nil {
+		<-serverErr
+		return nil, err
+	}
+	defer conn.Close()
+	// With TLS 1.3, client certificates are verified after the client handshake completes.
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestNew(t *testing.T) {
+	ca := newAuthority(t)
+	valid := files(t, ca)
+	dir := t.TempDir()
+	otherKey := filepath.Join(dir, "other.key")
+	_, keyPEM := ca.issue(t, 3, "localhost", x509.ExtKeyUsageServerAuth)
+	writeFile(t, otherKey, keyPEM, time.Now())
+	notPEM := filepath.Join(dir, "ca.txt")
+	writeFile(t, notPEM, []byte("not a certificate"), time.Now())
+	testCases := []struct {
+		name          string
+		input         Config
+		expectedError error
+	}{
+		{
+			name:  "happy path",
+			input: valid,
+		},
+		{
+			name:  "mutual TLS",
+			input: Config{CertFile: valid.CertFile, KeyFile: valid.KeyFile, ClientCAFile: valid.ClientCAFile, ClientAuth: ClientAuthRequire},
+		},
+		{
+			name:          "unknown client authentication mode",
+			input:         Config{CertFile: valid.CertFile, KeyFile: valid.KeyFile, ClientAuth: "always"},
+			expectedError: errors.New(`unknown client authentication mode "always", must be none, optional or require`),
+		},
+		{
+			name:          "no key file",
+			input:         Config{CertFile: valid.CertFile},
+			expectedError: errors.New("both a certificate and a key file are required"),
+		},
+		{
+			name:          "client authentication without client CA",
+			input:         Config{CertFile: valid.CertFile, KeyFile: valid.KeyFile, ClientAuth: ClientAuthOptional},
+			expectedError: errors.New(`client authentication mode "optional" requires a client CA file`),
+		},
+		{
+			name:          "missing file",
+			input:         Config{CertFile: valid.CertFile, KeyFile: filepath.Join(dir, "missing.key")},
+			expectedError: errors.New("reading " + filepath.Join(dir, "missing.key") + ": stat " + filepath.Join(dir, "missing.key") + ": no such file or directory"),
+		},
+		{
+			name:          "key of another certificate",
+			input:         Config{CertFile: valid.CertFile, KeyFile: otherKey},
+			expectedError: errors.New("loading certificate and key: tls: private key does not match public key"),
+		},
+		{
+			name:          "client CA file without certificates",
+			input:         Config{CertFile: valid.CertFile, KeyFile: valid.KeyFile, ClientCAFile: notPEM},
+			expectedError: errors.New("client CA file holds no PEM encoded certificate"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.input)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else if tc.expectedError != nil {
+				t.Fatalf("expected error %v, got nil", tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ca := newAuthority(t)
+	cfg := files(t, ca)
+	var reloadErrs []error
+	cfg.OnReload = func(err error) {
+		reloadErrs = append(reloadErrs, err)
+	}
+	r, err := New(cfg)
+	require.Nil(t, err)
+	r.interval = 0
+
+	cert, err := handshake(t, r, ca)
+	require.Nil(t, err)
+	require.Equal(t, int64(2), cert.SerialNumber.Int64())
+	require.Empty(t, reloadErrs)
+
+	// A rotated certificate is served from the next handshake on.
+	certPEM, keyPEM := ca.issue(t, 4, "localhost", x509.ExtKeyUsageServerAuth)
+	later := time.Now().Add(time.Minute)
+	writeFile(t, cfg.CertFile, certPEM, later)
+	writeFile(t, cfg.KeyFile, keyPEM, later)
+	cert, err = handshake(t, r, ca)
+	require.Nil(t, err)
+	require.Equal(t, int64(4), cert.SerialNumber.Int64())
+	require.Equal(t, []error{nil}, reloadErrs)
+
+	// While the certificate does not match its key, the previous one is kept.
+	certPEM, _ = ca.issue(t, 5, "localhost", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM, later.Add(time.Minute))
+	cert, err = handshake(t, r, ca)
+	require.Nil(t, err)
+	require.Equal(t, int64(4), cert.SerialNumber.Int64())
+	require.Len(t, reloadErrs, 2)
+	require.Equal(t, "loading certificate and key: tls: private key does not match public key", reloadErrs[1].Error())
+
+	// Files are only checked once per interval.
+	r.interval = time.Hour
+	certPEM, keyPEM = ca.issue(t, 6, "localhost", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM, later.Add(2*time.Minute))
+	writeFile(t, cfg.KeyFile, keyPEM, later.Add(2*time.Minute))
+	cert, err = handshake(t, r, ca)
+	require.Nil(t, err)
+	require.Equal(t, int64(4), cert.SerialNumber.Int64())
+}
+
+func TestClientAuth(t *testing.T) {
+	ca := newAuthority(t)
+	cfg := files(t, ca)
+	clientCertPEM, clientKeyPEM := ca.issue(t, 7, "importer", x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.Nil(t, err)
+	otherCA := newAuthority(t)
+	otherCertPEM, otherKeyPEM := otherCA.issue(t, 8, "importer", x509.ExtKeyUsageClientAuth)
+	otherCert, err := tls.X509KeyPair(otherCertPEM, otherKeyPEM)
+	require.Nil(t, err)
+	testCases := []struct {
+		name          string
+		clientAuth    string
+		clientCerts   []tls.Certificate
+		expectedError bool
+	}{
+		{
+			name:       "no client certificate, none required",
+			clientAuth: ClientAuthNone,
+		},
+		{
+			name:       "no client certificate, optional",
+			clientAuth: ClientAuthOptional,
+		},
+		{
+			name:        "client certificate, optional",
+			clientAuth:  ClientAuthOptional,
+			clientCerts: []tls.Certificate{clientCert},
+		},
+		{
+			name:          "client certificate of another authority, optional",
+			clientAuth:    ClientAuthOptional,
+			clientCerts:   []tls.Certificate{otherCert},
+			expectedError: true,
+		},
+		{
+			name:        "client certificate, required",
+			clientAuth:  ClientAuthRequire,
+			clientCerts: []tls.Certificate{clientCert},
+		},
+		{
+			name:          "no client certificate, required",
+			clientAuth:    ClientAuthRequire,
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg.ClientAuth = tc.clientAuth
+			r, err := New(cfg)
+			require.Nil(t, err)
+			_, err = handshake(t, r, ca, tc.clientCerts...)
+			require.Equal(t, tc.expectedError, err != nil, "handshake error: %v", err)
+		})
+	}
+}